@@ -0,0 +1,286 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package anticheat
+
+import (
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Actions taken on the pops that flag a player
+const (
+	// ActionWarn records the flag and still scores the pops
+	ActionWarn = "warn"
+	// ActionIgnore records the flag and does not score the pops
+	ActionIgnore = "ignore"
+	// ActionDisconnect records the flag and closes the connection of the player
+	ActionDisconnect = "disconnect"
+)
+
+// Reasons a player is flagged
+const (
+	ReasonRateLimited   = "rate_limited"
+	ReasonTooFast       = "pops_faster_than_spawn"
+	ReasonFavoriteRatio = "favorite_ratio"
+)
+
+// Defaults of the anti-cheat config
+const (
+	DefaultRate          = 5.0
+	DefaultBurst         = 10
+	DefaultFavoriteRatio = 0.9
+	DefaultAction        = ActionIgnore
+)
+
+// Spawned describes the balloons the server spawned for a player, Pop gets nil when the clients spawn them
+type Spawned struct {
+	// Favorites is the number of favorite balloons spawned for the player during the session
+	Favorites int
+	// Reaction is how long after it was spawned the balloon was popped
+	Reaction time.Duration
+}
+
+const (
+	// spawnWindow is the window the pops are compared with the balloons that could spawn in it,
+	// spawnSlack the balloons already floating when the window starts
+	spawnWindow = 10 * time.Second
+	spawnSlack  = 10
+	// ratioMinPops is the number of pops of a player, or of favorites spawned for the player,
+	// before the favorite hit ratio is judged
+	ratioMinPops = 20
+	// botReaction is the mean reaction below which the pops are not a human's, botSpread the
+	// spread of the reactions, relative to their mean, below which they are too steady to be
+	botReaction = 250 * time.Millisecond
+	botSpread   = 0.1
+	// maxFlags caps the flags kept, the oldest are dropped first
+	maxFlags = 1000
+)
+
+// Config configures the limits of the pops of a player and the action taken when they are crossed
+type Config struct {
+	// Rate is the number of pops per second a connection sustains, Burst how many it sends at once
+	Rate  float64
+	Burst int
+	// FavoriteRatio is the share of the favorite balloons spawned for a player that flags the player
+	// when they are popped with the reactions of a bot. When the clients spawn the balloons it is the
+	// share of favorite color hits, such flags only warn as popping only the favorites is fair play
+	FavoriteRatio float64
+	Action        string
+}
+
+// Validate checks the limits and the action of the config
+func (c Config) Validate() error {
+	switch c.Action {
+	case ActionWarn, ActionIgnore, ActionDisconnect:
+	default:
+		return fmt.Errorf("unknown anti-cheat action %q, must be one of warn, ignore or disconnect", c.Action)
+	}
+	if c.Rate <= 0 || c.Burst < 1 {
+		return fmt.Errorf("the anti-cheat rate and burst must be positive")
+	}
+	if c.FavoriteRatio <= 0 || c.FavoriteRatio > 1 {
+		return fmt.Errorf("the anti-cheat favorite ratio must be greater than 0 and at most 1")
+	}
+	return nil
+}
+
+type playerKey struct {
+	sessionID string
+	player    string
+}
+
+type flagKey struct {
+	playerKey
+	reason string
+}
+
+// playerStats are the pops of a player during a session, on all the connections of the player
+type playerStats struct {
+	recent    []time.Time
+	total     int
+	favorites int
+	// reactions sums the reactions to the spawned balloons, in seconds, and their squares
+	reactions, squares float64
+}
+
+// reaction returns the mean of the reactions and their standard deviation relative to the mean
+func (s *playerStats) reaction() (time.Duration, float64) {
+	n := float64(s.total)
+	mean := s.reactions / n
+	if mean <= 0 {
+		return 0, 0
+	}
+	return time.Duration(mean * float64(time.Second)), math.Sqrt(max(0, s.squares/n-mean*mean)) / mean
+}
+
+// Guard watches the pops of the players and keeps the flags they raised
+type Guard struct {
+	config  Config
+	mu      sync.Mutex
+	players map[playerKey]*playerStats
+	flags   []*models.Flag
+	byKey   map[flagKey]*models.Flag
+}
+
+// New creates a Guard enforcing config
+func New(config Config) *Guard {
+	return &Guard{
+		config:  config,
+		players: make(map[playerKey]*playerStats),
+		byKey:   make(map[flagKey]*models.Flag),
+	}
+}
+
+// Watch starts watching the pops of player on a new connection to the session of a room
+func (g *Guard) Watch(roomID, sessionID, player string) *Watch {
+	return &Watch{
+		guard:  g,
+		roomID: roomID,
+		key:    playerKey{sessionID: sessionID, player: player},
+		tokens: float64(g.config.Burst),
+	}
+}
+
+// Forget drops the pop statistics of the players of a session that ended, its flags are kept
+func (g *Guard) Forget(sessionID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for key := range g.players {
+		if key.sessionID == sessionID {
+			delete(g.players, key)
+		}
+	}
+}
+
+// Flags lists the flags, the last raised first, optionally of a player or of a room
+func (g *Guard) Flags(player, roomID string) []models.Flag {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	flags := make([]models.Flag, 0)
+	for _, flag := range g.flags {
+		if (player == "" || flag.Player == player) && (roomID == "" || flag.RoomID == roomID) {
+			flags = append(flags, *flag)
+		}
+	}
+	sort.SliceStable(flags, func(i, j int) bool {
+		return flags[i].LastAt.After(flags[j].LastAt)
+	})
+	return flags
+}
+
+// raise records that w crossed a limit, taking action on the pops, callers hold the lock
+func (g *Guard) raise(w *Watch, reason, detail, action string, now time.Time) *models.Flag {
+	key := flagKey{playerKey: w.key, reason: reason}
+	flag, ok := g.byKey[key]
+	if !ok {
+		if len(g.flags) == maxFlags {
+			oldest := g.flags[0]
+			delete(g.byKey, flagKey{playerKey: playerKey{sessionID: oldest.SessionID, player: oldest.Player}, reason: oldest.Reason})
+			g.flags = g.flags[1:]
+		}
+		flag = &models.Flag{
+			Player:    w.key.player,
+			RoomID:    w.roomID,
+			SessionID: w.key.sessionID,
+			Reason:    reason,
+			Action:    action,
+			FirstAt:   now,
+		}
+		g.flags = append(g.flags, flag)
+		g.byKey[key] = flag
+	}
+	flag.Detail = detail
+	flag.Count++
+	flag.LastAt = now
+	raised := *flag
+	return &raised
+}
+
+// Watch checks the pops of a player on one connection, it is not safe for concurrent use
+type Watch struct {
+	guard    *Guard
+	roomID   string
+	key      playerKey
+	tokens   float64
+	refilled time.Time
+}
+
+// Allow takes a token of the bucket of the connection for a message, it returns
+// the flag raised when the bucket is empty or nil
+func (w *Watch) Allow(now time.Time) *models.Flag {
+	config := w.guard.config
+	if !w.refilled.IsZero() {
+		w.tokens = min(float64(config.Burst), w.tokens+now.Sub(w.refilled).Seconds()*config.Rate)
+	}
+	w.refilled = now
+	if w.tokens >= 1 {
+		w.tokens--
+		return nil
+	}
+	w.guard.mu.Lock()
+	defer w.guard.mu.Unlock()
+	return w.guard.raise(w, ReasonRateLimited, fmt.Sprintf("more than %g pops per second", config.Rate), config.Action, now)
+}
+
+// Pop checks a valid pop, favorite telling whether it hit a favorite color and spawned what the server
+// spawned for the player, nil when the clients spawn the balloons. It returns the flag raised by a
+// sequence of pops no player makes fairly or nil.
+func (w *Watch) Pop(now time.Time, favorite bool, spawned *Spawned) *models.Flag {
+	g := w.guard
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	stats, ok := g.players[w.key]
+	if !ok {
+		stats = &playerStats{}
+		g.players[w.key] = stats
+	}
+	stats.total++
+	if favorite {
+		stats.favorites++
+	}
+	stats.recent = append(stats.recent, now)
+	for len(stats.recent) > 0 && now.Sub(stats.recent[0]) > spawnWindow {
+		stats.recent = stats.recent[1:]
+	}
+
+	if limit := int(spawnWindow/models.MinSpawnInterval) + spawnSlack; len(stats.recent) > limit {
+		return g.raise(w, ReasonTooFast, fmt.Sprintf("%d pops in %s, at most %d balloons could be popped", len(stats.recent), spawnWindow, limit), g.config.Action, now)
+	}
+	if spawned != nil {
+		reaction := spawned.Reaction.Seconds()
+		stats.reactions += reaction
+		stats.squares += reaction * reaction
+		// popping nearly every favorite spawned is fair play, unless the reactions are a bot's
+		if spawned.Favorites < ratioMinPops || float64(stats.favorites) < g.config.FavoriteRatio*float64(spawned.Favorites) {
+			return nil
+		}
+		if mean, spread := stats.reaction(); mean < botReaction || spread < botSpread {
+			return g.raise(w, ReasonFavoriteRatio, fmt.Sprintf("%d of %d favorite balloons popped, reacting in %s on average with a spread of %.0f%%",
+				stats.favorites, spawned.Favorites, mean.Round(time.Millisecond), spread*100), g.config.Action, now)
+		}
+		return nil
+	}
+	// the clients claim the colors, a perfect ratio may as well be a player popping only the favorites
+	if stats.total >= ratioMinPops && float64(stats.favorites) >= g.config.FavoriteRatio*float64(stats.total) {
+		return g.raise(w, ReasonFavoriteRatio, fmt.Sprintf("%d of %d pops hit a favorite color", stats.favorites, stats.total), ActionWarn, now)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package anticheat
+
+import (
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	return Config{Rate: 2, Burst: 3, FavoriteRatio: DefaultFavoriteRatio, Action: ActionIgnore}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, testConfig().Validate())
+	config := testConfig()
+	config.Action = "ban"
+	assert.ErrorContains(t, config.Validate(), "unknown anti-cheat action")
+	config = testConfig()
+	config.Burst = 0
+	assert.Error(t, config.Validate())
+	config = testConfig()
+	config.FavoriteRatio = 1.5
+	assert.Error(t, config.Validate())
+}
+
+func TestRateLimit(t *testing.T) {
+	g := New(testConfig())
+	w := g.Watch("default", "s1", "bot")
+	now := time.Now()
+
+	for range 3 {
+		assert.Nil(t, w.Allow(now))
+	}
+	flag := w.Allow(now)
+	if assert.NotNil(t, flag) {
+		assert.Equal(t, ReasonRateLimited, flag.Reason)
+		assert.Equal(t, ActionIgnore, flag.Action)
+		assert.Equal(t, "default", flag.RoomID)
+	}
+	// the bucket refills at the rate
+	assert.Nil(t, w.Allow(now.Add(500*time.Millisecond)))
+	assert.NotNil(t, w.Allow(now.Add(500*time.Millisecond)))
+
+	flags := g.Flags("", "")
+	if assert.Len(t, flags, 1) {
+		assert.Equal(t, 2, flags[0].Count)
+	}
+	assert.Empty(t, g.Flags("someone", ""))
+	assert.Empty(t, g.Flags("", "other"))
+}
+
+func TestHeuristics(t *testing.T) {
+	g := New(testConfig())
+	now := time.Now()
+
+	// a player popping every favorite balloon the server spawned, with the reactions of a human, plays fair
+	w := g.Watch("default", "s1", "fan")
+	for i := range ratioMinPops * 2 {
+		reaction := time.Duration(400+(i%5)*150) * time.Millisecond
+		assert.Nil(t, w.Pop(now.Add(time.Duration(i)*time.Second), true, &Spawned{Favorites: i + 1, Reaction: reaction}))
+	}
+	// a bot popping each of them as soon as it spawns does not
+	w = g.Watch("default", "s1", "bot")
+	var flag *models.Flag
+	for i := range ratioMinPops {
+		flag = w.Pop(now.Add(time.Duration(i)*time.Second), true, &Spawned{Favorites: i + 1, Reaction: 50 * time.Millisecond})
+		if i < ratioMinPops-1 {
+			assert.Nil(t, flag, "judged after %d favorites spawned", ratioMinPops)
+		}
+	}
+	if assert.NotNil(t, flag) {
+		assert.Equal(t, ReasonFavoriteRatio, flag.Reason)
+		assert.Equal(t, ActionIgnore, flag.Action)
+	}
+	// as does one reacting like a human but always in the same time
+	w = g.Watch("default", "s1", "steady")
+	for i := range ratioMinPops {
+		flag = w.Pop(now.Add(time.Duration(i)*time.Second), true, &Spawned{Favorites: i + 1, Reaction: 600 * time.Millisecond})
+	}
+	assert.NotNil(t, flag)
+
+	// the clients claiming the colors, a player popping its favorite colors only
+	w = g.Watch("default", "s1", "lucky")
+	for i := range ratioMinPops - 1 {
+		assert.Nil(t, w.Pop(now.Add(time.Duration(i)*time.Second), true, nil))
+	}
+	// the reconnections share the stats of the session
+	flag = g.Watch("default", "s1", "lucky").Pop(now.Add(time.Minute), true, nil)
+	if assert.NotNil(t, flag) {
+		assert.Equal(t, ReasonFavoriteRatio, flag.Reason)
+		assert.Equal(t, ActionWarn, flag.Action, "the pops of a lucky player are still scored")
+	}
+
+	// a player popping more balloons than can spawn
+	w = g.Watch("default", "s1", "fast")
+	limit := int(spawnWindow/models.MinSpawnInterval) + spawnSlack
+	for i := range limit {
+		assert.Nil(t, w.Pop(now.Add(time.Duration(i)*time.Millisecond), false, nil))
+	}
+	flag = w.Pop(now.Add(time.Second), false, nil)
+	if assert.NotNil(t, flag) {
+		assert.Equal(t, ReasonTooFast, flag.Reason)
+	}
+	// the pops out of the window no longer count
+	assert.Nil(t, w.Pop(now.Add(spawnWindow+time.Second), false, nil))
+
+	assert.Len(t, g.Flags("", ""), 4)
+	g.Forget("s1")
+	assert.Empty(t, g.players)
+	assert.Len(t, g.Flags("", ""), 4)
+}
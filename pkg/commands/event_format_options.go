@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package commands
+
+import (
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/serde"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+// EventFormatOptions holds the encoding of the score events and the schema registry settings
+type EventFormatOptions struct {
+	format            string
+	schemaRegistryURL string
+	registryUsername  string
+	registryPassword  string
+}
+
+// AddFlags adds the event format and the schema registry flags, usage describes the --event-format flag
+func (f *EventFormatOptions) AddFlags(cmd *cobra.Command, usage string) {
+	flags := cmd.Flags()
+
+	flags.StringVar(&f.format, "event-format", serde.FormatJSON, usage)
+	flags.StringVar(&f.schemaRegistryURL, "schema-registry-url", "", "Schema registry URL, required by the avro and protobuf event formats")
+	flags.StringVar(&f.registryUsername, "schema-registry-username", os.Getenv("SCHEMA_REGISTRY_USERNAME"), "Basic auth username of the schema registry ($SCHEMA_REGISTRY_USERNAME)")
+	flags.StringVar(&f.registryPassword, "schema-registry-password", "", "Basic auth password of the schema registry, prefer the environment variable ($SCHEMA_REGISTRY_PASSWORD)")
+}
+
+// Validate checks the --event-format flag, the schema based formats need a registry
+func (f *EventFormatOptions) Validate() error {
+	switch f.format {
+	case serde.FormatJSON:
+	case serde.FormatAvro, serde.FormatProtobuf:
+		if f.schemaRegistryURL == "" {
+			return fmt.Errorf("--event-format=%s requires --schema-registry-url", f.format)
+		}
+	default:
+		return fmt.Errorf("unknown event format %q, must be one of json, avro or protobuf", f.format)
+	}
+	// the secret is read from the environment here to keep it out of the --help defaults
+	if f.registryPassword == "" {
+		f.registryPassword = os.Getenv("SCHEMA_REGISTRY_PASSWORD")
+	}
+	if f.registryPassword != "" && f.registryUsername == "" {
+		return fmt.Errorf("the schema registry password requires --schema-registry-username")
+	}
+	return nil
+}
+
+// Serializer builds the serializer selected by the --event-format flag, call Validate first
+func (f *EventFormatOptions) Serializer() (serde.Serializer, error) {
+	var registry *serde.RegistryClient
+	if f.schemaRegistryURL != "" {
+		r, err := serde.NewRegistryClient(f.schemaRegistryURL)
+		if err != nil {
+			return nil, err
+		}
+		if f.registryUsername != "" {
+			r.SetBasicAuth(f.registryUsername, f.registryPassword)
+		}
+		registry = r
+	}
+	return serde.NewSerializer(f.format, registry)
+}
@@ -0,0 +1,225 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/consumer"
+	"github.com/kameshsampath/balloon-popper/pkg/export"
+	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/outbox"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+// Sources of the exported events
+const (
+	exportSourceTopic  = "topic"
+	exportSourceOutbox = "outbox"
+)
+
+// ExportOptions writes the score events to files for offline analysis
+type ExportOptions struct {
+	kafka       KafkaOptions
+	eventFormat EventFormatOptions
+	source      string
+	outboxDir   string
+	from        string
+	to          string
+	fromTime    time.Time
+	toTime      time.Time
+	sessionID   string
+	config      export.Config
+}
+
+func (e *ExportOptions) AddFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	e.kafka.AddConnectionFlags(cmd)
+	e.eventFormat.AddFlags(cmd, "Encoding of the score events in the topic (json, avro, protobuf)")
+	flags.StringVar(&e.source, "source", exportSourceTopic, "Where to read the score events from (topic, outbox)")
+	flags.StringVar(&e.outboxDir, "outbox-dir", "", "Directory of the outbox, required by --source=outbox")
+	flags.StringVar(&e.from, "from", "", "Export the scores produced at or after this RFC3339 time, only with --source=topic")
+	flags.StringVar(&e.to, "to", "", "Export the scores produced at or before this RFC3339 time, only with --source=topic")
+	flags.StringVar(&e.sessionID, "session", "", "Export only the scores of this game session")
+	flags.StringVarP(&e.config.Dir, "output-dir", "d", "export", "Directory the files and the manifest are written to")
+	flags.StringVar(&e.config.Format, "format", export.FormatParquet, "File format (parquet, csv)")
+	flags.StringVar(&e.config.Compression, "compression", "", "Compression codec, parquet: none, snappy, gzip, zstd, lz4 (default snappy), csv: none, gzip (default none)")
+	flags.Int64Var(&e.config.RowGroupSize, "row-group-size", export.DefaultRowGroupSize, "Size of the Parquet row groups in bytes")
+	flags.IntVar(&e.config.MaxOpenFiles, "max-open-files", export.DefaultMaxOpenFiles, "Files kept open, the least recently written one is finished and its partition continues in a new part file")
+}
+
+func (e *ExportOptions) Validate(cmd *cobra.Command, _ []string) error {
+	if e.config.Compression == "" {
+		e.config.Compression = export.CompressionNone
+		if e.config.Format == export.FormatParquet {
+			e.config.Compression = export.CompressionSnappy
+		}
+	}
+	if err := e.config.Validate(); err != nil {
+		return err
+	}
+	switch e.source {
+	case exportSourceTopic:
+		var err error
+		if e.fromTime, e.toTime, err = parseTimeRange(e.from, e.to); err != nil {
+			return err
+		}
+		if err := e.eventFormat.Validate(); err != nil {
+			return err
+		}
+		if err := e.kafka.Resolve(cmd); err != nil {
+			return err
+		}
+		e.config.Source = "kafka:" + e.kafka.Topic()
+	case exportSourceOutbox:
+		if e.outboxDir == "" {
+			return fmt.Errorf("--source=outbox requires --outbox-dir")
+		}
+		if e.from != "" || e.to != "" {
+			return fmt.Errorf("--from and --to require --source=topic")
+		}
+		if _, err := os.Stat(e.outboxDir); err != nil {
+			return fmt.Errorf("invalid --outbox-dir: %w", err)
+		}
+		e.config.Source = "outbox:" + e.outboxDir
+	default:
+		return fmt.Errorf("unknown source %q, must be one of topic or outbox", e.source)
+	}
+	return nil
+}
+
+func (e *ExportOptions) Execute(cmd *cobra.Command, _ []string) error {
+	log := logger.Get()
+
+	exporter, err := export.New(e.config)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	var writeErr error
+	skipped := 0
+	write := func(event *models.GameEvent) {
+		if writeErr != nil || (e.sessionID != "" && event.SessionID != e.sessionID) {
+			return
+		}
+		err := exporter.Write(event)
+		switch {
+		case errors.Is(err, export.ErrInvalidSession):
+			// skip the event rather than writing it out of the export directory
+			skipped++
+			log.Warnf("Skipping the score event of %s: %v", event.Player, err)
+		case err != nil:
+			writeErr = err
+			cancel()
+		}
+	}
+
+	var readErr error
+	if e.source == exportSourceOutbox {
+		readErr = e.readOutbox(write)
+	} else {
+		readErr = e.readTopic(ctx, write)
+	}
+	manifest, closeErr := exporter.Close()
+	switch {
+	case writeErr != nil:
+		return writeErr
+	case readErr != nil:
+		return readErr
+	case closeErr != nil:
+		return closeErr
+	}
+	if skipped > 0 {
+		log.Warnf("Skipped %d score events with an invalid session ID", skipped)
+	}
+	log.Infof("Exported %d score events to %d files, see %s/%s", manifest.Rows, len(manifest.Files), e.config.Dir, export.ManifestFile)
+	return nil
+}
+
+// readTopic reads the score events committed to the topic
+func (e *ExportOptions) readTopic(ctx context.Context, handle func(*models.GameEvent)) error {
+	serializer, err := e.eventFormat.Serializer()
+	if err != nil {
+		return err
+	}
+	var options []consumer.ReaderOption
+	if !e.fromTime.IsZero() {
+		options = append(options, consumer.WithStartTime(e.fromTime))
+	}
+	reader, err := consumer.NewReader(e.kafka.ProducerConfig(), e.kafka.Topic(), consumer.NewDecoder(serializer), logger.Get(), options...)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if err := reader.Read(ctx, e.toTime, handle); err != nil {
+		return fmt.Errorf("failed to read %s: %w", e.kafka.Topic(), err)
+	}
+	return nil
+}
+
+// readOutbox reads the undelivered score events of the outbox without consuming them
+func (e *ExportOptions) readOutbox(handle func(*models.GameEvent)) error {
+	ob, err := outbox.Open(e.outboxDir)
+	if err != nil {
+		return err
+	}
+	defer ob.Close() //nolint:errcheck
+	events, _, err := ob.Peek(ob.Len())
+	if err != nil {
+		return fmt.Errorf("failed to read the outbox: %w", err)
+	}
+	for _, event := range events {
+		handle(event)
+	}
+	return nil
+}
+
+var exportCommandExample = fmt.Sprintf(`
+  # Export the score topic to Parquet files partitioned by date and session
+  %[1]s export --output-dir ./export
+
+  # Export a day of scores as gzipped CSV
+  %[1]s export --format csv --compression gzip --from 2025-03-01T00:00:00Z --to 2025-03-02T00:00:00Z
+
+  # Export the scores waiting in the outbox
+  %[1]s export --source outbox --outbox-dir ./outbox --compression zstd
+`, ExamplePrefix())
+
+func NewExportCommand() *cobra.Command {
+
+	exportOpts := &ExportOptions{}
+
+	exportCommand := &cobra.Command{
+		Use:     "export",
+		Short:   "Export the score events to Parquet or CSV files",
+		Example: exportCommandExample,
+		RunE:    exportOpts.Execute,
+		PreRunE: exportOpts.Validate,
+	}
+
+	exportOpts.AddFlags(exportCommand)
+
+	return exportCommand
+}
+
+var _ Command = (*ExportOptions)(nil)
@@ -0,0 +1,213 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package commands
+
+import (
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
+	"github.com/spf13/cobra"
+	"os"
+	"strconv"
+)
+
+// KafkaOptions holds the Kafka connection and producer settings shared by the commands.
+// The settings are resolved in the order flag, environment variable, config file and default.
+type KafkaOptions struct {
+	bootstrapServers string
+	topic            string
+	lifecycleTopic   string
+	deadLetterTopic  string
+	resultsTopic     string
+	transactions     bool
+	transactionalID  string
+	configFile       string
+	config           producer.ProducerConfig
+	createTopics     bool
+	topicConfig      producer.TopicConfig
+}
+
+// kafkaSetting maps a flag, and optionally an environment variable, to the config file value
+type kafkaSetting struct {
+	flag     string
+	env      string
+	fromFile func(file *producer.ProducerConfig)
+}
+
+// AddConnectionFlags adds the flags required to connect to the Kafka cluster
+func (k *KafkaOptions) AddConnectionFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	k.config = producer.DefaultProducerConfig()
+
+	flags.StringVarP(&k.bootstrapServers, "kafka-servers", "s", producer.DefaultKafkaBootstrapServers, "Comma separated list of Kafka bootstrap servers")
+	flags.StringVarP(&k.topic, "kafka-topic", "t", "balloon-game", "Kafka topic of the balloon game scores")
+	flags.StringVar(&k.configFile, "kafka-config", "", "YAML or JSON file with the Kafka producer configuration")
+	flags.BoolVar(&k.config.TLS.Enabled, "kafka-tls", envBool("KAFKA_TLS"), "Connect to the Kafka brokers using TLS ($KAFKA_TLS)")
+	flags.StringVar(&k.config.TLS.CAFile, "kafka-tls-ca-file", os.Getenv("KAFKA_TLS_CA_FILE"), "CA certificate to verify the Kafka brokers ($KAFKA_TLS_CA_FILE)")
+	flags.StringVar(&k.config.TLS.CertFile, "kafka-tls-cert-file", os.Getenv("KAFKA_TLS_CERT_FILE"), "Client certificate for mTLS ($KAFKA_TLS_CERT_FILE)")
+	flags.StringVar(&k.config.TLS.KeyFile, "kafka-tls-key-file", os.Getenv("KAFKA_TLS_KEY_FILE"), "Client key for mTLS ($KAFKA_TLS_KEY_FILE)")
+	flags.StringVar(&k.config.TLS.ServerName, "kafka-tls-server-name", os.Getenv("KAFKA_TLS_SERVER_NAME"), "Server name used to verify the Kafka brokers certificate ($KAFKA_TLS_SERVER_NAME)")
+	flags.BoolVar(&k.config.TLS.InsecureSkipVerify, "kafka-tls-insecure-skip-verify", envBool("KAFKA_TLS_INSECURE_SKIP_VERIFY"), "Skip verifying the Kafka brokers certificate ($KAFKA_TLS_INSECURE_SKIP_VERIFY)")
+	flags.StringVar(&k.config.SASL.Mechanism, "kafka-sasl-mechanism", os.Getenv("KAFKA_SASL_MECHANISM"), "SASL mechanism (PLAIN, SCRAM-SHA-256, SCRAM-SHA-512, OAUTHBEARER) ($KAFKA_SASL_MECHANISM)")
+	flags.StringVar(&k.config.SASL.Username, "kafka-sasl-username", os.Getenv("KAFKA_SASL_USERNAME"), "SASL username ($KAFKA_SASL_USERNAME)")
+	flags.StringVar(&k.config.SASL.Password, "kafka-sasl-password", "", "SASL password, prefer the environment variable ($KAFKA_SASL_PASSWORD)")
+	flags.StringVar(&k.config.SASL.Token, "kafka-sasl-oauth-token", "", "OAuth bearer token for OAUTHBEARER, prefer the environment variable ($KAFKA_SASL_OAUTH_TOKEN)")
+}
+
+// AddFlags adds the connection and the producer tuning flags
+func (k *KafkaOptions) AddFlags(cmd *cobra.Command) {
+	k.AddConnectionFlags(cmd)
+	flags := cmd.Flags()
+
+	flags.StringVar(&k.lifecycleTopic, "kafka-lifecycle-topic", "balloon-game-lifecycle", "Kafka topic of the game lifecycle events, disabled when empty")
+	flags.StringVar(&k.deadLetterTopic, "kafka-dead-letter-topic", "balloon-game-dlq", "Kafka topic of the rejected game messages, disabled when empty")
+	flags.BoolVar(&k.transactions, "kafka-transactions", false, "Write the final standings and the game_stopped event of a session in a single Kafka transaction")
+	flags.StringVar(&k.transactionalID, "kafka-transactional-id", "", "Stable transactional ID of this server, defaults to balloon-popper-<instance-id>")
+	flags.StringVar(&k.resultsTopic, "kafka-results-topic", "balloon-game-results", "Kafka topic of the final standings of the game sessions")
+	flags.StringVar(&k.config.Acks, "kafka-acks", k.config.Acks, "Acknowledgements required for produced records (all, leader, none)")
+	flags.BoolVar(&k.config.Idempotent, "kafka-idempotent", k.config.Idempotent, "Enable idempotent writes, requires --kafka-acks=all")
+	flags.StringVar(&k.config.Compression, "kafka-compression", k.config.Compression, "Record batch compression (none, gzip, snappy, lz4, zstd)")
+	flags.Int32Var(&k.config.BatchBytes, "kafka-batch-bytes", k.config.BatchBytes, "Maximum size of a record batch in bytes, 0 uses the client default")
+	flags.DurationVar(&k.config.Linger, "kafka-linger", k.config.Linger, "How long to wait for more records before sending a batch")
+	flags.IntVar(&k.config.Retries, "kafka-retries", k.config.Retries, "How many times to retry a record, 0 retries until it times out")
+	flags.IntVar(&k.config.MaxInFlight, "kafka-max-in-flight", k.config.MaxInFlight, "Produce requests in flight per broker, only with --kafka-idempotent=false")
+	flags.StringVar(&k.config.Partitioner, "kafka-partitioner", k.config.Partitioner, "How records are assigned to partitions (player-hash, round-robin, sticky)")
+
+	k.topicConfig = producer.DefaultTopicConfig()
+	flags.BoolVar(&k.createTopics, "create-topics", false, "Create the Kafka topic at startup when it is missing and warn when its settings differ")
+	flags.Int32Var(&k.topicConfig.Partitions, "topic-partitions", k.topicConfig.Partitions, "Partitions of the created topic, -1 uses the broker default")
+	flags.Int16Var(&k.topicConfig.ReplicationFactor, "topic-replication-factor", k.topicConfig.ReplicationFactor, "Replication factor of the created topic, -1 uses the broker default")
+	flags.DurationVar(&k.topicConfig.Retention, "topic-retention", k.topicConfig.Retention, "Retention of the created topic, 0 uses the broker default and negative keeps the records forever")
+	flags.StringVar(&k.topicConfig.CleanupPolicy, "topic-cleanup-policy", k.topicConfig.CleanupPolicy, "Cleanup policy of the created topic (delete, compact, compact,delete)")
+}
+
+// Resolve merges the config file, environment and flags and validates the result
+func (k *KafkaOptions) Resolve(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+
+	// secrets are read from the environment here to keep them out of the --help defaults
+	if k.config.SASL.Password == "" {
+		k.config.SASL.Password = os.Getenv("KAFKA_SASL_PASSWORD")
+	}
+	if k.config.SASL.Token == "" {
+		k.config.SASL.Token = os.Getenv("KAFKA_SASL_OAUTH_TOKEN")
+	}
+
+	if k.configFile != "" {
+		file, err := producer.LoadProducerConfig(k.configFile)
+		if err != nil {
+			return err
+		}
+		c := &k.config
+		settings := []kafkaSetting{
+			{"kafka-servers", "", func(f *producer.ProducerConfig) { k.bootstrapServers = ""; c.Seeds = f.Seeds }},
+			{"kafka-acks", "", func(f *producer.ProducerConfig) { c.Acks = f.Acks }},
+			{"kafka-idempotent", "", func(f *producer.ProducerConfig) { c.Idempotent = f.Idempotent }},
+			{"kafka-compression", "", func(f *producer.ProducerConfig) { c.Compression = f.Compression }},
+			{"kafka-batch-bytes", "", func(f *producer.ProducerConfig) { c.BatchBytes = f.BatchBytes }},
+			{"kafka-linger", "", func(f *producer.ProducerConfig) { c.Linger = f.Linger }},
+			{"kafka-retries", "", func(f *producer.ProducerConfig) { c.Retries = f.Retries }},
+			{"kafka-max-in-flight", "", func(f *producer.ProducerConfig) { c.MaxInFlight = f.MaxInFlight }},
+			{"kafka-partitioner", "", func(f *producer.ProducerConfig) { c.Partitioner = f.Partitioner }},
+			{"kafka-tls", "KAFKA_TLS", func(f *producer.ProducerConfig) { c.TLS.Enabled = f.TLS.Enabled }},
+			{"kafka-tls-ca-file", "KAFKA_TLS_CA_FILE", func(f *producer.ProducerConfig) { c.TLS.CAFile = f.TLS.CAFile }},
+			{"kafka-tls-cert-file", "KAFKA_TLS_CERT_FILE", func(f *producer.ProducerConfig) { c.TLS.CertFile = f.TLS.CertFile }},
+			{"kafka-tls-key-file", "KAFKA_TLS_KEY_FILE", func(f *producer.ProducerConfig) { c.TLS.KeyFile = f.TLS.KeyFile }},
+			{"kafka-tls-server-name", "KAFKA_TLS_SERVER_NAME", func(f *producer.ProducerConfig) { c.TLS.ServerName = f.TLS.ServerName }},
+			{"kafka-tls-insecure-skip-verify", "KAFKA_TLS_INSECURE_SKIP_VERIFY", func(f *producer.ProducerConfig) {
+				c.TLS.InsecureSkipVerify = f.TLS.InsecureSkipVerify
+			}},
+			{"kafka-sasl-mechanism", "KAFKA_SASL_MECHANISM", func(f *producer.ProducerConfig) { c.SASL.Mechanism = f.SASL.Mechanism }},
+			{"kafka-sasl-username", "KAFKA_SASL_USERNAME", func(f *producer.ProducerConfig) { c.SASL.Username = f.SASL.Username }},
+		}
+		for _, setting := range settings {
+			// flags not registered by the command, e.g. the producer tuning ones, are ignored
+			if flags.Lookup(setting.flag) == nil || flags.Changed(setting.flag) {
+				continue
+			}
+			if _, ok := os.LookupEnv(setting.env); setting.env != "" && ok {
+				continue
+			}
+			setting.fromFile(&file)
+		}
+	}
+	if k.bootstrapServers != "" {
+		k.config.Seeds = producer.SplitSeeds(k.bootstrapServers)
+	}
+	if k.topic == "" {
+		return fmt.Errorf("--kafka-topic must not be empty")
+	}
+	if err := k.config.Validate(); err != nil {
+		return fmt.Errorf("invalid kafka configuration: %w", err)
+	}
+	if k.createTopics {
+		if err := k.topicConfig.Validate(); err != nil {
+			return err
+		}
+	}
+	if k.transactions {
+		if !k.config.Idempotent {
+			return fmt.Errorf("--kafka-transactions requires --kafka-idempotent")
+		}
+		if k.resultsTopic == "" {
+			return fmt.Errorf("--kafka-transactions requires --kafka-results-topic")
+		}
+	}
+	return nil
+}
+
+// Topic is the topic of the balloon game scores
+func (k *KafkaOptions) Topic() string {
+	return k.topic
+}
+
+// LifecycleTopic is the topic of the game lifecycle events, empty when disabled
+func (k *KafkaOptions) LifecycleTopic() string {
+	return k.lifecycleTopic
+}
+
+// DeadLetterTopic is the topic of the rejected game messages, empty when disabled
+func (k *KafkaOptions) DeadLetterTopic() string {
+	return k.deadLetterTopic
+}
+
+// Transactions returns the transactional ID and the results topic and whether
+// --kafka-transactions is set, the ID is empty unless --kafka-transactional-id is set
+func (k *KafkaOptions) Transactions() (string, string, bool) {
+	return k.transactionalID, k.resultsTopic, k.transactions
+}
+
+// TopicProvisioning returns how to create the topic and whether --create-topics is set
+func (k *KafkaOptions) TopicProvisioning() (producer.TopicConfig, bool) {
+	return k.topicConfig, k.createTopics
+}
+
+// UseSeeds connects to the brokers at seeds instead of --kafka-servers, e.g. an embedded cluster
+func (k *KafkaOptions) UseSeeds(seeds []string) {
+	k.config.Seeds = seeds
+}
+
+// ProducerConfig returns the resolved configuration, call Resolve first
+func (k *KafkaOptions) ProducerConfig() producer.ProducerConfig {
+	return k.config
+}
+
+// envBool reads a boolean environment variable, false when unset or invalid
+func envBool(key string) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	return err == nil && v
+}
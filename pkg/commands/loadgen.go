@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/loadgen"
+	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// LoadgenOptions simulates WebSocket players to size the game server
+type LoadgenOptions struct {
+	config loadgen.Config
+	output string
+}
+
+func (l *LoadgenOptions) AddFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.StringVar(&l.config.ServerURL, "server-url", "http://localhost:8080", "Base URL of the game server")
+	flags.StringVarP(&l.config.Username, "user-name", "u", "admin", "Admin user to login with")
+	flags.StringVarP(&l.config.Password, "user-password", "p", os.Getenv("LOADGEN_PASSWORD"), "Password of the admin user ($LOADGEN_PASSWORD)")
+	flags.BoolVar(&l.config.StartGame, "start-game", false, "Start a game before connecting the players")
+	flags.BoolVar(&l.config.StopGame, "stop-game", false, "Stop the game once the load test is over")
+	flags.IntVarP(&l.config.Players, "players", "n", 100, "Number of concurrent players")
+	flags.StringVar(&l.config.PlayerPrefix, "player-prefix", "loadgen-", "Prefix of the player names, followed by the player number")
+	flags.Float64Var(&l.config.Rate, "rate", 1, "Balloons popped per second by each player")
+	flags.DurationVarP(&l.config.Duration, "duration", "d", time.Minute, "How long to run, 0 runs until interrupted")
+	flags.StringVarP(&l.output, "output", "o", "table", "Output format of the report (table, json)")
+}
+
+func (l *LoadgenOptions) Validate(_ *cobra.Command, _ []string) error {
+	if l.output != "table" && l.output != "json" {
+		return fmt.Errorf("unknown output %q, must be one of table or json", l.output)
+	}
+	return l.config.Validate()
+}
+
+func (l *LoadgenOptions) Execute(cmd *cobra.Command, _ []string) error {
+	log := logger.Get()
+
+	// Ctrl-C ends the load test and still prints the report
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := loadgen.Run(ctx, l.config, log)
+	if err != nil {
+		return err
+	}
+	if l.output == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return writeLoadgenReport(cmd.OutOrStdout(), report)
+}
+
+// writeLoadgenReport prints the report as an aligned table
+func writeLoadgenReport(w io.Writer, report *loadgen.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Players\t%d connected of %d\n", report.Connected, report.Players)
+	_, _ = fmt.Fprintf(tw, "Duration\t%.1fs\n", report.DurationSeconds)
+	_, _ = fmt.Fprintf(tw, "Pops sent\t%d (%.1f/s)\n", report.Sent, report.PopsPerSecond)
+	_, _ = fmt.Fprintf(tw, "Score updates\t%d\n", report.ScoreUpdates)
+	_, _ = fmt.Fprintf(tw, "Rejected\t%d\n", report.Rejected)
+	latency := report.Latency
+	_, _ = fmt.Fprintf(tw, "Latency (ms)\tmin %.2f  mean %.2f  p50 %.2f  p90 %.2f  p95 %.2f  p99 %.2f  max %.2f\n",
+		latency.Min, latency.Mean, latency.P50, latency.P90, latency.P95, latency.P99, latency.Max)
+	for _, kind := range report.ErrorKinds() {
+		_, _ = fmt.Fprintf(tw, "Errors %s\t%d\n", kind, report.Errors[kind])
+	}
+	return tw.Flush()
+}
+
+var loadgenCommandExample = fmt.Sprintf(`
+  # Start a game and run 200 players popping 2 balloons per second for 5 minutes
+  %[1]s loadgen -u admin -p secretpassword --start-game --stop-game -n 200 --rate 2 -d 5m
+
+  # Load an already started game until interrupted with Ctrl-C, reporting as JSON
+  LOADGEN_PASSWORD=secretpassword %[1]s loadgen --server-url http://booth:8080 -d 0 -o json
+`, ExamplePrefix())
+
+func NewLoadgenCommand() *cobra.Command {
+
+	loadgenOpts := &LoadgenOptions{}
+
+	loadgenCommand := &cobra.Command{
+		Use:     "loadgen",
+		Short:   "Simulate WebSocket players popping balloons and report the latencies",
+		Example: loadgenCommandExample,
+		RunE:    loadgenOpts.Execute,
+		PreRunE: loadgenOpts.Validate,
+	}
+
+	loadgenOpts.AddFlags(loadgenCommand)
+
+	return loadgenCommand
+}
+
+var _ Command = (*LoadgenOptions)(nil)
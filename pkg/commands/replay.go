@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package commands
+
+import (
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/consumer"
+	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/kameshsampath/balloon-popper/pkg/replay"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+// ReplayOptions rebuilds the scores of past games from the score topic
+type ReplayOptions struct {
+	kafka       KafkaOptions
+	eventFormat EventFormatOptions
+	from        string
+	to          string
+	fromTime    time.Time
+	toTime      time.Time
+	sessionID   string
+	output      string
+	view        string
+}
+
+func (r *ReplayOptions) AddFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	r.kafka.AddConnectionFlags(cmd)
+	r.eventFormat.AddFlags(cmd, "Encoding of the score events in the topic (json, avro, protobuf)")
+	flags.StringVar(&r.from, "from", "", "Replay the scores produced at or after this RFC3339 time")
+	flags.StringVar(&r.to, "to", "", "Replay the scores produced at or before this RFC3339 time")
+	flags.StringVar(&r.sessionID, "session", "", "Replay the scores of this game session")
+	flags.StringVarP(&r.output, "output", "o", replay.OutputTable, "Output format (table, json, csv)")
+	flags.StringVar(&r.view, "view", "", "What to print (all, scores, timeline), defaults to all or to scores for the csv output")
+}
+
+func (r *ReplayOptions) Validate(cmd *cobra.Command, _ []string) error {
+	if r.sessionID == "" && r.from == "" && r.to == "" {
+		return fmt.Errorf("one of --session, --from or --to is required")
+	}
+	var err error
+	if r.fromTime, r.toTime, err = parseTimeRange(r.from, r.to); err != nil {
+		return err
+	}
+	if r.view == "" {
+		r.view = replay.ViewAll
+		if r.output == replay.OutputCSV {
+			r.view = replay.ViewScores
+		}
+	}
+	if err := replay.ValidateOutput(r.output, r.view); err != nil {
+		return err
+	}
+	if err := r.eventFormat.Validate(); err != nil {
+		return err
+	}
+	return r.kafka.Resolve(cmd)
+}
+
+func (r *ReplayOptions) Execute(cmd *cobra.Command, _ []string) error {
+	log := logger.Get()
+
+	serializer, err := r.eventFormat.Serializer()
+	if err != nil {
+		return err
+	}
+	var options []consumer.ReaderOption
+	if !r.fromTime.IsZero() {
+		options = append(options, consumer.WithStartTime(r.fromTime))
+	}
+	reader, err := consumer.NewReader(r.kafka.ProducerConfig(), r.kafka.Topic(), consumer.NewDecoder(serializer), log, options...)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	rp := replay.New(r.sessionID)
+	if err := reader.Read(cmd.Context(), r.toTime, rp.Record); err != nil {
+		return fmt.Errorf("failed to read %s: %w", r.kafka.Topic(), err)
+	}
+	return replay.Write(cmd.OutOrStdout(), rp.Report(), r.output, r.view)
+}
+
+// parseTimeRange parses the RFC3339 --from and --to flags, an empty flag gives a zero time
+func parseTimeRange(from, to string) (time.Time, time.Time, error) {
+	var fromTime, toTime time.Time
+	var err error
+	if from != "" {
+		if fromTime, err = time.Parse(time.RFC3339, from); err != nil {
+			return fromTime, toTime, fmt.Errorf("invalid --from: %w", err)
+		}
+	}
+	if to != "" {
+		if toTime, err = time.Parse(time.RFC3339, to); err != nil {
+			return fromTime, toTime, fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+	if !fromTime.IsZero() && !toTime.IsZero() && toTime.Before(fromTime) {
+		return fromTime, toTime, fmt.Errorf("--to must not be before --from")
+	}
+	return fromTime, toTime, nil
+}
+
+var replayCommandExample = fmt.Sprintf(`
+  # Replay the scores of a game session
+  %[1]s replay --session 6f1c2a9e-4d7b-4c55-9a43-2b8f0e6d1c7a
+
+  # Replay the scores produced in an hour as JSON
+  %[1]s replay --from 2025-03-01T10:00:00Z --to 2025-03-01T11:00:00Z -o json
+
+  # Export the timeline of a session as CSV
+  %[1]s replay --session 6f1c2a9e-4d7b-4c55-9a43-2b8f0e6d1c7a -o csv --view timeline > timeline.csv
+`, ExamplePrefix())
+
+func NewReplayCommand() *cobra.Command {
+
+	replayOpts := &ReplayOptions{}
+
+	replayCommand := &cobra.Command{
+		Use:     "replay",
+		Short:   "Rebuild the scores of past games from the score topic",
+		Example: replayCommandExample,
+		RunE:    replayOpts.Execute,
+		PreRunE: replayOpts.Validate,
+	}
+
+	replayOpts.AddFlags(replayCommand)
+
+	return replayCommand
+}
+
+var _ Command = (*ReplayOptions)(nil)
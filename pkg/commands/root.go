@@ -57,6 +57,9 @@ func NewRootCommand() *cobra.Command {
 	rootCmd.AddCommand(NewServerCommand())
 	rootCmd.AddCommand(NewJWTKeysCommand())
 	rootCmd.AddCommand(NewUserCommand())
+	rootCmd.AddCommand(NewReplayCommand())
+	rootCmd.AddCommand(NewExportCommand())
+	rootCmd.AddCommand(NewLoadgenCommand())
 
 	return rootCmd
 }
@@ -19,27 +19,54 @@ package commands
 
 import (
 	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/anticheat"
+	"github.com/kameshsampath/balloon-popper/pkg/consumer"
+	"github.com/kameshsampath/balloon-popper/pkg/leaderboard"
 	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/outbox"
 	"github.com/kameshsampath/balloon-popper/pkg/producer"
 	"github.com/kameshsampath/balloon-popper/pkg/routes"
+	"github.com/kameshsampath/balloon-popper/pkg/scheduler"
 	"github.com/kameshsampath/balloon-popper/pkg/security"
+	"github.com/kameshsampath/balloon-popper/pkg/sessions"
 	"github.com/kameshsampath/balloon-popper/pkg/web"
 	"github.com/spf13/cobra"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 var appLogger = logger.Get()
 
 type ServerOptions struct {
-	privateKeyFile        string
-	privateKeyPassword    string
-	kafkaBootstrapServers string
-	kafkaTopic            string
-	port                  int
-	userCredentialsFile   string
-	verbose               bool
+	privateKeyFile      string
+	privateKeyPassword  string
+	kafka               KafkaOptions
+	embeddedKafka       bool
+	embeddedKafkaPort   int
+	eventFormat         EventFormatOptions
+	cloudEventsMode     string
+	instanceID          string
+	sink                string
+	sinkFile            string
+	deadLetterFile      string
+	outboxDir           string
+	outboxRetryInterval time.Duration
+	sessionsDir         string
+	schedulesFile       string
+	asyncPipeline       bool
+	pipelineQueueSize   int
+	pipelineWorkers     int
+	pipelineOverflow    string
+	leaderboardMode     string
+	balloonSpawning     string
+	antiCheat           anticheat.Config
+	gameConfigFile      string
+	port                int
+	userCredentialsFile string
+	verbose             bool
 }
 
 func (s *ServerOptions) AddFlags(cmd *cobra.Command) {
@@ -48,8 +75,30 @@ func (s *ServerOptions) AddFlags(cmd *cobra.Command) {
 	// Add flags
 	flags.StringVarP(&s.privateKeyFile, "key-file", "k", "", "Path to the private key file")
 	flags.StringVarP(&s.privateKeyPassword, "key-password", "p", "", "Password for the private key")
-	flags.StringVarP(&s.kafkaBootstrapServers, "kafka-servers", "s", "localhost:19094", "Kafka bootstrap servers")
-	flags.StringVarP(&s.kafkaTopic, "kafka-topic", "t", "balloon-game", "Kafka topic to send balloon game scores")
+	s.kafka.AddFlags(cmd)
+	flags.BoolVar(&s.embeddedKafka, "embedded-kafka", false, "Send the scores to an in-process, in-memory Kafka cluster with the topics created, for development")
+	flags.IntVar(&s.embeddedKafkaPort, "embedded-kafka-port", 0, "Port of the embedded Kafka cluster, a random port when 0")
+	s.eventFormat.AddFlags(cmd, "Encoding of the score events sent to Kafka (json, avro, protobuf)")
+	flags.StringVar(&s.cloudEventsMode, "cloudevents-mode", producer.CloudEventsNone, "Wrap the score events sent to Kafka as CloudEvents (none, binary, structured)")
+	flags.StringVar(&s.instanceID, "instance-id", defaultInstanceID(), "Identifies this server instance, used as the CloudEvents source")
+	flags.StringVar(&s.sink, "sink", producer.SinkKafka, "Where to send the score events (kafka, stdout, file, memory)")
+	flags.StringVar(&s.sinkFile, "sink-file", "scores.jsonl", "Path to the JSON lines file used by the file sink")
+	flags.StringVar(&s.deadLetterFile, "dead-letter-file", "", "Path to the JSON lines file receiving the rejected game messages, instead of the dead letter topic")
+	flags.StringVar(&s.outboxDir, "outbox-dir", "", "Directory to store the score events that fail to be sent, disabled when empty")
+	flags.DurationVar(&s.outboxRetryInterval, "outbox-retry-interval", producer.DefaultOutboxRetryInterval, "How often to retry sending the score events stored in the outbox")
+	flags.StringVar(&s.sessionsDir, "sessions-dir", "data/sessions", "Directory to store the history of the game sessions, kept in memory only when empty")
+	flags.StringVar(&s.schedulesFile, "schedules-file", "data/schedules.json", "File to store the scheduled games, kept in memory only when empty")
+	flags.BoolVar(&s.asyncPipeline, "async-pipeline", true, "Deliver the score events asynchronously, off the WebSocket read loop")
+	flags.IntVar(&s.pipelineQueueSize, "pipeline-queue-size", producer.DefaultPipelineQueueSize, "Number of score events the async pipeline buffers")
+	flags.IntVar(&s.pipelineWorkers, "pipeline-workers", producer.DefaultPipelineWorkers, "Number of workers delivering the score events")
+	flags.StringVar(&s.pipelineOverflow, "pipeline-overflow", producer.OverflowBlock, "What to do when the pipeline queue is full (block, drop-oldest, spill)")
+	flags.StringVar(&s.leaderboardMode, "leaderboard", leaderboard.ModeTap, "Feed the leaderboard from the scores sent by this server or from the Kafka topic (off, tap, topic)")
+	flags.StringVar(&s.balloonSpawning, "balloon-spawning", routes.SpawningServer, "Spawn the balloons on the server and score the pops by balloon ID, or let the clients spawn them (server, client)")
+	flags.StringVar(&s.gameConfigFile, "game-config", "", "YAML or JSON file with the game config, reloaded when it changes, the built-in config when empty")
+	flags.Float64Var(&s.antiCheat.Rate, "anti-cheat-rate", anticheat.DefaultRate, "Number of pops per second a player connection sustains")
+	flags.IntVar(&s.antiCheat.Burst, "anti-cheat-burst", anticheat.DefaultBurst, "Number of pops a player connection sends at once")
+	flags.Float64Var(&s.antiCheat.FavoriteRatio, "anti-cheat-favorite-ratio", anticheat.DefaultFavoriteRatio, "Share of the spawned favorite balloons popped with the reactions of a bot that flags a player, or of favorite color hits when the clients spawn the balloons, such flags only warn")
+	flags.StringVar(&s.antiCheat.Action, "anti-cheat-action", anticheat.DefaultAction, "What to do with the pops of a flagged player (warn, ignore, disconnect)")
 	flags.IntVarP(&s.port, "port", "P", 8080, "Server port")
 	flags.StringVarP(&s.userCredentialsFile, "credentials-file", "c", "", "Path to user credentials file")
 	flags.BoolVarP(&s.verbose, "verbose", "v", false, "Enable verbose mode")
@@ -65,7 +114,60 @@ func (s *ServerOptions) AddFlags(cmd *cobra.Command) {
 	}
 }
 
-func (s *ServerOptions) Validate(_ *cobra.Command, _ []string) error {
+func (s *ServerOptions) Validate(cmd *cobra.Command, _ []string) error {
+	switch s.sink {
+	case producer.SinkKafka, producer.SinkStdout, producer.SinkFile, producer.SinkMemory:
+	default:
+		return fmt.Errorf("unknown sink %q, must be one of kafka, stdout, file or memory", s.sink)
+	}
+	if err := s.eventFormat.Validate(); err != nil {
+		return err
+	}
+	if s.sink == producer.SinkKafka {
+		if err := s.kafka.Resolve(cmd); err != nil {
+			return err
+		}
+	}
+	if err := producer.ValidateCloudEventsMode(s.cloudEventsMode); err != nil {
+		return err
+	}
+	if s.instanceID == "" {
+		return fmt.Errorf("--instance-id must not be empty")
+	}
+	switch s.leaderboardMode {
+	case leaderboard.ModeOff, leaderboard.ModeTap:
+	case leaderboard.ModeTopic:
+		if s.sink != producer.SinkKafka {
+			return fmt.Errorf("--leaderboard=topic requires --sink=kafka")
+		}
+	default:
+		return fmt.Errorf("unknown leaderboard mode %q, must be one of off, tap or topic", s.leaderboardMode)
+	}
+	if _, _, ok := s.kafka.Transactions(); ok && s.sink != producer.SinkKafka {
+		return fmt.Errorf("--kafka-transactions requires --sink=kafka")
+	}
+	if s.embeddedKafka {
+		if s.sink != producer.SinkKafka {
+			return fmt.Errorf("--embedded-kafka requires --sink=kafka")
+		}
+		if _, _, ok := s.kafka.Transactions(); ok {
+			return fmt.Errorf("--embedded-kafka does not support --kafka-transactions")
+		}
+		if config := s.kafka.ProducerConfig(); config.TLS.Enabled || config.SASL.Mechanism != "" {
+			return fmt.Errorf("--embedded-kafka does not support TLS or SASL")
+		}
+	}
+	switch s.balloonSpawning {
+	case routes.SpawningServer, routes.SpawningClient:
+	default:
+		return fmt.Errorf("unknown balloon spawning %q, must be one of server or client", s.balloonSpawning)
+	}
+	if err := s.antiCheat.Validate(); err != nil {
+		return err
+	}
+	if s.asyncPipeline && s.pipelineOverflow == producer.OverflowSpill && s.outboxDir == "" {
+		return fmt.Errorf("--pipeline-overflow=spill requires --outbox-dir")
+	}
 	return nil
 }
 
@@ -94,22 +196,155 @@ func (s *ServerOptions) Execute(_ *cobra.Command, _ []string) error {
 		return err
 	}
 	ec.Logger = appLogger
+	ec.ServerSpawning = s.balloonSpawning == routes.SpawningServer
+	ec.AntiCheat = anticheat.New(s.antiCheat)
+	// Load the game config before the players connect, then reload it when the file changes
+	var stopConfigWatch func() error
+	if s.gameConfigFile != "" {
+		config, err := models.LoadGameConfig(s.gameConfigFile)
+		if err != nil {
+			return err
+		}
+		if err := ec.ApplyConfig(config); err != nil {
+			return fmt.Errorf("invalid game config %s: %w", s.gameConfigFile, err)
+		}
+		if stopConfigWatch, err = ec.WatchConfig(s.gameConfigFile); err != nil {
+			return err
+		}
+		appLogger.Infof("Loaded the game config from %s", s.gameConfigFile)
+	}
 	//Load Users
 	if c, err := security.LoadCredentials(s.userCredentialsFile); err != nil {
 		return err
 	} else {
 		ec.Users = c
 	}
-	// Initialize Kafka kafkaScoreProducer
-	kp, err := producer.NewKafkaScoreProducer(s.kafkaBootstrapServers, s.kafkaTopic)
+	// Keep the history of the game sessions
+	if ec.Sessions, err = sessions.Open(s.sessionsDir); err != nil {
+		return err
+	}
+	if s.sessionsDir != "" {
+		appLogger.Infof("Storing the session history in %s", s.sessionsDir)
+	}
+	aborted, err := ec.Sessions.AbortStale(ec.SessionRunning)
+	if err != nil {
+		return err
+	}
+	for _, id := range aborted {
+		appLogger.Warnf("Session %s was still active when the server stopped, marked as aborted", id)
+	}
+	// Start the scheduled games the same way as the start endpoints
+	if ec.Scheduler, err = scheduler.New(s.schedulesFile, ec.StartScheduled, appLogger); err != nil {
+		return err
+	}
+	if err := ec.Scheduler.DisableOrphans(ec.CheckScheduled); err != nil {
+		return err
+	}
+	// Start the in-process cluster before the sink connects to it
+	var cluster *producer.EmbeddedCluster
+	if s.embeddedKafka {
+		topicConfig, _ := s.kafka.TopicProvisioning()
+		cluster, err = producer.StartEmbeddedCluster(s.embeddedKafkaPort, topicConfig.Partitions,
+			s.kafka.Topic(), s.kafka.LifecycleTopic(), s.kafka.DeadLetterTopic())
+		if err != nil {
+			return err
+		}
+		s.kafka.UseSeeds(cluster.Seeds())
+		appLogger.Infof("Started embedded Kafka cluster at %v", cluster.Seeds())
+	}
+	// Initialize the score sink
+	sink, err := s.newScoreSink()
 	if err != nil {
 		return err
 	}
-	ec.KafkaProducer = kp
-	// Start Kafka producer
-	if err := ec.KafkaProducer.Start(); err != nil {
-		return fmt.Errorf("failed to start Kafka producer: %v", err)
+	// The lifecycle events go to the same place as the scores
+	if ls, ok := sink.(producer.LifecycleSink); ok && (s.sink != producer.SinkKafka || s.kafka.LifecycleTopic() != "") {
+		ec.Lifecycle = ls
 	}
+	// The rejected messages go to their own file, or the dead letter topic, never to the scores file
+	var deadLetters *producer.FileScoreSink
+	if s.deadLetterFile != "" {
+		if deadLetters, err = producer.NewFileScoreSink(s.deadLetterFile); err != nil {
+			return err
+		}
+		ec.DeadLetters = deadLetters
+	} else if dl, ok := sink.(producer.DeadLetterSink); ok && s.sink != producer.SinkFile && (s.sink != producer.SinkKafka || s.kafka.DeadLetterTopic() != "") {
+		ec.DeadLetters = dl
+	}
+	// Wrap the sink with the durable outbox
+	if s.outboxDir != "" {
+		ob, err := outbox.Open(s.outboxDir)
+		if err != nil {
+			return err
+		}
+		ec.Outbox = ob
+		sink = producer.NewOutboxScoreSink(sink, ob, s.outboxRetryInterval, appLogger)
+		appLogger.Infof("Storing undelivered scores in outbox %s", s.outboxDir)
+	}
+	// Deliver the scores off the WebSocket read loop
+	if s.asyncPipeline {
+		p, err := producer.NewAsyncScorePipeline(sink, producer.PipelineConfig{
+			QueueSize: s.pipelineQueueSize,
+			Workers:   s.pipelineWorkers,
+			Overflow:  s.pipelineOverflow,
+			Spill:     ec.Outbox,
+		}, appLogger)
+		if err != nil {
+			return err
+		}
+		ec.Pipeline = p
+		sink = p
+	}
+	// Aggregate the scores on the leaderboard
+	var feed *leaderboard.TopicFeed
+	switch s.leaderboardMode {
+	case leaderboard.ModeTap:
+		ec.Leaderboard = leaderboard.New()
+		sink = leaderboard.NewTapScoreSink(sink, ec.Leaderboard)
+	case leaderboard.ModeTopic:
+		ec.Leaderboard = leaderboard.New()
+		serializer, err := s.eventFormat.Serializer()
+		if err != nil {
+			return err
+		}
+		reader, err := consumer.NewReader(s.kafka.ProducerConfig(), s.kafka.Topic(), consumer.NewDecoder(serializer), appLogger)
+		if err != nil {
+			return err
+		}
+		feed = leaderboard.NewTopicFeed(reader, ec.Leaderboard)
+	}
+	// Write the session results in Kafka transactions
+	var results *producer.KafkaScoreProducer
+	if _, _, ok := s.kafka.Transactions(); ok && s.sink == producer.SinkKafka {
+		if results, err = s.newResultsProducer(); err != nil {
+			return err
+		}
+		// only the game_stopped event is sent with the results, the other lifecycle events
+		// stay on the score producer so joins are never held up by a transaction
+		ec.Results = results
+	}
+	ec.ScoreSink = sink
+	// Start the score sink
+	if err := ec.ScoreSink.Start(); err != nil {
+		return fmt.Errorf("failed to start %s score sink: %v", s.sink, err)
+	}
+	appLogger.Infof("Sending scores to %s sink", s.sink)
+	if deadLetters != nil {
+		if err := deadLetters.Start(); err != nil {
+			return fmt.Errorf("failed to start the dead letter file: %v", err)
+		}
+		appLogger.Infof("Writing rejected messages to %s", s.deadLetterFile)
+	}
+	if results != nil {
+		if err := results.Start(); err != nil {
+			return fmt.Errorf("failed to start the session results producer: %v", err)
+		}
+	}
+	if feed != nil {
+		feed.Start()
+		appLogger.Infof("Rebuilding the leaderboard from topic %s", s.kafka.Topic())
+	}
+	ec.Scheduler.Start()
 	//Create a new Server
 	server := web.NewServer(appLogger, s.port, ec)
 	// Graceful shutdown
@@ -117,9 +352,31 @@ func (s *ServerOptions) Execute(_ *cobra.Command, _ []string) error {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 		<-sigChan
+		ec.Scheduler.Stop()
+		if stopConfigWatch != nil {
+			if err := stopConfigWatch(); err != nil {
+				appLogger.Errorf("Error stopping the game config watch: %v", err)
+			}
+		}
+		if feed != nil {
+			feed.Stop()
+		}
+		if results != nil {
+			if err := results.Stop(); err != nil {
+				appLogger.Errorf("Error stopping the session results producer: %v", err)
+			}
+		}
+		if deadLetters != nil {
+			if err := deadLetters.Stop(); err != nil {
+				appLogger.Errorf("Error closing the dead letter file: %v", err)
+			}
+		}
 		if err := server.Stop(); err != nil {
 			appLogger.Errorf("Error stopping server: %v", err)
 		}
+		if cluster != nil {
+			cluster.Close()
+		}
 		os.Exit(0)
 	}()
 	//Start the server
@@ -130,11 +387,98 @@ func (s *ServerOptions) Execute(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
+// newScoreSink builds the score sink selected by the --sink flag
+func (s *ServerOptions) newScoreSink() (producer.ScoreSink, error) {
+	switch s.sink {
+	case producer.SinkStdout:
+		return producer.NewStdoutScoreSink(os.Stdout), nil
+	case producer.SinkFile:
+		return producer.NewFileScoreSink(s.sinkFile)
+	case producer.SinkMemory:
+		return producer.NewMemoryScoreSink(), nil
+	default:
+		serializer, err := s.eventFormat.Serializer()
+		if err != nil {
+			return nil, err
+		}
+		options := []producer.Option{
+			producer.WithSerializer(serializer),
+			producer.WithCloudEvents(s.cloudEventsMode, producer.EventSource(s.instanceID)),
+			producer.WithLifecycleTopic(s.kafka.LifecycleTopic()),
+			producer.WithDeadLetterTopic(s.kafka.DeadLetterTopic()),
+			producer.WithLogger(appLogger),
+		}
+		if topicConfig, ok := s.kafka.TopicProvisioning(); ok {
+			options = append(options, producer.WithTopicProvisioning(topicConfig))
+		}
+		return producer.NewKafkaScoreProducerWithConfig(s.kafka.ProducerConfig(), s.kafka.Topic(), options...)
+	}
+}
+
+// newResultsProducer builds the transactional producer of the session results
+func (s *ServerOptions) newResultsProducer() (*producer.KafkaScoreProducer, error) {
+	id, resultsTopic, _ := s.kafka.Transactions()
+	if id == "" {
+		id = producer.TransactionalID(s.instanceID)
+	}
+	options := []producer.Option{
+		producer.WithTransactionalID(id),
+		producer.WithResultsTopic(resultsTopic),
+		producer.WithLifecycleTopic(s.kafka.LifecycleTopic()),
+		producer.WithCloudEvents(s.cloudEventsMode, producer.EventSource(s.instanceID)),
+		producer.WithLogger(appLogger),
+	}
+	if topicConfig, ok := s.kafka.TopicProvisioning(); ok {
+		options = append(options, producer.WithTopicProvisioning(topicConfig))
+	}
+	return producer.NewKafkaScoreProducerWithConfig(s.kafka.ProducerConfig(), s.kafka.Topic(), options...)
+}
+
+// defaultInstanceID uses the host name to identify the server instance
+func defaultInstanceID() string {
+	if h, err := os.Hostname(); err == nil && h != "" {
+		return h
+	}
+	return "balloon-popper"
+}
+
 var serverCommandExample = fmt.Sprintf(`
   # Run server with unencrypted private key
   %[1]s server --private-key-file /keys/foo
   # Run server with unencrypted private key
   %[1]s server --private-key-file /keys/foo --private-key-password password123
+  # Run server without Kafka, printing the scores to stdout
+  %[1]s server --key-file /keys/foo --credentials-file users.json --sink stdout
+  # Run server without Kafka, appending the scores to a JSON lines file
+  %[1]s server --key-file /keys/foo --credentials-file users.json --sink file --sink-file ./data/scores.jsonl
+  # Run server sending Avro encoded scores registered with a schema registry
+  %[1]s server --key-file /keys/foo --credentials-file users.json --event-format avro --schema-registry-url http://localhost:8081
+  # Run server sending the scores as binary mode CloudEvents
+  %[1]s server --key-file /keys/foo --credentials-file users.json --cloudevents-mode binary --instance-id booth-1
+  # Run server connecting to a cluster requiring SCRAM over TLS
+  KAFKA_SASL_PASSWORD=secret %[1]s server --key-file /keys/foo --credentials-file users.json \
+    --kafka-servers broker:9093 --kafka-tls --kafka-tls-ca-file ca.pem \
+    --kafka-sasl-mechanism SCRAM-SHA-512 --kafka-sasl-username balloon
+  # Run server with the producer settings from a file, overriding the compression
+  %[1]s server --key-file /keys/foo --credentials-file users.json --kafka-config kafka.yaml --kafka-compression zstd
+  # Run server creating the score topic when it is missing
+  %[1]s server --key-file /keys/foo --credentials-file users.json --create-topics --topic-partitions 6 --topic-replication-factor 3
+  # Run server rebuilding the leaderboard from the Kafka topic on restart
+  %[1]s server --key-file /keys/foo --credentials-file users.json --leaderboard topic
+  # Run server writing the session results in Kafka transactions
+  %[1]s server --key-file /keys/foo --credentials-file users.json --kafka-transactions --instance-id booth-1
+  # Run server keeping the scores that fail to reach Kafka in a local outbox
+  %[1]s server --key-file /keys/foo --credentials-file users.json --outbox-dir ./data/outbox
+  # Run server keeping the session history in a custom directory
+  %[1]s server --key-file /keys/foo --credentials-file users.json --sessions-dir /var/lib/balloon-popper/sessions
+  # Run server keeping the scheduled games in a custom file
+  %[1]s server --key-file /keys/foo --credentials-file users.json --schedules-file /var/lib/balloon-popper/schedules.json
+  # Run server disconnecting the players sending more than 3 pops per second
+  %[1]s server --key-file /keys/foo --credentials-file users.json --anti-cheat-rate 3 --anti-cheat-action disconnect
+  # Run server with the game config of a file, reloaded when the file changes
+  %[1]s server --key-file /keys/foo --credentials-file users.json --game-config ./config/game.yaml
+  # Run server letting the browsers spawn the balloons, as the clients before the server spawned them
+  %[1]s server --key-file /keys/foo --credentials-file users.json --balloon-spawning client
 `, ExamplePrefix())
 
 // NewServerCommand starts the Balloon Popper Server
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
+	"github.com/kameshsampath/balloon-popper/pkg/serde"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Decoder turns the score records back into game events, it understands every
+// CloudEvents mode the KafkaScoreProducer writes
+type Decoder struct {
+	serializer serde.Serializer
+}
+
+// NewDecoder creates a decoder for the records written with serializer, nil decodes JSON
+func NewDecoder(serializer serde.Serializer) *Decoder {
+	if serializer == nil {
+		serializer = &serde.JSONSerializer{}
+	}
+	return &Decoder{serializer: serializer}
+}
+
+// Decode returns the game event of record
+func (d *Decoder) Decode(ctx context.Context, record *kgo.Record) (*models.GameEvent, error) {
+	value := record.Value
+	if isStructuredCloudEvent(record) {
+		var ce producer.CloudEvent
+		if err := json.Unmarshal(value, &ce); err != nil {
+			return nil, fmt.Errorf("failed to decode cloudevent: %w", err)
+		}
+		if ce.DataBase64 != nil {
+			value = ce.DataBase64
+		} else {
+			value = ce.Data
+		}
+	}
+	event, err := d.serializer.Deserialize(ctx, record.Topic, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s event at %s/%d/%d: %w",
+			d.serializer.Format(), record.Topic, record.Partition, record.Offset, err)
+	}
+	return event, nil
+}
+
+func isStructuredCloudEvent(record *kgo.Record) bool {
+	for _, h := range record.Headers {
+		if h.Key == "content-type" {
+			return bytes.Equal(h.Value, []byte(producer.CloudEventsContentType))
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	event := &models.GameEvent{
+		Player:       "tester",
+		BalloonColor: "red",
+		Score:        100,
+		EventTS:      time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+		SessionID:    "s1",
+	}
+	data, err := json.Marshal(event)
+	assert.NoError(t, err)
+	structured := func(ce producer.CloudEvent) *kgo.Record {
+		value, err := json.Marshal(ce)
+		assert.NoError(t, err)
+		return &kgo.Record{
+			Topic:   "scores",
+			Value:   value,
+			Headers: []kgo.RecordHeader{{Key: "content-type", Value: []byte(producer.CloudEventsContentType)}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		record *kgo.Record
+	}{
+		{"plain", &kgo.Record{Topic: "scores", Value: data}},
+		{"binary", &kgo.Record{Topic: "scores", Value: data, Headers: []kgo.RecordHeader{
+			{Key: "ce_specversion", Value: []byte("1.0")},
+			{Key: "content-type", Value: []byte("application/json")},
+		}}},
+		{"structured", structured(producer.CloudEvent{SpecVersion: "1.0", Data: data})},
+		{"structured base64", structured(producer.CloudEvent{SpecVersion: "1.0", DataBase64: data})},
+	}
+	decoder := NewDecoder(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decoder.Decode(context.Background(), tt.record)
+			assert.NoError(t, err)
+			assert.Equal(t, event, got)
+		})
+	}
+
+	_, err = decoder.Decode(context.Background(), &kgo.Record{Topic: "scores", Value: []byte("not json")})
+	assert.Error(t, err)
+}
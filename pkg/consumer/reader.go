@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Reader reads the score topic from the beginning, without a consumer group, so
+// every reader sees all the score events
+type Reader struct {
+	client  *kgo.Client
+	topic   string
+	from    time.Time
+	decoder *Decoder
+	log     *zap.SugaredLogger
+}
+
+// ReaderOption configures a Reader
+type ReaderOption func(r *Reader)
+
+// WithStartTime starts reading at the first record produced at or after from
+func WithStartTime(from time.Time) ReaderOption {
+	return func(r *Reader) {
+		r.from = from
+	}
+}
+
+// NewReader creates a reader of topic connecting with the connection settings of config
+func NewReader(config producer.ProducerConfig, topic string, decoder *Decoder, log *zap.SugaredLogger, options ...ReaderOption) (*Reader, error) {
+	r := &Reader{
+		topic:   topic,
+		decoder: decoder,
+		log:     log,
+	}
+	for _, option := range options {
+		option(r)
+	}
+
+	opts, err := config.ConnectionOpts()
+	if err != nil {
+		return nil, err
+	}
+	start := kgo.NewOffset().AtStart()
+	if !r.from.IsZero() {
+		start = kgo.NewOffset().AfterMilli(r.from.UnixMilli())
+	}
+	opts = append(opts,
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(start),
+		// skip the records of aborted or still open transactions
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+		// the transaction markers tell Read how far a partition has been read
+		kgo.KeepControlRecords(),
+	)
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+	r.client = client
+	return r, nil
+}
+
+// Follow calls handle with every score event of the topic, waiting for new events
+// until ctx is done or the reader is closed. Records that cannot be decoded are skipped.
+func (r *Reader) Follow(ctx context.Context, handle func(*models.GameEvent)) {
+	for {
+		fetches := r.client.PollFetches(ctx)
+		if fetches.IsClientClosed() || ctx.Err() != nil {
+			return
+		}
+		fetches.EachError(func(topic string, partition int32, err error) {
+			r.log.Warnf("Failed to fetch %s/%d: %v", topic, partition, err)
+		})
+		fetches.EachRecord(func(record *kgo.Record) {
+			r.handle(ctx, record, handle)
+		})
+	}
+}
+
+// Read calls handle with the score events of the topic produced up to to, a zero
+// to reads everything. Unlike Follow it returns once it has read the records that
+// were committed to the topic when it was called.
+func (r *Reader) Read(ctx context.Context, to time.Time, handle func(*models.GameEvent)) error {
+	pending, err := r.pendingOffsets(ctx)
+	if err != nil {
+		return err
+	}
+	for len(pending) > 0 {
+		fetches := r.client.PollFetches(ctx)
+		if fetches.IsClientClosed() {
+			return fmt.Errorf("reader closed while reading %s", r.topic)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fetches.EachError(func(topic string, partition int32, err error) {
+			r.log.Warnf("Failed to fetch %s/%d: %v", topic, partition, err)
+		})
+		fetches.EachRecord(func(record *kgo.Record) {
+			end, ok := pending[record.Partition]
+			if !ok || record.Offset >= end {
+				return
+			}
+			if record.Offset+1 >= end {
+				delete(pending, record.Partition)
+			}
+			if !to.IsZero() && record.Timestamp.After(to) {
+				return
+			}
+			r.handle(ctx, record, handle)
+		})
+	}
+	return nil
+}
+
+// pendingOffsets returns the committed end offset of the partitions that have
+// records left to read from the start offset of the reader
+func (r *Reader) pendingOffsets(ctx context.Context) (map[int32]int64, error) {
+	adm := kadm.NewClient(r.client)
+	ends, err := adm.ListCommittedOffsets(ctx, r.topic)
+	if err == nil {
+		err = ends.Error()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the end offsets of %s: %w", r.topic, err)
+	}
+	var starts kadm.ListedOffsets
+	if r.from.IsZero() {
+		starts, err = adm.ListStartOffsets(ctx, r.topic)
+	} else {
+		starts, err = adm.ListOffsetsAfterMilli(ctx, r.from.UnixMilli(), r.topic)
+	}
+	if err == nil {
+		err = starts.Error()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the start offsets of %s: %w", r.topic, err)
+	}
+
+	pending := make(map[int32]int64)
+	ends.Each(func(end kadm.ListedOffset) {
+		start, ok := starts.Lookup(end.Topic, end.Partition)
+		// no record was produced after the start time when the offset is unknown
+		if ok && start.Offset >= 0 && start.Offset < end.Offset {
+			pending[end.Partition] = end.Offset
+		}
+	})
+	return pending, nil
+}
+
+// handle decodes record and passes it to handle, skipping the transaction markers
+// and the records that cannot be decoded
+func (r *Reader) handle(ctx context.Context, record *kgo.Record, handle func(*models.GameEvent)) {
+	if record.Attrs.IsControl() {
+		return
+	}
+	event, err := r.decoder.Decode(ctx, record)
+	if err != nil {
+		r.log.Warnf("Skipping score record: %v", err)
+		return
+	}
+	handle(event)
+}
+
+// Close closes the connection to the brokers
+func (r *Reader) Close() {
+	r.client.Close()
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestRead(t *testing.T) {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(2, "scores"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cluster.Close()
+	config := producer.DefaultProducerConfig()
+	config.Seeds = cluster.ListenAddrs()
+
+	client, err := kgo.NewClient(kgo.SeedBrokers(config.Seeds...))
+	assert.NoError(t, err)
+	defer client.Close()
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	players := []string{"tom", "jerry", "tom", "spike"}
+	for i, player := range players {
+		value, err := json.Marshal(models.GameEvent{Player: player, Score: 10 * (i + 1), EventTS: start})
+		assert.NoError(t, err)
+		ts := start.Add(time.Duration(i) * time.Minute)
+		record := &kgo.Record{Topic: "scores", Key: []byte(player), Value: value, Timestamp: ts}
+		assert.NoError(t, client.ProduceSync(context.Background(), record).FirstErr())
+	}
+
+	read := func(from, to time.Time) []int {
+		var options []ReaderOption
+		if !from.IsZero() {
+			options = append(options, WithStartTime(from))
+		}
+		reader, err := NewReader(config, "scores", NewDecoder(nil), zap.NewNop().Sugar(), options...)
+		assert.NoError(t, err)
+		defer reader.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		scores := make([]int, 0)
+		assert.NoError(t, reader.Read(ctx, to, func(event *models.GameEvent) {
+			scores = append(scores, event.Score)
+		}))
+		return scores
+	}
+
+	assert.ElementsMatch(t, []int{10, 20, 30, 40}, read(time.Time{}, time.Time{}))
+	assert.ElementsMatch(t, []int{20, 30}, read(start.Add(time.Minute), start.Add(2*time.Minute)))
+	assert.Empty(t, read(start.Add(time.Hour), time.Time{}))
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package export
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// csvHeader uses the JSON names of the game event fields
+var csvHeader = []string{"player", "balloon_color", "score", "favorite_color_bonus", "event_ts", "session_id", "room_id"}
+
+type csvWriter struct {
+	file    *os.File
+	gz      *gzip.Writer
+	csv     *csv.Writer
+	started bool
+}
+
+func newCSVWriter(f *os.File, compress bool) *csvWriter {
+	w := &csvWriter{file: f}
+	var out io.Writer = f
+	if compress {
+		w.gz = gzip.NewWriter(f)
+		out = w.gz
+	}
+	w.csv = csv.NewWriter(out)
+	return w
+}
+
+func (w *csvWriter) write(event *models.GameEvent) error {
+	if !w.started {
+		if err := w.csv.Write(csvHeader); err != nil {
+			return err
+		}
+		w.started = true
+	}
+	return w.csv.Write([]string{
+		event.Player,
+		event.BalloonColor,
+		strconv.Itoa(event.Score),
+		strconv.FormatBool(event.FavoriteColorBonus),
+		event.EventTS.Format(time.RFC3339Nano),
+		event.SessionID,
+		event.RoomID,
+	})
+}
+
+func (w *csvWriter) close() error {
+	w.csv.Flush()
+	err := w.csv.Error()
+	if w.gz != nil {
+		if gzErr := w.gz.Close(); err == nil {
+			err = gzErr
+		}
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,327 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Export file formats
+const (
+	FormatParquet = "parquet"
+	FormatCSV     = "csv"
+)
+
+// Compression codecs, the csv format supports none and gzip
+const (
+	CompressionNone   = "none"
+	CompressionSnappy = "snappy"
+	CompressionGzip   = "gzip"
+	CompressionZstd   = "zstd"
+	CompressionLZ4    = "lz4"
+)
+
+// ManifestFile is the name of the manifest written in the export directory
+const ManifestFile = "manifest.json"
+
+// DefaultRowGroupSize is the default size of the Parquet row groups in bytes
+const DefaultRowGroupSize = 128 * 1024 * 1024
+
+// DefaultMaxOpenFiles is the default number of files an export keeps open
+const DefaultMaxOpenFiles = 64
+
+// unknownSession names the partition of the events without a session, as Hive and Spark do
+const unknownSession = "__HIVE_DEFAULT_PARTITION__"
+
+// sessionIDPattern matches the session IDs that are safe to use as a directory name
+var sessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ErrInvalidSession is returned by Write for an event whose session ID cannot name a partition
+var ErrInvalidSession = errors.New("invalid session id")
+
+// Config configures an export
+type Config struct {
+	// Dir is the directory the files are written to
+	Dir string
+	// Format is the file format, parquet or csv
+	Format string
+	// Compression is the codec used to compress the files
+	Compression string
+	// RowGroupSize is the size of the Parquet row groups in bytes
+	RowGroupSize int64
+	// MaxOpenFiles caps the files kept open, the least recently written one is finished to
+	// open another and its partition continues in a new part file, zero takes DefaultMaxOpenFiles
+	MaxOpenFiles int
+	// Source describes where the events were read from, recorded in the manifest
+	Source string
+}
+
+// Validate checks the format and its compression
+func (c Config) Validate() error {
+	if c.Dir == "" {
+		return fmt.Errorf("export directory must not be empty")
+	}
+	if c.MaxOpenFiles < 0 {
+		return fmt.Errorf("max open files must not be negative, got %d", c.MaxOpenFiles)
+	}
+	switch c.Format {
+	case FormatParquet:
+		if _, err := parquetCodec(c.Compression); err != nil {
+			return err
+		}
+		if c.RowGroupSize <= 0 {
+			return fmt.Errorf("row group size must be positive, got %d", c.RowGroupSize)
+		}
+	case FormatCSV:
+		if c.Compression != CompressionNone && c.Compression != CompressionGzip {
+			return fmt.Errorf("unknown csv compression %q, must be one of none or gzip", c.Compression)
+		}
+	default:
+		return fmt.Errorf("unknown export format %q, must be one of parquet or csv", c.Format)
+	}
+	return nil
+}
+
+// Manifest lists the files written by an export
+type Manifest struct {
+	ExportedAt  time.Time      `json:"exported_at"`
+	Source      string         `json:"source,omitempty"`
+	Format      string         `json:"format"`
+	Compression string         `json:"compression"`
+	Rows        int            `json:"rows"`
+	Files       []ManifestItem `json:"files"`
+}
+
+// ManifestItem describes a file of the export, one per date and session unless
+// the partition was finished early to cap the open files
+type ManifestItem struct {
+	Path       string    `json:"path"`
+	Date       string    `json:"date"`
+	SessionID  string    `json:"session_id"`
+	Rows       int       `json:"rows"`
+	Bytes      int64     `json:"bytes"`
+	MinEventTS time.Time `json:"min_event_ts"`
+	MaxEventTS time.Time `json:"max_event_ts"`
+}
+
+// fileWriter writes the events of a partition
+type fileWriter interface {
+	write(event *models.GameEvent) error
+	close() error
+}
+
+// partition is an open file of the export
+type partition struct {
+	item   ManifestItem
+	writer fileWriter
+	// written orders the partitions by their last write
+	written uint64
+}
+
+// Exporter writes the game events to files partitioned by date and session,
+// using the Hive layout date=<yyyy-mm-dd>/session=<id>/part-<nnnnn>.<ext>
+type Exporter struct {
+	config Config
+	// partitions are the open files by partition directory, parts the number of files
+	// opened in each directory
+	partitions map[string]*partition
+	parts      map[string]int
+	finished   []ManifestItem
+	writes     uint64
+}
+
+// New creates an exporter writing to config.Dir
+func New(config Config) (*Exporter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory %s: %w", config.Dir, err)
+	}
+	if config.MaxOpenFiles == 0 {
+		config.MaxOpenFiles = DefaultMaxOpenFiles
+	}
+	return &Exporter{
+		config:     config,
+		partitions: make(map[string]*partition),
+		parts:      make(map[string]int),
+	}, nil
+}
+
+// Write adds event to the file of its date and session, the events whose session ID
+// is not made of letters, digits, '-' and '_' are rejected with ErrInvalidSession
+func (e *Exporter) Write(event *models.GameEvent) error {
+	date := event.EventTS.UTC().Format(time.DateOnly)
+	session := event.SessionID
+	if session == "" {
+		session = unknownSession
+	}
+	// the session ID comes from the topic, it must not lead out of the export directory
+	if !sessionIDPattern.MatchString(session) {
+		return fmt.Errorf("%w %q", ErrInvalidSession, session)
+	}
+	dir := filepath.Join("date="+date, "session="+session)
+	if !filepath.IsLocal(dir) {
+		return fmt.Errorf("%w %q", ErrInvalidSession, session)
+	}
+
+	p, ok := e.partitions[dir]
+	if !ok {
+		if len(e.partitions) >= e.config.MaxOpenFiles {
+			if err := e.finishOldest(); err != nil {
+				return err
+			}
+		}
+		path := filepath.Join(dir, fmt.Sprintf("part-%05d.%s", e.parts[dir], e.extension()))
+		w, err := e.open(path)
+		if err != nil {
+			return err
+		}
+		p = &partition{
+			item: ManifestItem{
+				Path:       filepath.ToSlash(path),
+				Date:       date,
+				SessionID:  event.SessionID,
+				MinEventTS: event.EventTS,
+				MaxEventTS: event.EventTS,
+			},
+			writer: w,
+		}
+		e.partitions[dir] = p
+		e.parts[dir]++
+	}
+	if err := p.writer.write(event); err != nil {
+		return fmt.Errorf("failed to write %s: %w", p.item.Path, err)
+	}
+	e.writes++
+	p.written = e.writes
+	p.item.Rows++
+	if event.EventTS.Before(p.item.MinEventTS) {
+		p.item.MinEventTS = event.EventTS
+	}
+	if event.EventTS.After(p.item.MaxEventTS) {
+		p.item.MaxEventTS = event.EventTS
+	}
+	return nil
+}
+
+// Close finishes the files and writes the manifest listing them
+func (e *Exporter) Close() (*Manifest, error) {
+	manifest := &Manifest{
+		ExportedAt:  time.Now().UTC(),
+		Source:      e.config.Source,
+		Format:      e.config.Format,
+		Compression: e.config.Compression,
+	}
+	// every file is closed, the first error is returned
+	var closeErr error
+	for dir, p := range e.partitions {
+		if err := e.finish(dir, p); err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	manifest.Files = append(make([]ManifestItem, 0, len(e.finished)), e.finished...)
+	e.partitions = make(map[string]*partition)
+	e.parts = make(map[string]int)
+	e.finished = nil
+	if closeErr != nil {
+		return nil, closeErr
+	}
+	for _, item := range manifest.Files {
+		manifest.Rows += item.Rows
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(e.config.Dir, ManifestFile), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// finishOldest finishes the file written the longest ago, to open another one
+func (e *Exporter) finishOldest() error {
+	var oldestDir string
+	var oldest *partition
+	for dir, p := range e.partitions {
+		if oldest == nil || p.written < oldest.written {
+			oldestDir, oldest = dir, p
+		}
+	}
+	return e.finish(oldestDir, oldest)
+}
+
+// finish closes the file of the partition in dir and adds it to the manifest
+func (e *Exporter) finish(dir string, p *partition) error {
+	delete(e.partitions, dir)
+	if err := p.writer.close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", p.item.Path, err)
+	}
+	info, err := os.Stat(filepath.Join(e.config.Dir, filepath.FromSlash(p.item.Path)))
+	if err != nil {
+		return err
+	}
+	p.item.Bytes = info.Size()
+	e.finished = append(e.finished, p.item)
+	return nil
+}
+
+// open creates the file at path, relative to the export directory
+func (e *Exporter) open(path string) (fileWriter, error) {
+	fullPath := filepath.Join(e.config.Dir, path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create partition directory: %w", err)
+	}
+	f, err := os.Create(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", fullPath, err)
+	}
+	var w fileWriter
+	if e.config.Format == FormatParquet {
+		w, err = newParquetWriter(f, e.config.Compression, e.config.RowGroupSize)
+	} else {
+		w = newCSVWriter(f, e.config.Compression == CompressionGzip)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (e *Exporter) extension() string {
+	if e.config.Format == FormatParquet {
+		return "parquet"
+	}
+	if e.config.Compression == CompressionGzip {
+		return "csv.gz"
+	}
+	return "csv"
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package export
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 3, 1, 23, 59, 0, 0, time.UTC)
+
+func testEvents() []*models.GameEvent {
+	return []*models.GameEvent{
+		{Player: "tom", BalloonColor: "red", Score: 10, EventTS: start, SessionID: "s1"},
+		{Player: "jerry", BalloonColor: "blue", Score: 20, FavoriteColorBonus: true, EventTS: start.Add(30 * time.Second), SessionID: "s1"},
+		// the session goes past midnight
+		{Player: "tom", BalloonColor: "red", Score: 10, EventTS: start.Add(2 * time.Minute), SessionID: "s1"},
+		{Player: "spike", BalloonColor: "green", Score: 5, EventTS: start.Add(time.Hour)},
+	}
+}
+
+func export(t *testing.T, config Config) *Manifest {
+	e, err := New(config)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for _, event := range testEvents() {
+		assert.NoError(t, e.Write(event))
+	}
+	manifest, err := e.Close()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	data, err := os.ReadFile(filepath.Join(config.Dir, ManifestFile))
+	assert.NoError(t, err)
+	var written Manifest
+	assert.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, manifest.Files, written.Files)
+	return manifest
+}
+
+func TestExportParquet(t *testing.T) {
+	dir := t.TempDir()
+	manifest := export(t, Config{Dir: dir, Format: FormatParquet, Compression: CompressionSnappy, RowGroupSize: DefaultRowGroupSize, Source: "test"})
+
+	assert.Equal(t, 4, manifest.Rows)
+	assert.Equal(t, "test", manifest.Source)
+	if !assert.Len(t, manifest.Files, 3) {
+		return
+	}
+	first := manifest.Files[0]
+	assert.Equal(t, "date=2025-03-01/session=s1/part-00000.parquet", first.Path)
+	assert.Equal(t, 2, first.Rows)
+	assert.Equal(t, start, first.MinEventTS)
+	assert.Equal(t, start.Add(30*time.Second), first.MaxEventTS)
+	assert.Positive(t, first.Bytes)
+	assert.Equal(t, "date=2025-03-02/session=__HIVE_DEFAULT_PARTITION__/part-00000.parquet", manifest.Files[1].Path)
+	assert.Equal(t, "date=2025-03-02/session=s1/part-00000.parquet", manifest.Files[2].Path)
+
+	f, err := local.NewLocalFileReader(filepath.Join(dir, first.Path))
+	assert.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+	pr, err := reader.NewParquetReader(f, new(parquetRow), 1)
+	assert.NoError(t, err)
+	defer pr.ReadStop()
+	rows := make([]parquetRow, pr.GetNumRows())
+	assert.NoError(t, pr.Read(&rows))
+	assert.Equal(t, []parquetRow{
+		{Player: "tom", BalloonColor: "red", Score: 10, EventTS: start.UnixMicro(), SessionID: "s1"},
+		{Player: "jerry", BalloonColor: "blue", Score: 20, FavoriteColorBonus: true, EventTS: start.Add(30 * time.Second).UnixMicro(), SessionID: "s1"},
+	}, rows)
+}
+
+func TestExportCSV(t *testing.T) {
+	dir := t.TempDir()
+	manifest := export(t, Config{Dir: dir, Format: FormatCSV, Compression: CompressionGzip})
+	if !assert.Len(t, manifest.Files, 3) {
+		return
+	}
+	assert.Equal(t, "date=2025-03-01/session=s1/part-00000.csv.gz", manifest.Files[0].Path)
+
+	f, err := os.Open(filepath.Join(dir, manifest.Files[0].Path))
+	assert.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+	gz, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	records, err := csv.NewReader(gz).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		csvHeader,
+		{"tom", "red", "10", "false", "2025-03-01T23:59:00Z", "s1", ""},
+		{"jerry", "blue", "20", "true", "2025-03-01T23:59:30Z", "s1", ""},
+	}, records)
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, Config{Dir: "out", Format: FormatParquet, Compression: CompressionZstd, RowGroupSize: 1024}.Validate())
+	assert.Error(t, Config{Dir: "out", Format: FormatParquet, Compression: "brotli", RowGroupSize: 1024}.Validate())
+	assert.Error(t, Config{Dir: "out", Format: FormatParquet, Compression: CompressionNone}.Validate())
+	assert.Error(t, Config{Dir: "out", Format: FormatCSV, Compression: CompressionSnappy}.Validate())
+	assert.Error(t, Config{Dir: "out", Format: "avro", Compression: CompressionNone}.Validate())
+	assert.Error(t, Config{Format: FormatCSV, Compression: CompressionNone}.Validate())
+}
+
+func TestExportRejectsUnsafeSession(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "export")
+	e, err := New(Config{Dir: dir, Format: FormatCSV, Compression: CompressionNone})
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, session := range []string{"../../..", "..", "a/b", `a\b`, "s1 "} {
+		err := e.Write(&models.GameEvent{Player: "tom", BalloonColor: "red", EventTS: start, SessionID: session})
+		assert.ErrorIs(t, err, ErrInvalidSession, session)
+	}
+	assert.NoError(t, e.Write(&models.GameEvent{Player: "tom", BalloonColor: "red", EventTS: start, SessionID: "9b2f6c1e-2f4b-4a57-a3f4-1f0e0a6c3d21"}))
+	manifest, err := e.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, manifest.Rows)
+
+	// nothing was written next to the export directory
+	entries, err := os.ReadDir(root)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestCloseFinishesEveryFile(t *testing.T) {
+	dir := t.TempDir()
+	e, err := New(Config{Dir: dir, Format: FormatCSV, Compression: CompressionNone})
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, session := range []string{"s1", "s2"} {
+		assert.NoError(t, e.Write(&models.GameEvent{Player: "tom", BalloonColor: "red", EventTS: start, SessionID: session}))
+	}
+	// a file removed during the export fails the close, the other files are still finished
+	assert.NoError(t, os.Remove(filepath.Join(dir, "date=2025-03-01", "session=s1", "part-00000.csv")))
+	_, err = e.Close()
+	assert.Error(t, err)
+	assert.Empty(t, e.partitions)
+
+	f, err := os.Open(filepath.Join(dir, "date=2025-03-01", "session=s2", "part-00000.csv"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close() //nolint:errcheck
+	rows, err := csv.NewReader(f).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+}
+
+func TestExportCapsOpenFiles(t *testing.T) {
+	dir := t.TempDir()
+	e, err := New(Config{Dir: dir, Format: FormatParquet, Compression: CompressionSnappy, RowGroupSize: DefaultRowGroupSize, MaxOpenFiles: 1})
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, session := range []string{"s1", "s2", "s1"} {
+		assert.NoError(t, e.Write(&models.GameEvent{Player: "tom", BalloonColor: "red", EventTS: start, SessionID: session}))
+		assert.LessOrEqual(t, len(e.partitions), 1)
+	}
+	manifest, err := e.Close()
+	if !assert.NoError(t, err) {
+		return
+	}
+	// the partition written again continues in a new part file
+	assert.Equal(t, 3, manifest.Rows)
+	paths := make([]string, 0, len(manifest.Files))
+	for _, item := range manifest.Files {
+		paths = append(paths, item.Path)
+		assert.Equal(t, 1, item.Rows)
+		assert.Positive(t, item.Bytes)
+	}
+	assert.Equal(t, []string{
+		"date=2025-03-01/session=s1/part-00000.parquet",
+		"date=2025-03-01/session=s1/part-00001.parquet",
+		"date=2025-03-01/session=s2/part-00000.parquet",
+	}, paths)
+}
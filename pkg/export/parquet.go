@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package export
+
+import (
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+	"os"
+)
+
+// parquetRow is the Parquet schema of a game event
+type parquetRow struct {
+	Player             string `parquet:"name=player, type=BYTE_ARRAY, convertedtype=UTF8"`
+	BalloonColor       string `parquet:"name=balloon_color, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Score              int32  `parquet:"name=score, type=INT32"`
+	FavoriteColorBonus bool   `parquet:"name=favorite_color_bonus, type=BOOLEAN"`
+	EventTS            int64  `parquet:"name=event_ts, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+	SessionID          string `parquet:"name=session_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	RoomID             string `parquet:"name=room_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// parquetCodec maps the compression name to the Parquet codec
+func parquetCodec(compression string) (parquet.CompressionCodec, error) {
+	switch compression {
+	case CompressionNone:
+		return parquet.CompressionCodec_UNCOMPRESSED, nil
+	case CompressionSnappy:
+		return parquet.CompressionCodec_SNAPPY, nil
+	case CompressionGzip:
+		return parquet.CompressionCodec_GZIP, nil
+	case CompressionZstd:
+		return parquet.CompressionCodec_ZSTD, nil
+	case CompressionLZ4:
+		return parquet.CompressionCodec_LZ4, nil
+	default:
+		return 0, fmt.Errorf("unknown parquet compression %q, must be one of none, snappy, gzip, zstd or lz4", compression)
+	}
+}
+
+type parquetWriter struct {
+	file *os.File
+	pw   *writer.ParquetWriter
+}
+
+func newParquetWriter(f *os.File, compression string, rowGroupSize int64) (*parquetWriter, error) {
+	codec, err := parquetCodec(compression)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := writer.NewParquetWriterFromWriter(f, new(parquetRow), 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	pw.CompressionType = codec
+	pw.RowGroupSize = rowGroupSize
+	return &parquetWriter{file: f, pw: pw}, nil
+}
+
+func (w *parquetWriter) write(event *models.GameEvent) error {
+	return w.pw.Write(parquetRow{
+		Player:             event.Player,
+		BalloonColor:       event.BalloonColor,
+		Score:              int32(event.Score),
+		FavoriteColorBonus: event.FavoriteColorBonus,
+		EventTS:            event.EventTS.UnixMicro(),
+		SessionID:          event.SessionID,
+		RoomID:             event.RoomID,
+	})
+}
+
+func (w *parquetWriter) close() error {
+	if err := w.pw.WriteStop(); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package leaderboard
+
+import (
+	"context"
+	"github.com/kameshsampath/balloon-popper/pkg/consumer"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
+)
+
+// TapScoreSink records every score event sent through it on the leaderboard
+type TapScoreSink struct {
+	sink  producer.ScoreSink
+	board *Leaderboard
+}
+
+// NewTapScoreSink taps the score events sent to sink
+func NewTapScoreSink(sink producer.ScoreSink, board *Leaderboard) *TapScoreSink {
+	return &TapScoreSink{
+		sink:  sink,
+		board: board,
+	}
+}
+
+func (t *TapScoreSink) Start() error {
+	return t.sink.Start()
+}
+
+// Ready checks the tapped sink
+func (t *TapScoreSink) Ready(ctx context.Context) error {
+	return producer.Ready(ctx, t.sink)
+}
+
+func (t *TapScoreSink) Stop() error {
+	return t.sink.Stop()
+}
+
+func (t *TapScoreSink) SendScore(ctx context.Context, event *models.GameEvent) error {
+	if err := t.sink.SendScore(ctx, event); err != nil {
+		return err
+	}
+	t.board.Record(event)
+	return nil
+}
+
+func (t *TapScoreSink) SendScoreBatch(ctx context.Context, events []*models.GameEvent) error {
+	if err := t.sink.SendScoreBatch(ctx, events); err != nil {
+		return err
+	}
+	for _, event := range events {
+		t.board.Record(event)
+	}
+	return nil
+}
+
+// TopicFeed rebuilds the leaderboard from the score topic and keeps it up to date
+type TopicFeed struct {
+	reader *consumer.Reader
+	board  *Leaderboard
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewTopicFeed feeds board with the score events read by reader
+func NewTopicFeed(reader *consumer.Reader, board *Leaderboard) *TopicFeed {
+	return &TopicFeed{
+		reader: reader,
+		board:  board,
+	}
+}
+
+// Start reads the topic in the background
+func (f *TopicFeed) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	f.done = make(chan struct{})
+	go func() {
+		defer close(f.done)
+		f.reader.Follow(ctx, f.board.Record)
+	}()
+}
+
+// Stop stops reading the topic and closes the reader
+func (f *TopicFeed) Stop() {
+	if f.cancel != nil {
+		f.cancel()
+		<-f.done
+	}
+	f.reader.Close()
+}
+
+var _ producer.ScoreSink = (*TapScoreSink)(nil)
@@ -0,0 +1,255 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package leaderboard
+
+import (
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// ModeOff disables the leaderboard
+	ModeOff = "off"
+	// ModeTap feeds the leaderboard with the score events sent by this server
+	ModeTap = "tap"
+	// ModeTopic feeds the leaderboard from the score topic, rebuilding it on restart
+	ModeTopic = "topic"
+)
+
+// DefaultLimit is the number of players listed when no limit is given
+const DefaultLimit = 10
+
+const (
+	// maxEndedSessions is the number of ended sessions kept, the older ones are evicted
+	// as their standings are in the session history
+	maxEndedSessions = 10
+	// maxSessions caps the sessions kept, e.g. when rebuilt from the topic, the oldest are evicted first
+	maxSessions = 100
+)
+
+// Leaderboard aggregates the score events into a PlayerScore per player and session
+type Leaderboard struct {
+	mu       sync.RWMutex
+	sessions map[string]*session
+	latest   *session
+	// unbounded keeps every session, e.g. for a replay
+	unbounded bool
+}
+
+// session holds the scores of a game session
+type session struct {
+	id        string
+	startedAt time.Time
+	ended     bool
+	players   map[string]*models.PlayerScore
+}
+
+// New creates an empty leaderboard that evicts the older sessions
+func New() *Leaderboard {
+	return &Leaderboard{
+		sessions: make(map[string]*session),
+	}
+}
+
+// NewUnbounded creates an empty leaderboard that never evicts a session
+func NewUnbounded() *Leaderboard {
+	l := New()
+	l.unbounded = true
+	return l
+}
+
+// Record adds the score of event to its player and session
+func (l *Leaderboard) Record(event *models.GameEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s, ok := l.sessions[event.SessionID]
+	if !ok {
+		s = &session{
+			id:        event.SessionID,
+			startedAt: event.EventTS,
+			players:   make(map[string]*models.PlayerScore),
+		}
+		l.sessions[event.SessionID] = s
+		defer l.evict()
+	}
+	if event.EventTS.Before(s.startedAt) {
+		s.startedAt = event.EventTS
+	}
+	if l.latest == nil || s.startedAt.After(l.latest.startedAt) {
+		l.latest = s
+	}
+
+	ps, ok := s.players[event.Player]
+	if !ok {
+		ps = &models.PlayerScore{Player: event.Player}
+		s.players[event.Player] = ps
+	}
+	ps.TotalScore += event.Score
+	switch {
+	case event.FavoriteColorBonus:
+		ps.BonusHits++
+	case event.Score < 0:
+		// a negative hit takes its penalty off
+		ps.NegativeHits++
+	default:
+		ps.RegularHits++
+	}
+	if event.EventTS.After(ps.LastUpdated) {
+		ps.LastUpdated = event.EventTS
+	}
+}
+
+// End marks the session as ended, the ended sessions are evicted once more recent ones ended
+func (l *Leaderboard) End(sessionID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.sessions[sessionID]; ok {
+		s.ended = true
+		l.evict()
+	}
+}
+
+// evict drops the oldest sessions beyond maxEndedSessions ended ones or maxSessions in total,
+// the latest session is always kept, callers hold the lock
+func (l *Leaderboard) evict() {
+	if l.unbounded {
+		return
+	}
+	ended := 0
+	for _, s := range l.sessions {
+		if s.ended {
+			ended++
+		}
+	}
+	if ended <= maxEndedSessions && len(l.sessions) <= maxSessions {
+		return
+	}
+	sessions := make([]*session, 0, len(l.sessions))
+	for _, s := range l.sessions {
+		sessions = append(sessions, s)
+	}
+	sortSessions(sessions)
+	for _, s := range sessions {
+		if ended <= maxEndedSessions && len(l.sessions) <= maxSessions {
+			return
+		}
+		if s == l.latest || (!s.ended && len(l.sessions) <= maxSessions) {
+			continue
+		}
+		delete(l.sessions, s.id)
+		if s.ended {
+			ended--
+		}
+	}
+}
+
+// Latest returns the ID of the most recently started session
+func (l *Leaderboard) Latest() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.latest == nil {
+		return ""
+	}
+	return l.latest.id
+}
+
+// Sessions returns the IDs of the sessions, in the order they started
+func (l *Leaderboard) Sessions() []string {
+	l.mu.RLock()
+	sessions := make([]*session, 0, len(l.sessions))
+	for _, s := range l.sessions {
+		sessions = append(sessions, s)
+	}
+	l.mu.RUnlock()
+
+	sortSessions(sessions)
+	ids := make([]string, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.id
+	}
+	return ids
+}
+
+// sortSessions orders the sessions the way they started
+func sortSessions(sessions []*session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].startedAt.Equal(sessions[j].startedAt) {
+			return sessions[i].startedAt.Before(sessions[j].startedAt)
+		}
+		return sessions[i].id < sessions[j].id
+	})
+}
+
+// Top returns up to limit players of the session ranked by their total score,
+// a limit of 0 or less returns all the players
+func (l *Leaderboard) Top(sessionID string, limit int) models.Leaderboard {
+	board := models.Leaderboard{
+		SessionID: sessionID,
+		Players:   l.ranked(sessionID),
+	}
+	if limit > 0 && len(board.Players) > limit {
+		board.Players = board.Players[:limit]
+	}
+	return board
+}
+
+// Player returns the ranked score of player in the session
+func (l *Leaderboard) Player(sessionID, player string) (models.PlayerScore, bool) {
+	for _, ps := range l.ranked(sessionID) {
+		if ps.Player == player {
+			return ps, true
+		}
+	}
+	return models.PlayerScore{}, false
+}
+
+// ranked returns a ranked copy of the session scores
+func (l *Leaderboard) ranked(sessionID string) []models.PlayerScore {
+	l.mu.RLock()
+	s, ok := l.sessions[sessionID]
+	players := make([]models.PlayerScore, 0)
+	if ok {
+		for _, ps := range s.players {
+			players = append(players, *ps)
+		}
+	}
+	l.mu.RUnlock()
+	return Rank(players)
+}
+
+// Rank orders the player scores, highest first, and numbers them, players with the
+// same score share the rank
+func Rank(players []models.PlayerScore) []models.PlayerScore {
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].TotalScore != players[j].TotalScore {
+			return players[i].TotalScore > players[j].TotalScore
+		}
+		return players[i].Player < players[j].Player
+	})
+	for i := range players {
+		if i > 0 && players[i].TotalScore == players[i-1].TotalScore {
+			players[i].Rank = players[i-1].Rank
+		} else {
+			players[i].Rank = i + 1
+		}
+	}
+	return players
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/consumer"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func scoreEvent(session, player string, score int, bonus bool, ts time.Time) *models.GameEvent {
+	event := models.NewGameEvent(player, "red", score, bonus)
+	event.SessionID = session
+	event.EventTS = ts
+	return event
+}
+
+func TestLeaderboard(t *testing.T) {
+	board := New()
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	board.Record(scoreEvent("s1", "tom", 100, false, start))
+	board.Record(scoreEvent("s1", "jerry", 70, true, start.Add(time.Second)))
+	board.Record(scoreEvent("s1", "jerry", 30, false, start.Add(2*time.Second)))
+	board.Record(scoreEvent("s1", "spike", -20, false, start.Add(3*time.Second)))
+	board.Record(scoreEvent("s2", "tom", 10, false, start.Add(time.Hour)))
+
+	assert.Equal(t, "s2", board.Latest())
+	assert.Equal(t, []string{"s1", "s2"}, board.Sessions())
+
+	top := board.Top("s1", 0)
+	assert.Equal(t, "s1", top.SessionID)
+	if assert.Len(t, top.Players, 3) {
+		// tom and jerry are tied, ordered by name
+		assert.Equal(t, models.PlayerScore{Rank: 1, Player: "jerry", TotalScore: 100, BonusHits: 1, RegularHits: 1, LastUpdated: start.Add(2 * time.Second)}, top.Players[0])
+		assert.Equal(t, 1, top.Players[1].Rank)
+		assert.Equal(t, "tom", top.Players[1].Player)
+		assert.Equal(t, 3, top.Players[2].Rank)
+		assert.Equal(t, 1, top.Players[2].NegativeHits)
+		assert.Zero(t, top.Players[2].RegularHits)
+	}
+	assert.Len(t, board.Top("s1", 2).Players, 2)
+	assert.Empty(t, board.Top("unknown", 10).Players)
+
+	ps, ok := board.Player("s2", "tom")
+	assert.True(t, ok)
+	assert.Equal(t, 10, ps.TotalScore)
+	_, ok = board.Player("s2", "jerry")
+	assert.False(t, ok)
+}
+
+func TestLeaderboardEvictsSessions(t *testing.T) {
+	board := New()
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	session := func(i int) string { return fmt.Sprintf("s%03d", i) }
+
+	for i := range maxEndedSessions + 2 {
+		board.Record(scoreEvent(session(i), "tom", 10, false, start.Add(time.Duration(i)*time.Minute)))
+		board.End(session(i))
+	}
+	// only the most recent ended sessions are kept
+	sessions := board.Sessions()
+	assert.Len(t, sessions, maxEndedSessions)
+	assert.Equal(t, session(2), sessions[0])
+	assert.Equal(t, session(maxEndedSessions+1), board.Latest())
+
+	// sessions that never ended, e.g. rebuilt from the topic, are capped too
+	board = New()
+	for i := range maxSessions + 5 {
+		board.Record(scoreEvent(session(i), "tom", 10, false, start.Add(time.Duration(i)*time.Minute)))
+	}
+	sessions = board.Sessions()
+	assert.Len(t, sessions, maxSessions)
+	assert.Equal(t, session(5), sessions[0])
+	_, ok := board.Player(session(0), "tom")
+	assert.False(t, ok)
+}
+
+func TestTapScoreSink(t *testing.T) {
+	board := New()
+	memory := producer.NewMemoryScoreSink()
+	tap := NewTapScoreSink(memory, board)
+	assert.NoError(t, tap.Start())
+
+	ctx := context.Background()
+	assert.NoError(t, tap.SendScore(ctx, scoreEvent("s1", "tom", 10, false, time.Now())))
+	assert.NoError(t, tap.SendScoreBatch(ctx, []*models.GameEvent{
+		scoreEvent("s1", "tom", 20, true, time.Now()),
+		scoreEvent("s1", "jerry", 5, false, time.Now()),
+	}))
+
+	assert.Len(t, memory.Events(), 3)
+	ps, ok := board.Player("s1", "tom")
+	assert.True(t, ok)
+	assert.Equal(t, 30, ps.TotalScore)
+	assert.NoError(t, tap.Stop())
+}
+
+func TestTopicFeedRebuildsLeaderboard(t *testing.T) {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "scores"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cluster.Close()
+	config := producer.DefaultProducerConfig()
+	config.Seeds = cluster.ListenAddrs()
+
+	p, err := producer.NewKafkaScoreProducerWithConfig(config, "scores",
+		producer.WithCloudEvents(producer.CloudEventsStructured, producer.EventSource("test")))
+	assert.NoError(t, err)
+	assert.NoError(t, p.Start())
+	assert.NoError(t, p.SendScoreBatch(context.Background(), []*models.GameEvent{
+		scoreEvent("s1", "tom", 100, false, time.Now()),
+		scoreEvent("s1", "jerry", 50, true, time.Now()),
+		scoreEvent("s1", "tom", 25, false, time.Now()),
+	}))
+	assert.NoError(t, p.Stop())
+
+	// a new feed, as after a restart, reads the topic from the beginning
+	reader, err := consumer.NewReader(config, "scores", consumer.NewDecoder(nil), zap.NewNop().Sugar())
+	assert.NoError(t, err)
+	board := New()
+	feed := NewTopicFeed(reader, board)
+	feed.Start()
+	defer feed.Stop()
+
+	assert.Eventually(t, func() bool {
+		ps, ok := board.Player("s1", "tom")
+		return ok && ps.TotalScore == 125
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "s1", board.Latest())
+	assert.Len(t, board.Top("s1", 10).Players, 2)
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package loadgen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// client calls the HTTP endpoints of the game server
+type client struct {
+	baseURL string
+	http    *http.Client
+	token   string
+}
+
+func newClient(baseURL string) *client {
+	return &client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// login gets the JWT used by the admin endpoints
+func (c *client) login(ctx context.Context, username, password string) error {
+	form := url.Values{"username": {username}, "password": {password}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := c.do(req, &body); err != nil {
+		return fmt.Errorf("failed to login as %s: %w", username, err)
+	}
+	c.token = body.Token
+	return nil
+}
+
+// admin posts to the admin endpoint action, e.g. start or stop
+func (c *client) admin(ctx context.Context, action string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/admin/"+action, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	return c.do(req, nil)
+}
+
+// gameConfig gets the colors and characters of the game
+func (c *client) gameConfig(ctx context.Context) (*models.GameConfig, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/config", nil)
+	if err != nil {
+		return nil, err
+	}
+	var config models.GameConfig
+	if err := c.do(req, &config); err != nil {
+		return nil, fmt.Errorf("failed to get the game config: %w", err)
+	}
+	return &config, nil
+}
+
+// do sends the request and decodes the JSON response into out, when not nil
+func (c *client) do(req *http.Request, out any) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// wsURL is the WebSocket URL of player playing character
+func (c *client) wsURL(player, character string) string {
+	u := c.baseURL
+	if strings.HasPrefix(u, "https://") {
+		u = "wss://" + strings.TrimPrefix(u, "https://")
+	} else {
+		u = "ws://" + strings.TrimPrefix(u, "http://")
+	}
+	return u + "/ws/" + url.PathEscape(player) + "?character=" + url.QueryEscape(character)
+}
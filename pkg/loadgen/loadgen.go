@@ -0,0 +1,369 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package loadgen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"go.uber.org/zap"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// negativeProbability matches the share of negative balloons of the game client
+const negativeProbability = 0.15
+
+// replyTimeout is how long a player waits for the reply to a pop
+const replyTimeout = 10 * time.Second
+
+// Config configures a load test
+type Config struct {
+	// ServerURL is the base URL of the game server, e.g. http://localhost:8080
+	ServerURL string
+	// Username and Password of an admin user
+	Username string
+	Password string
+	// StartGame starts a game session before connecting the players
+	StartGame bool
+	// StopGame stops the game session once the load test is over
+	StopGame bool
+	// Players is the number of concurrent WebSocket connections
+	Players int
+	// PlayerPrefix prefixes the player names, followed by the player number
+	PlayerPrefix string
+	// Rate is the number of pops per second of each player
+	Rate float64
+	// Duration of the load test, 0 runs until the context is done
+	Duration time.Duration
+}
+
+// Validate checks the load test settings
+func (c Config) Validate() error {
+	if c.ServerURL == "" {
+		return fmt.Errorf("server URL must not be empty")
+	}
+	if c.Username == "" {
+		return fmt.Errorf("admin user name must not be empty")
+	}
+	if c.Players <= 0 {
+		return fmt.Errorf("players must be positive, got %d", c.Players)
+	}
+	if c.Rate <= 0 {
+		return fmt.Errorf("rate must be positive, got %v", c.Rate)
+	}
+	if c.Duration < 0 {
+		return fmt.Errorf("duration must not be negative, got %v", c.Duration)
+	}
+	return nil
+}
+
+// Report summarizes a load test
+type Report struct {
+	Players         int            `json:"players"`
+	Connected       int            `json:"connected"`
+	DurationSeconds float64        `json:"duration_seconds"`
+	Sent            int            `json:"sent"`
+	ScoreUpdates    int            `json:"score_updates"`
+	Rejected        int            `json:"rejected"`
+	PopsPerSecond   float64        `json:"pops_per_second"`
+	Latency         LatencyStats   `json:"latency"`
+	Errors          map[string]int `json:"errors"`
+}
+
+// ErrorKinds returns the kinds of errors of the report, sorted
+func (r *Report) ErrorKinds() []string {
+	kinds := make([]string, 0, len(r.Errors))
+	for kind := range r.Errors {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Run logs in, optionally starts a game, and connects the players that pop balloons
+// until the duration is over, the game ends or ctx is done
+func Run(ctx context.Context, config Config, log *zap.SugaredLogger) (*Report, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	c := newClient(config.ServerURL)
+	if err := c.login(ctx, config.Username, config.Password); err != nil {
+		return nil, err
+	}
+	if config.StartGame {
+		if err := c.admin(ctx, "start"); err != nil {
+			return nil, fmt.Errorf("failed to start the game: %w", err)
+		}
+		log.Infof("Started a game on %s", config.ServerURL)
+	}
+	gameConfig, err := c.gameConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	characters := make([]string, 0, len(gameConfig.CharacterFavorites))
+	for character := range gameConfig.CharacterFavorites {
+		characters = append(characters, character)
+	}
+	colors := make([]string, 0, len(gameConfig.Colors))
+	for color := range gameConfig.Colors {
+		colors = append(colors, color)
+	}
+	if len(characters) == 0 || len(colors) == 0 {
+		return nil, fmt.Errorf("the game config has no characters or colors")
+	}
+	sort.Strings(characters)
+	sort.Strings(colors)
+
+	if config.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Duration)
+		defer cancel()
+	}
+
+	rec := newRecorder()
+	started := time.Now()
+	var wg sync.WaitGroup
+	for i := 1; i <= config.Players; i++ {
+		p := &player{
+			name:       fmt.Sprintf("%s%d", config.PlayerPrefix, i),
+			character:  characters[rand.IntN(len(characters))],
+			config:     gameConfig,
+			colors:     colors,
+			interval:   time.Duration(float64(time.Second) / config.Rate),
+			recorder:   rec,
+			bonusShare: gameConfig.BonusProbability,
+			// the servers spawning the balloons score the pops by balloon ID only
+			serverSpawning: gameConfig.BalloonSpawning == "server",
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.run(ctx, c.wsURL(p.name, p.character))
+		}()
+	}
+	log.Infof("Connecting %d players popping %v balloons per second each", config.Players, config.Rate)
+	wg.Wait()
+	elapsed := time.Since(started)
+
+	if config.StopGame {
+		// the game is stopped even when the load test was interrupted
+		stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		if err := c.admin(stopCtx, "stop"); err != nil {
+			log.Warnf("Failed to stop the game: %v", err)
+		}
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	report := &Report{
+		Players:         config.Players,
+		Connected:       rec.connected,
+		DurationSeconds: elapsed.Seconds(),
+		Sent:            rec.sent,
+		ScoreUpdates:    len(rec.latencies),
+		Rejected:        rec.rejected,
+		Latency:         latencyStats(rec.latencies),
+		Errors:          rec.errors,
+	}
+	if elapsed > 0 {
+		report.PopsPerSecond = float64(rec.sent) / elapsed.Seconds()
+	}
+	return report, nil
+}
+
+// player pops balloons on its own WebSocket connection
+type player struct {
+	name       string
+	character  string
+	config     *models.GameConfig
+	colors     []string
+	interval   time.Duration
+	bonusShare float64
+	recorder   *recorder
+
+	serverSpawning bool
+	// balloons are the balloons spawned for the player, oldest first
+	balloons []models.Balloon
+}
+
+// frame is the part of the frames sent by the server a player looks at
+type frame struct {
+	Type    string         `json:"type"`
+	Code    string         `json:"code"`
+	Balloon models.Balloon `json:"balloon"`
+}
+
+// run connects the player and sends a pop every interval, waiting for its reply
+func (p *player) run(ctx context.Context, wsURL string) {
+	ws, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.recorder.fail("connect")
+		}
+		return
+	}
+	p.recorder.connect()
+	// unblock a pending read when the load test ends
+	stop := context.AfterFunc(ctx, func() {
+		_ = ws.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		_ = ws.Close()
+	})
+	defer func() {
+		if stop() {
+			_ = ws.Close()
+		}
+	}()
+
+	// spread the first pops of the players over the interval
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(rand.N(p.interval)):
+	}
+	for {
+		if !p.pop(ctx, ws) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pop sends a pop and reads its reply, returning false when the connection is over
+func (p *player) pop(ctx context.Context, ws *websocket.Conn) bool {
+	msg := p.message()
+	if p.serverSpawning {
+		b, ok := p.balloon(ctx, ws)
+		if !ok {
+			return false
+		}
+		msg = models.GameMessage{Type: "pop", Player: p.name, BalloonID: b.ID}
+	}
+	sent := time.Now()
+	if err := ws.WriteJSON(msg); err != nil {
+		if ctx.Err() == nil {
+			p.recorder.fail("write")
+		}
+		return false
+	}
+	p.recorder.send()
+
+	_ = ws.SetReadDeadline(sent.Add(replyTimeout))
+	reply, ok := p.next(ctx, ws, "score_update", "error")
+	latency := time.Since(sent)
+	if !ok {
+		return false
+	}
+	switch reply.Type {
+	case "score_update":
+		p.recorder.scored(latency)
+	case "error":
+		p.recorder.reject(reply.Code)
+	}
+	return true
+}
+
+// balloon takes the oldest balloon that can still be popped, waiting for the next
+// one to spawn when there is none, it returns false when the connection is over
+func (p *player) balloon(ctx context.Context, ws *websocket.Conn) (models.Balloon, bool) {
+	for {
+		now := time.Now()
+		for len(p.balloons) > 0 {
+			b := p.balloons[0]
+			p.balloons = p.balloons[1:]
+			if now.Before(b.ExpiresAt) {
+				return b, true
+			}
+		}
+		_ = ws.SetReadDeadline(now.Add(replyTimeout))
+		if _, ok := p.next(ctx, ws, "balloon_spawned"); !ok {
+			return models.Balloon{}, false
+		}
+	}
+}
+
+// next reads the frames of ws until one of types, keeping the spawned balloons and
+// skipping the other frames, e.g. the time_remaining ticks. An unreadable frame is
+// returned without a type, it returns false when the connection is over
+func (p *player) next(ctx context.Context, ws *websocket.Conn, types ...string) (frame, bool) {
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			switch {
+			case ctx.Err() != nil:
+			case errors.As(err, &netErr) && netErr.Timeout():
+				p.recorder.fail("timeout")
+			case websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway):
+			default:
+				// e.g. the server dropped the connection when the game was stopped
+				p.recorder.fail("disconnected")
+			}
+			return frame{}, false
+		}
+		var f frame
+		if err := json.Unmarshal(data, &f); err != nil {
+			p.recorder.fail("invalid_reply")
+			return frame{}, true
+		}
+		if f.Type == "balloon_spawned" {
+			p.balloons = append(p.balloons, f.Balloon)
+		}
+		if slices.Contains(types, f.Type) {
+			return f, true
+		}
+	}
+}
+
+// message builds a pop with the shape sent by the game client
+func (p *player) message() models.GameMessage {
+	msg := models.GameMessage{
+		Player:       p.name,
+		Character:    p.character,
+		BalloonColor: p.colors[rand.IntN(len(p.colors))],
+	}
+	favorites := p.config.CharacterFavorites[p.character]
+	negatives := p.config.CharacterNegatives[p.character]
+	// the servers without negative colors take the favorite colors flagged as negative hits
+	if p.config.CharacterNegatives == nil {
+		negatives = favorites
+	}
+	roll := rand.Float64()
+	switch {
+	case len(negatives) > 0 && roll < negativeProbability:
+		msg.BalloonColor = negatives[rand.IntN(len(negatives))]
+		msg.NegativeHit = true
+	case len(favorites) > 0 && roll < negativeProbability+p.bonusShare:
+		msg.BalloonColor = favorites[rand.IntN(len(favorites))]
+	}
+	return msg
+}
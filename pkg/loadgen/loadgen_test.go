@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package loadgen
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGame answers like the game server, rejecting the negative hits
+type fakeGame struct {
+	mu      sync.Mutex
+	actions []string
+	players map[string]bool
+	// spawning spawns the balloons on the fake server, one after each pop
+	spawning bool
+}
+
+func (f *fakeGame) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("username") != "admin" || r.FormValue("password") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "t0ken"})
+	})
+	mux.HandleFunc("POST /admin/{action}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.mu.Lock()
+		f.actions = append(f.actions, r.PathValue("action"))
+		f.mu.Unlock()
+	})
+	mux.HandleFunc("GET /config", func(w http.ResponseWriter, _ *http.Request) {
+		spawning := "client"
+		if f.spawning {
+			spawning = "server"
+		}
+		_ = json.NewEncoder(w).Encode(models.GameConfig{
+			Colors:             map[string]int{"red": 10, "blue": 20},
+			CharacterFavorites: map[string][]string{"Tom": {"blue"}},
+			BonusProbability:   0.2,
+			BalloonSpawning:    spawning,
+		})
+	})
+	upgrader := websocket.Upgrader{}
+	mux.HandleFunc("GET /ws/{player}", func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close() //nolint:errcheck
+		f.mu.Lock()
+		f.players[r.PathValue("player")] = r.URL.Query().Get("character") == "Tom"
+		f.mu.Unlock()
+		spawned := 0
+		spawn := func() error {
+			spawned++
+			return ws.WriteJSON(models.BalloonSpawned{Type: "balloon_spawned", Balloon: models.Balloon{
+				ID:        strconv.Itoa(spawned),
+				Color:     "red",
+				ExpiresAt: time.Now().Add(time.Minute),
+			}})
+		}
+		if f.spawning && spawn() != nil {
+			return
+		}
+		for {
+			var msg models.GameMessage
+			if err := ws.ReadJSON(&msg); err != nil {
+				return
+			}
+			var reply any = models.ScoreUpdate{Type: "score_update", Event: models.NewGameEvent(msg.Player, msg.BalloonColor, 10, false)}
+			if msg.NegativeHit || (f.spawning && msg.BalloonID != strconv.Itoa(spawned)) {
+				reply = models.ErrorFrame{Type: "error", Code: "negative", Message: "rejected by the test"}
+			}
+			if f.spawning && spawn() != nil {
+				return
+			}
+			if err := ws.WriteJSON(reply); err != nil {
+				return
+			}
+		}
+	})
+	return mux
+}
+
+func TestRun(t *testing.T) {
+	game := &fakeGame{players: make(map[string]bool)}
+	srv := httptest.NewServer(game.handler())
+	defer srv.Close()
+
+	report, err := Run(context.Background(), Config{
+		ServerURL:    srv.URL,
+		Username:     "admin",
+		Password:     "secret",
+		StartGame:    true,
+		StopGame:     true,
+		Players:      5,
+		PlayerPrefix: "bot-",
+		Rate:         100,
+		Duration:     500 * time.Millisecond,
+	}, zap.NewNop().Sugar())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{"start", "stop"}, game.actions)
+	assert.Len(t, game.players, 5)
+	assert.True(t, game.players["bot-1"])
+	assert.Equal(t, 5, report.Connected)
+	assert.Positive(t, report.ScoreUpdates)
+	assert.Positive(t, report.Rejected)
+	assert.Equal(t, report.Rejected, report.Errors["rejected_negative"])
+	// the pops in flight when the test ends get no reply
+	answered := report.ScoreUpdates + report.Rejected
+	assert.LessOrEqual(t, answered, report.Sent)
+	assert.GreaterOrEqual(t, answered, report.Sent-5)
+	assert.Equal(t, report.ScoreUpdates, report.Latency.Count)
+	assert.Greater(t, report.Latency.Max, 0.0)
+
+	_, err = Run(context.Background(), Config{ServerURL: srv.URL, Username: "admin", Password: "wrong", Players: 1, Rate: 1}, zap.NewNop().Sugar())
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestRunServerSpawning(t *testing.T) {
+	game := &fakeGame{players: make(map[string]bool), spawning: true}
+	srv := httptest.NewServer(game.handler())
+	defer srv.Close()
+
+	report, err := Run(context.Background(), Config{
+		ServerURL:    srv.URL,
+		Username:     "admin",
+		Password:     "secret",
+		Players:      3,
+		PlayerPrefix: "bot-",
+		Rate:         100,
+		Duration:     300 * time.Millisecond,
+	}, zap.NewNop().Sugar())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, map[string]bool{"bot-1": true, "bot-2": true, "bot-3": true}, game.players)
+	assert.Positive(t, report.ScoreUpdates)
+	// the players pop the balloons the server spawned for them
+	assert.Zero(t, report.Rejected)
+}
+
+func TestRunStopsWhenCancelled(t *testing.T) {
+	game := &fakeGame{players: make(map[string]bool)}
+	srv := httptest.NewServer(game.handler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	report, err := Run(ctx, Config{ServerURL: srv.URL, Username: "admin", Password: "secret", Players: 3, Rate: 10}, zap.NewNop().Sugar())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Connected)
+	for _, kind := range report.ErrorKinds() {
+		assert.True(t, strings.HasPrefix(kind, "rejected_"), kind)
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	latencies := make([]time.Duration, 0, 100)
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, LatencyStats{Count: 100, Min: 1, Mean: 50.5, P50: 50, P90: 90, P95: 95, P99: 99, Max: 100}, latencyStats(latencies))
+	assert.Equal(t, LatencyStats{}, latencyStats(nil))
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package loadgen
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// LatencyStats summarizes the score_update round trips in milliseconds
+type LatencyStats struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min_ms"`
+	Mean  float64 `json:"mean_ms"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P95   float64 `json:"p95_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+// recorder collects the results of the players
+type recorder struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	connected int
+	sent      int
+	rejected  int
+	errors    map[string]int
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		latencies: make([]time.Duration, 0, 1024),
+		errors:    make(map[string]int),
+	}
+}
+
+func (r *recorder) connect() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connected++
+}
+
+func (r *recorder) send() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent++
+}
+
+func (r *recorder) scored(latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies = append(r.latencies, latency)
+}
+
+// reject counts the error frames by their reason code
+func (r *recorder) reject(code string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rejected++
+	r.errors["rejected_"+code]++
+}
+
+func (r *recorder) fail(kind string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors[kind]++
+}
+
+// latencyStats computes the percentiles with the nearest rank method
+func latencyStats(latencies []time.Duration) LatencyStats {
+	if len(latencies) == 0 {
+		return LatencyStats{}
+	}
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	ms := func(d time.Duration) float64 {
+		return float64(d.Microseconds()) / 1000
+	}
+	percentile := func(p float64) float64 {
+		rank := int(p/100*float64(len(sorted))+0.999999) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		return ms(sorted[rank])
+	}
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return LatencyStats{
+		Count: len(sorted),
+		Min:   ms(sorted[0]),
+		Mean:  ms(total / time.Duration(len(sorted))),
+		P50:   percentile(50),
+		P90:   percentile(90),
+		P95:   percentile(95),
+		P99:   percentile(99),
+		Max:   ms(sorted[len(sorted)-1]),
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package models
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// LoadGameConfig reads the game config from a YAML or JSON file, the settings
+// missing in the file keep their default values
+func LoadGameConfig(file string) (*GameConfig, error) {
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game config %s: %w", file, err)
+	}
+	config, err := ParseGameConfig(data, NewGameConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse game config %s: %w", file, err)
+	}
+	return config, nil
+}
+
+// ParseGameConfig parses a YAML or JSON game config, the settings missing in data
+// keep the values of base. The colors and characters of data replace those of base
+// rather than adding to them
+func ParseGameConfig(data []byte, base *GameConfig) (*GameConfig, error) {
+	config := *base
+	config.Colors, config.CharacterFavorites, config.CharacterNegatives = nil, nil, nil
+	// YAML is a superset of JSON, so this handles both
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	if config.Colors == nil {
+		config.Colors = maps.Clone(base.Colors)
+	}
+	if config.CharacterFavorites == nil {
+		config.CharacterFavorites = maps.Clone(base.CharacterFavorites)
+	}
+	if config.CharacterNegatives == nil {
+		config.CharacterNegatives = maps.Clone(base.CharacterNegatives)
+	}
+	return &config, nil
+}
+
+// Validate checks that the config is consistent, every favorite and negative color
+// scores and no hit is both a bonus and a negative hit
+func (c *GameConfig) Validate() error {
+	var errs []error
+	if len(c.Colors) == 0 {
+		errs = append(errs, errors.New("at least one color is required"))
+	}
+	for _, color := range slices.Sorted(maps.Keys(c.Colors)) {
+		if c.Colors[color] < 0 {
+			errs = append(errs, fmt.Errorf("score of color %q must not be negative, got %d", color, c.Colors[color]))
+		}
+	}
+	if len(c.CharacterFavorites) == 0 {
+		errs = append(errs, errors.New("at least one character is required"))
+	}
+	for _, character := range slices.Sorted(maps.Keys(c.CharacterFavorites)) {
+		favorites := c.CharacterFavorites[character]
+		if len(favorites) == 0 {
+			errs = append(errs, fmt.Errorf("character %q has no favorite colors", character))
+		}
+		for _, color := range favorites {
+			if _, ok := c.Colors[color]; !ok {
+				errs = append(errs, fmt.Errorf("favorite color %q of character %q is not in colors", color, character))
+			}
+		}
+	}
+	for _, character := range slices.Sorted(maps.Keys(c.CharacterNegatives)) {
+		favorites, ok := c.CharacterFavorites[character]
+		if !ok {
+			errs = append(errs, fmt.Errorf("negative colors of unknown character %q", character))
+		}
+		for _, color := range c.CharacterNegatives[character] {
+			if _, ok := c.Colors[color]; !ok {
+				errs = append(errs, fmt.Errorf("negative color %q of character %q is not in colors", color, character))
+			}
+			if slices.Contains(favorites, color) {
+				errs = append(errs, fmt.Errorf("color %q is both a favorite and a negative color of character %q", color, character))
+			}
+		}
+	}
+	if c.NegativePenalty.Ratio < 0 || c.NegativePenalty.Min < 0 {
+		errs = append(errs, errors.New("the negative penalty ratio and min must not be negative"))
+	}
+	if c.BonusProbability < 0 || c.BonusProbability > 1 {
+		errs = append(errs, fmt.Errorf("bonus probability must be between 0 and 1, got %v", c.BonusProbability))
+	}
+	return errors.Join(errs...)
+}
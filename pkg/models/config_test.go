@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultGameConfigIsValid(t *testing.T) {
+	assert.NoError(t, NewGameConfig().Validate())
+}
+
+func TestDefaultGameConfigScoresEveryColor(t *testing.T) {
+	config := NewGameConfig()
+	for _, colors := range []map[string][]string{config.CharacterFavorites, config.CharacterNegatives} {
+		for character, characterColors := range colors {
+			for _, color := range characterColors {
+				assert.Positive(t, config.Colors[color], "color %s of %s has no score", color, character)
+			}
+		}
+	}
+}
+
+func TestLoadGameConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "game.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(`
+colors:
+  red: 100
+  blue: 50
+character_favorites:
+  Mario: [red]
+bonus_probability: 0.3
+`), 0o600))
+
+	config, err := LoadGameConfig(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// the maps of the file replace the defaults, the settings it misses keep them
+	assert.Equal(t, map[string]int{"red": 100, "blue": 50}, config.Colors)
+	assert.Equal(t, map[string][]string{"Mario": {"red"}}, config.CharacterFavorites)
+	assert.Equal(t, 0.3, config.BonusProbability)
+	assert.Equal(t, NegativePenalty{Ratio: 0.5, Min: 10}, config.NegativePenalty)
+	// the default negative colors reference colors the file dropped
+	assert.ErrorContains(t, config.Validate(), `negative color "maroon" of character "Mario" is not in colors`)
+
+	_, err = LoadGameConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestValidateGameConfig(t *testing.T) {
+	testCases := []struct {
+		name   string
+		change func(c *GameConfig)
+		err    string
+	}{
+		{"NoColors", func(c *GameConfig) { c.Colors = nil }, "at least one color is required"},
+		{"NegativeScore", func(c *GameConfig) { c.Colors["red"] = -1 }, `score of color "red" must not be negative`},
+		{"UnknownFavorite", func(c *GameConfig) { c.CharacterFavorites["Mario"] = []string{"teal"} }, `favorite color "teal" of character "Mario" is not in colors`},
+		{"NoFavorites", func(c *GameConfig) { c.CharacterFavorites["Mario"] = nil }, `character "Mario" has no favorite colors`},
+		{"FavoriteNegative", func(c *GameConfig) { c.CharacterNegatives["Mario"] = []string{"red"} }, `color "red" is both a favorite and a negative color of character "Mario"`},
+		{"UnknownCharacter", func(c *GameConfig) { c.CharacterNegatives["Garfield"] = []string{"red"} }, `negative colors of unknown character "Garfield"`},
+		{"Penalty", func(c *GameConfig) { c.NegativePenalty.Min = -5 }, "negative penalty"},
+		{"BonusProbability", func(c *GameConfig) { c.BonusProbability = 2 }, "bonus probability must be between 0 and 1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := NewGameConfig()
+			tc.change(config)
+			assert.ErrorContains(t, config.Validate(), tc.err)
+		})
+	}
+}
@@ -30,31 +30,115 @@ type GameEvent struct {
 	Score              int       `json:"score"`
 	FavoriteColorBonus bool      `json:"favorite_color_bonus"`
 	EventTS            time.Time `json:"event_ts"`
+	SessionID          string    `json:"session_id,omitempty"`
+	RoomID             string    `json:"room_id,omitempty"`
+}
+
+// Lifecycle event types
+const (
+	GameStarted  = "game_started"
+	GameStopped  = "game_stopped"
+	GamePaused   = "game_paused"
+	GameResumed  = "game_resumed"
+	PlayerJoined = "player_joined"
+	PlayerLeft   = "player_left"
+)
+
+// LifecycleEvent marks the start and end of a game session and the players joining and leaving it
+type LifecycleEvent struct {
+	Type         string        `json:"type"`
+	SessionID    string        `json:"session_id"`
+	RoomID       string        `json:"room_id,omitempty"`
+	Player       string        `json:"player,omitempty"`
+	SessionStats *SessionStats `json:"session_stats,omitempty"`
+	EventTS      time.Time     `json:"event_ts"`
 }
 
 // PlayerScore tracks the cumulative score for a player
 type PlayerScore struct {
-	Player      string    `json:"player"`
-	TotalScore  int       `json:"total_score"`
-	BonusHits   int       `json:"bonus_hits"`
-	RegularHits int       `json:"regular_hits"`
-	LastUpdated time.Time `json:"last_updated"`
+	Rank         int       `json:"rank,omitempty"`
+	SessionID    string    `json:"session_id,omitempty"`
+	Player       string    `json:"player"`
+	TotalScore   int       `json:"total_score"`
+	BonusHits    int       `json:"bonus_hits"`
+	RegularHits  int       `json:"regular_hits"`
+	NegativeHits int       `json:"negative_hits"`
+	LastUpdated  time.Time `json:"last_updated"`
+}
+
+// Leaderboard lists the player scores of a game session, highest score first
+type Leaderboard struct {
+	SessionID string        `json:"session_id"`
+	Players   []PlayerScore `json:"players"`
+}
+
+// NegativePenalty sets the points taken off a negative hit, the share of the score of
+// the color and at least Min points, e.g. for the colors that score nothing
+type NegativePenalty struct {
+	Ratio float64 `json:"ratio" yaml:"ratio"`
+	Min   int     `json:"min" yaml:"min"`
 }
 
 // GameConfig holds the game configuration settings
 type GameConfig struct {
-	Colors             map[string]int      `json:"colors"`
-	CharacterFavorites map[string][]string `json:"character_favorites"`
-	BonusProbability   float64             `json:"bonus_probability"`
+	Colors             map[string]int      `json:"colors" yaml:"colors"`
+	CharacterFavorites map[string][]string `json:"character_favorites" yaml:"character_favorites"`
+	// CharacterNegatives are the colors of the negative balloons of each character, look-alikes of the favorites
+	CharacterNegatives map[string][]string `json:"character_negatives" yaml:"character_negatives"`
+	NegativePenalty    NegativePenalty     `json:"negative_penalty" yaml:"negative_penalty"`
+	BonusProbability   float64             `json:"bonus_probability" yaml:"bonus_probability"`
+	// BalloonSpawning tells the clients whether the server spawns the balloons, set when serving the config
+	BalloonSpawning string `json:"balloon_spawning,omitempty" yaml:"-"`
 }
 
 // GameState represents the current state of the game
 type GameState struct {
+	SessionID      string    `json:"session_id,omitempty"`
 	IsActive       bool      `json:"is_active"`
+	IsPaused       bool      `json:"is_paused"`
+	PausedSeconds  float64   `json:"paused_seconds,omitempty"`
 	StartedAt      time.Time `json:"started_at"`
 	EndedAt        time.Time `json:"ended_at"`
 	CurrentPlayers []string  `json:"current_players"`
 	PlayerCount    int       `json:"player_count,omitempty"`
+	// DurationSeconds is the length of a timed game, 0 when the game runs until it is stopped
+	DurationSeconds  int `json:"duration_seconds,omitempty"`
+	RemainingSeconds int `json:"remaining_seconds,omitempty"`
+}
+
+// StartRequest is the optional body of the requests starting a game
+type StartRequest struct {
+	// DurationSeconds stops the game automatically after that many seconds when set
+	DurationSeconds int `json:"duration_seconds,omitempty"`
+}
+
+// TimeRemaining tells the players how long a timed game keeps running
+type TimeRemaining struct {
+	Type             string    `json:"type"`
+	SessionID        string    `json:"session_id"`
+	RemainingSeconds int       `json:"remaining_seconds"`
+	EndsAt           time.Time `json:"ends_at"`
+}
+
+// RoomRequest creates a room, the config overrides are merged over the default game config
+type RoomRequest struct {
+	Name               string              `json:"name,omitempty"`
+	JoinCode           string              `json:"join_code,omitempty"`
+	Colors             map[string]int      `json:"colors,omitempty"`
+	CharacterFavorites map[string][]string `json:"character_favorites,omitempty"`
+	CharacterNegatives map[string][]string `json:"character_negatives,omitempty"`
+	NegativePenalty    *NegativePenalty    `json:"negative_penalty,omitempty"`
+	BonusProbability   *float64            `json:"bonus_probability,omitempty"`
+}
+
+// RoomInfo describes a room, its game config and the state of its game
+type RoomInfo struct {
+	ID        string      `json:"id"`
+	JoinCode  string      `json:"join_code,omitempty"`
+	Name      string      `json:"name,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	Config    *GameConfig `json:"config,omitempty"`
+	State     GameState   `json:"state"`
 }
 
 // GameMessage represents the message send with each balloon pops
@@ -62,13 +146,114 @@ type GameMessage struct {
 	Player       string `json:"player"`
 	Character    string `json:"character"`
 	BalloonColor string `json:"balloon_color"`
-	NegativeHit  bool   `json:"negative_hit"`
+	// NegativeHit is ignored, the server tells the negative hits by the negative colors of the character
+	NegativeHit bool `json:"negative_hit"`
+	// Type and BalloonID pop a balloon spawned by the server, e.g. {"type": "pop", "balloon_id": "..."}
+	Type      string `json:"type,omitempty"`
+	BalloonID string `json:"balloon_id,omitempty"`
+}
+
+// Balloon kinds, a bonus balloon has a favorite color of the character of the player
+const (
+	BalloonRegular  = "regular"
+	BalloonBonus    = "bonus"
+	BalloonNegative = "negative"
+)
+
+// MinSpawnInterval is the shortest time between two balloons of a player, at the top level of the game
+const MinSpawnInterval = 300 * time.Millisecond
+
+// Balloon is a balloon spawned by the server for a player
+type Balloon struct {
+	ID    string `json:"id"`
+	Color string `json:"color"`
+	Kind  string `json:"kind"`
+	// Speed is how fast the balloon rises, in pixels per animation frame
+	Speed float64 `json:"speed"`
+	// X is the horizontal position the balloon rises from, between 0 and 1
+	X         float64   `json:"x"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// BalloonSpawned tells the player about a new balloon to pop
+type BalloonSpawned struct {
+	Type    string  `json:"type"`
+	Balloon Balloon `json:"balloon"`
 }
 
 // ScoreUpdate represents the score state
 type ScoreUpdate struct {
 	Type  string     `json:"type"`
 	Event *GameEvent `json:"event"`
+	// Penalty is the number of points a negative hit took off, Total the score of the player in the session
+	Penalty int `json:"penalty"`
+	Total   int `json:"total"`
+	// BonusHits, RegularHits and NegativeHits count the hits of the player in the session
+	BonusHits    int `json:"bonus_hits"`
+	RegularHits  int `json:"regular_hits"`
+	NegativeHits int `json:"negative_hits"`
+}
+
+// Reason codes of the rejected game messages
+const (
+	ReasonMalformedMessage = "malformed_message"
+	ReasonUnknownColor     = "unknown_color"
+	ReasonUnknownCharacter = "unknown_character"
+	ReasonInvalidPlayer    = "invalid_player"
+	ReasonUnknownBalloon   = "unknown_balloon"
+	ReasonExpiredBalloon   = "balloon_expired"
+	ReasonPoppedBalloon    = "balloon_already_popped"
+)
+
+// DeadLetter is a game message that was rejected instead of scored
+type DeadLetter struct {
+	Reason    string       `json:"reason"`
+	Detail    string       `json:"detail"`
+	Player    string       `json:"player"`
+	SessionID string       `json:"session_id,omitempty"`
+	RoomID    string       `json:"room_id,omitempty"`
+	Message   *GameMessage `json:"message,omitempty"`
+	// Raw holds the payload that could not be parsed as a GameMessage
+	Raw     string    `json:"raw,omitempty"`
+	EventTS time.Time `json:"event_ts"`
+}
+
+// Flag records a player suspected of cheating, the repeats of the same reason
+// during a session are counted on the same flag
+type Flag struct {
+	Player    string    `json:"player"`
+	RoomID    string    `json:"room_id,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	Reason    string    `json:"reason"`
+	Detail    string    `json:"detail"`
+	Action    string    `json:"action"`
+	Count     int       `json:"count"`
+	FirstAt   time.Time `json:"first_at"`
+	LastAt    time.Time `json:"last_at"`
+}
+
+// CodeGamePaused is the code of the error frames rejecting the pops sent while the game is paused
+const CodeGamePaused = "game_paused"
+
+// PauseFrame tells the players that the game was paused or resumed
+type PauseFrame struct {
+	Type          string    `json:"type"`
+	SessionID     string    `json:"session_id"`
+	At            time.Time `json:"at"`
+	PausedSeconds float64   `json:"paused_seconds,omitempty"`
+}
+
+// ConfigChanged tells the players of a room that its game config changed
+type ConfigChanged struct {
+	Type   string      `json:"type"`
+	Config *GameConfig `json:"config"`
+}
+
+// ErrorFrame tells the player why a message was rejected
+type ErrorFrame struct {
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
 
 // GameStatus represents the game status started, stopped and active
@@ -79,13 +264,44 @@ type GameStatus struct {
 
 // SessionStats provides the session stats
 type SessionStats struct {
+	SessionID       string    `json:"session_id,omitempty"`
 	StartedAt       time.Time `json:"started_at,omitempty"`
 	EndedAt         time.Time `json:"ended_at,omitempty"`
 	DurationSeconds float64   `json:"duration_seconds,omitempty"`
+	PausedSeconds   float64   `json:"paused_seconds,omitempty"`
 	TotalPlayers    int       `json:"total_players,omitempty"`
 	PlayerList      []string  `json:"player_list,omitempty"`
 }
 
+// SessionRecord is the history of a game session, kept after the session ends
+type SessionRecord struct {
+	SessionID string `json:"session_id"`
+	RoomID    string `json:"room_id,omitempty"`
+	Active    bool   `json:"active"`
+	// Aborted marks a session that was still active when the server stopped, e.g. in a crash
+	Aborted         bool          `json:"aborted,omitempty"`
+	StartedAt       time.Time     `json:"started_at"`
+	EndedAt         time.Time     `json:"ended_at,omitempty"`
+	DurationSeconds float64       `json:"duration_seconds,omitempty"`
+	PausedSeconds   float64       `json:"paused_seconds,omitempty"`
+	Participants    []string      `json:"participants"`
+	Standings       []PlayerScore `json:"standings,omitempty"`
+}
+
+// Schedule starts a timed game in a room on a cron schedule, or once at a time
+type Schedule struct {
+	ID string `json:"id"`
+	// Cron is a standard 5 field cron expression or a descriptor such as @hourly or @every 30m
+	Cron            string     `json:"cron,omitempty"`
+	At              *time.Time `json:"at,omitempty"`
+	DurationSeconds int        `json:"duration_seconds"`
+	RoomID          string     `json:"room_id,omitempty"`
+	NextRun         time.Time  `json:"next_run"`
+	CreatedAt       time.Time  `json:"created_at"`
+	// Disabled is why the schedule no longer runs, e.g. its room was lost on a restart
+	Disabled string `json:"disabled,omitempty"`
+}
+
 // UserCredentials defines the structure for storing credentials
 type UserCredentials struct {
 	Username string `json:"username"`
@@ -115,6 +331,7 @@ func NewGameConfig() *GameConfig {
 			"gold":   90,
 			"grey":   70,
 			"maroon": 80,
+			"black":  70, // the look-alike of grey, a favorite of Tom and Bugs_Bunny, scores the same
 		},
 		CharacterFavorites: map[string][]string{
 			"Jerry":        {"brown", "yellow"},
@@ -136,6 +353,27 @@ func NewGameConfig() *GameConfig {
 			"Road_Runner":  {"blue", "purple"},
 			"Tweety":       {"yellow", "orange"},
 		},
+		CharacterNegatives: map[string][]string{
+			"Jerry":        {"orange", "gold"},
+			"Tom":          {"black", "purple"},
+			"Mickey":       {"maroon", "grey"},
+			"Donald":       {"purple", "red"},
+			"Bugs_Bunny":   {"black", "brown"},
+			"Daffy_Duck":   {"grey", "brown"},
+			"SpongeBob":    {"gold", "orange"},
+			"Patrick":      {"red", "yellow"},
+			"Pikachu":      {"gold", "maroon"},
+			"Mario":        {"maroon", "purple"},
+			"Sonic":        {"purple", "yellow"},
+			"Woody":        {"orange", "gold"},
+			"Buzz":         {"yellow", "pink"},
+			"Scooby":       {"orange", "yellow"},
+			"Popeye":       {"purple", "maroon"},
+			"Pink_Panther": {"red", "blue"},
+			"Road_Runner":  {"grey", "pink"},
+			"Tweety":       {"gold", "brown"},
+		},
+		NegativePenalty:  NegativePenalty{Ratio: 0.5, Min: 10},
 		BonusProbability: bonusProb,
 	}
 }
@@ -151,6 +389,25 @@ func NewGameState() *GameState {
 	}
 }
 
+// NewLifecycleEvent creates a LifecycleEvent of eventType with the current timestamp
+func NewLifecycleEvent(eventType, sessionID string) *LifecycleEvent {
+	return &LifecycleEvent{
+		Type:      eventType,
+		SessionID: sessionID,
+		EventTS:   time.Now().UTC(),
+	}
+}
+
+// NewDeadLetter creates a DeadLetter of the player with the current timestamp
+func NewDeadLetter(reason, detail, player string) *DeadLetter {
+	return &DeadLetter{
+		Reason:  reason,
+		Detail:  detail,
+		Player:  player,
+		EventTS: time.Now().UTC(),
+	}
+}
+
 // NewGameEvent creates a new GameEvent with the current timestamp
 func NewGameEvent(player, balloonColor string, score int, favoriteColorBonus bool) *GameEvent {
 	return &GameEvent{
@@ -0,0 +1,429 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package outbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	segmentSuffix = ".log"
+	cursorFile    = "cursor.json"
+	// DefaultMaxSegmentBytes is the size after which a new segment file is started
+	DefaultMaxSegmentBytes int64 = 16 * 1024 * 1024
+)
+
+// ErrClosed is returned when the outbox is used after Close
+var ErrClosed = errors.New("outbox is closed")
+
+// Position is the read position in the outbox
+type Position struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+}
+
+// Stats reports the current state of the outbox
+type Stats struct {
+	Dir      string `json:"dir"`
+	Backlog  int    `json:"backlog"`
+	Segments int    `json:"segments"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// Outbox is a durable, append-only store of the game events that could not be
+// delivered. Events are stored as JSON lines in segment files under a directory
+// and read back in the order they were appended.
+type Outbox struct {
+	mu              sync.Mutex
+	dir             string
+	maxSegmentBytes int64
+	segments        []uint64
+	writer          *os.File
+	writerSize      int64
+	cursor          Position
+	backlog         int
+	closed          bool
+}
+
+// Open opens or creates the outbox in dir, restoring the backlog left by a previous run
+func Open(dir string) (*Outbox, error) {
+	dir = filepath.Clean(dir)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory %s: %w", dir, err)
+	}
+	o := &Outbox{
+		dir:             dir,
+		maxSegmentBytes: DefaultMaxSegmentBytes,
+	}
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// SetMaxSegmentBytes sets the size after which appends roll over to a new segment
+func (o *Outbox) SetMaxSegmentBytes(n int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if n > 0 {
+		o.maxSegmentBytes = n
+	}
+}
+
+// Dir returns the directory holding the outbox segments
+func (o *Outbox) Dir() string {
+	return o.dir
+}
+
+// Len returns the number of events waiting to be replayed
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.backlog
+}
+
+// Stats returns the backlog size and the disk usage of the outbox
+func (o *Outbox) Stats() Stats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var size int64
+	for _, seq := range o.segments {
+		if fi, err := os.Stat(o.segmentPath(seq)); err == nil {
+			size += fi.Size()
+		}
+	}
+	return Stats{
+		Dir:      o.dir,
+		Backlog:  o.backlog,
+		Segments: len(o.segments),
+		Bytes:    size,
+	}
+}
+
+// Append durably stores the events at the end of the outbox
+func (o *Outbox) Append(events ...*models.GameEvent) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return ErrClosed
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	if o.writer == nil || o.writerSize >= o.maxSegmentBytes {
+		if err := o.roll(); err != nil {
+			return err
+		}
+	}
+
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+	n, err := o.writer.WriteString(buf.String())
+	o.writerSize += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to append to outbox: %w", err)
+	}
+	if err := o.writer.Sync(); err != nil {
+		return fmt.Errorf("failed to sync outbox: %w", err)
+	}
+	o.backlog += len(events)
+	return nil
+}
+
+// Peek reads up to max events from the current read position without consuming them.
+// The returned Position has to be passed to Commit once the events are delivered.
+func (o *Outbox) Peek(max int) ([]*models.GameEvent, Position, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return nil, o.cursor, ErrClosed
+	}
+	events := make([]*models.GameEvent, 0, max)
+	pos := o.cursor
+	for _, seq := range o.segments {
+		if seq < pos.Segment {
+			continue
+		}
+		if seq > pos.Segment {
+			pos = Position{Segment: seq}
+		}
+		read, next, err := o.readSegment(pos, max-len(events))
+		if err != nil {
+			return nil, o.cursor, err
+		}
+		events = append(events, read...)
+		pos = next
+		if len(events) >= max {
+			break
+		}
+	}
+	return events, pos, nil
+}
+
+// Commit marks everything before pos as delivered, removing the fully consumed segments
+func (o *Outbox) Commit(pos Position, delivered int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return ErrClosed
+	}
+	remaining := make([]uint64, 0, len(o.segments))
+	for _, seq := range o.segments {
+		// keep the segment being read and the one being written
+		if seq >= pos.Segment || (o.writer != nil && seq == o.activeSegment()) {
+			remaining = append(remaining, seq)
+			continue
+		}
+		if err := os.Remove(o.segmentPath(seq)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove outbox segment: %w", err)
+		}
+	}
+	o.segments = remaining
+	o.cursor = pos
+	o.backlog -= delivered
+	if o.backlog < 0 {
+		o.backlog = 0
+	}
+	// once everything is delivered start over with a fresh segment
+	if o.backlog == 0 {
+		if err := o.reset(); err != nil {
+			return err
+		}
+	}
+	return o.saveCursor()
+}
+
+// Replay sends the stored events in order in batches of batchSize, stopping at the first error.
+// It returns the number of events that were delivered.
+func (o *Outbox) Replay(batchSize int, send func([]*models.GameEvent) error) (int, error) {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	total := 0
+	for {
+		events, pos, err := o.Peek(batchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(events) == 0 {
+			return total, nil
+		}
+		if err := send(events); err != nil {
+			return total, err
+		}
+		if err := o.Commit(pos, len(events)); err != nil {
+			return total, err
+		}
+		total += len(events)
+	}
+}
+
+// Close closes the active segment, the backlog stays on disk for the next Open
+func (o *Outbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+	if o.writer != nil {
+		err := o.writer.Close()
+		o.writer = nil
+		return err
+	}
+	return nil
+}
+
+// load restores the segments and the read cursor from the outbox directory
+func (o *Outbox) load() error {
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read outbox directory %s: %w", o.dir, err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		o.segments = append(o.segments, seq)
+	}
+	sort.Slice(o.segments, func(i, j int) bool { return o.segments[i] < o.segments[j] })
+
+	data, err := os.ReadFile(filepath.Join(o.dir, cursorFile))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &o.cursor); err != nil {
+			return fmt.Errorf("failed to parse outbox cursor: %w", err)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("failed to read outbox cursor: %w", err)
+	}
+	if len(o.segments) > 0 && o.cursor.Segment < o.segments[0] {
+		o.cursor = Position{Segment: o.segments[0]}
+	}
+
+	// count what is left to replay
+	pos := o.cursor
+	for _, seq := range o.segments {
+		if seq < pos.Segment {
+			continue
+		}
+		if seq > pos.Segment {
+			pos = Position{Segment: seq}
+		}
+		events, next, err := o.readSegment(pos, -1)
+		if err != nil {
+			return err
+		}
+		o.backlog += len(events)
+		pos = next
+	}
+	return nil
+}
+
+// readSegment reads up to max events (all when max < 0) from pos.Segment starting at pos.Offset.
+// A partially written trailing line, e.g. after a crash, is ignored.
+func (o *Outbox) readSegment(pos Position, max int) ([]*models.GameEvent, Position, error) {
+	f, err := os.Open(o.segmentPath(pos.Segment))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, pos, nil
+		}
+		return nil, pos, fmt.Errorf("failed to open outbox segment: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	if _, err := f.Seek(pos.Offset, io.SeekStart); err != nil {
+		return nil, pos, fmt.Errorf("failed to seek outbox segment: %w", err)
+	}
+	events := make([]*models.GameEvent, 0)
+	r := bufio.NewReader(f)
+	for max < 0 || len(events) < max {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, pos, fmt.Errorf("failed to read outbox segment: %w", err)
+		}
+		pos.Offset += int64(len(line))
+		var event models.GameEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			// skip the corrupt entry rather than blocking the whole outbox
+			continue
+		}
+		events = append(events, &event)
+	}
+	return events, pos, nil
+}
+
+// roll closes the active segment and starts a new one
+func (o *Outbox) roll() error {
+	if o.writer != nil {
+		if err := o.writer.Close(); err != nil {
+			return fmt.Errorf("failed to close outbox segment: %w", err)
+		}
+		o.writer = nil
+	}
+	// keep the numbering monotonic, a drained outbox restarts after the saved cursor
+	seq := max(o.cursor.Segment, 1)
+	if n := len(o.segments); n > 0 {
+		seq = max(seq, o.segments[n-1]+1)
+	}
+	f, err := os.OpenFile(o.segmentPath(seq), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create outbox segment: %w", err)
+	}
+	o.writer = f
+	o.writerSize = 0
+	o.segments = append(o.segments, seq)
+	if len(o.segments) == 1 {
+		o.cursor = Position{Segment: seq}
+	}
+	return nil
+}
+
+// reset removes all the segments once the backlog is fully delivered
+func (o *Outbox) reset() error {
+	if o.writer != nil {
+		if err := o.writer.Close(); err != nil {
+			return fmt.Errorf("failed to close outbox segment: %w", err)
+		}
+		o.writer = nil
+	}
+	var next uint64
+	for _, seq := range o.segments {
+		if err := os.Remove(o.segmentPath(seq)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove outbox segment: %w", err)
+		}
+		next = seq + 1
+	}
+	o.segments = o.segments[:0]
+	if next > 0 {
+		o.cursor = Position{Segment: next}
+	}
+	return nil
+}
+
+func (o *Outbox) activeSegment() uint64 {
+	if len(o.segments) == 0 {
+		return 0
+	}
+	return o.segments[len(o.segments)-1]
+}
+
+// saveCursor atomically persists the read position
+func (o *Outbox) saveCursor() error {
+	data, err := json.Marshal(o.cursor)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(o.dir, cursorFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write outbox cursor: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(o.dir, cursorFile))
+}
+
+func (o *Outbox) segmentPath(seq uint64) string {
+	return filepath.Join(o.dir, fmt.Sprintf("%020d%s", seq, segmentSuffix))
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func testEvents(n int) []*models.GameEvent {
+	events := make([]*models.GameEvent, n)
+	for i := range events {
+		events[i] = models.NewGameEvent(fmt.Sprintf("player-%d", i), "red", i, false)
+	}
+	return events
+}
+
+func TestAppendAndReplay(t *testing.T) {
+	o, err := Open(t.TempDir())
+	assert.NoError(t, err)
+	defer o.Close() //nolint:errcheck
+
+	assert.NoError(t, o.Append(testEvents(5)...))
+	assert.Equal(t, 5, o.Len())
+
+	var got []*models.GameEvent
+	n, err := o.Replay(2, func(events []*models.GameEvent) error {
+		got = append(got, events...)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, 0, o.Len())
+	for i, event := range got {
+		assert.Equal(t, i, event.Score, "events must be replayed in order")
+	}
+	assert.Equal(t, 0, o.Stats().Segments)
+}
+
+func TestReplayStopsOnError(t *testing.T) {
+	o, err := Open(t.TempDir())
+	assert.NoError(t, err)
+	defer o.Close() //nolint:errcheck
+
+	assert.NoError(t, o.Append(testEvents(4)...))
+
+	calls := 0
+	n, err := o.Replay(2, func(events []*models.GameEvent) error {
+		calls++
+		if calls == 2 {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, o.Len())
+
+	// the failed batch is replayed again
+	events, _, err := o.Peek(10)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, 2, events[0].Score)
+		assert.Equal(t, 3, events[1].Score)
+	}
+}
+
+func TestReopenRestoresBacklog(t *testing.T) {
+	dir := t.TempDir()
+	o, err := Open(dir)
+	assert.NoError(t, err)
+	o.SetMaxSegmentBytes(1)
+
+	assert.NoError(t, o.Append(testEvents(6)...))
+	for _, e := range testEvents(3) {
+		assert.NoError(t, o.Append(e))
+	}
+	assert.Equal(t, 4, o.Stats().Segments)
+
+	// deliver the first segment only
+	events, pos, err := o.Peek(6)
+	assert.NoError(t, err)
+	assert.Len(t, events, 6)
+	assert.NoError(t, o.Commit(pos, len(events)))
+	assert.NoError(t, o.Close())
+
+	o, err = Open(dir)
+	assert.NoError(t, err)
+	defer o.Close() //nolint:errcheck
+	assert.Equal(t, 3, o.Len())
+
+	events, _, err = o.Peek(10)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 3) {
+		for i, event := range events {
+			assert.Equal(t, i, event.Score)
+		}
+	}
+
+	// appends after reopening go after the restored backlog
+	assert.NoError(t, o.Append(testEvents(1)...))
+	events, _, err = o.Peek(10)
+	assert.NoError(t, err)
+	assert.Len(t, events, 4)
+}
+
+func TestReopenAfterDrain(t *testing.T) {
+	dir := t.TempDir()
+	o, err := Open(dir)
+	assert.NoError(t, err)
+
+	assert.NoError(t, o.Append(testEvents(2)...))
+	n, err := o.Replay(10, func([]*models.GameEvent) error { return nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// events appended after a full drain must survive a restart
+	assert.NoError(t, o.Append(testEvents(1)...))
+	assert.NoError(t, o.Close())
+
+	o, err = Open(dir)
+	assert.NoError(t, err)
+	defer o.Close() //nolint:errcheck
+	assert.Equal(t, 1, o.Len())
+	events, _, err := o.Peek(10)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// CloudEventsNone sends the serialized event as the record value without an envelope
+	CloudEventsNone = "none"
+	// CloudEventsBinary puts the CloudEvents attributes in the ce_ prefixed record headers
+	CloudEventsBinary = "binary"
+	// CloudEventsStructured wraps the event in a JSON CloudEvents envelope
+	CloudEventsStructured = "structured"
+
+	// CloudEventsSpecVersion is the CloudEvents specification version we produce
+	CloudEventsSpecVersion = "1.0"
+	// ScoreEventType is the CloudEvents type of the game score events
+	ScoreEventType = "com.github.kameshsampath.balloonpopper.score"
+	// LifecycleEventTypePrefix prefixes the lifecycle event type to form its CloudEvents type,
+	// e.g. com.github.kameshsampath.balloonpopper.game_started
+	LifecycleEventTypePrefix = "com.github.kameshsampath.balloonpopper."
+	// CloudEventsContentType is the content type of the structured mode records
+	CloudEventsContentType = "application/cloudevents+json"
+
+	headerContentType = "content-type"
+)
+
+// CloudEvent is the structured mode envelope of a CloudEvents 1.0 event
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+}
+
+// EventSource returns the CloudEvents source of the server instance
+func EventSource(instanceID string) string {
+	return "/balloon-popper/" + instanceID
+}
+
+// WithCloudEvents wraps the records in a CloudEvents envelope using mode,
+// source identifies the server instance producing the events
+func WithCloudEvents(mode, source string) Option {
+	return func(k *KafkaScoreProducer) {
+		k.ceMode = mode
+		k.ceSource = source
+	}
+}
+
+// ValidateCloudEventsMode checks mode is one of none, binary or structured
+func ValidateCloudEventsMode(mode string) error {
+	switch mode {
+	case "", CloudEventsNone, CloudEventsBinary, CloudEventsStructured:
+		return nil
+	default:
+		return fmt.Errorf("unknown cloudevents mode %q, must be one of none, binary or structured", mode)
+	}
+}
+
+// eventIDNamespace is the namespace of the name based UUIDs of the events
+var eventIDNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/kameshsampath/balloon-popper"))
+
+// eventID derives the CloudEvents ID from the event type, time and the fields that tell the event
+// apart, so a retried, spilled or replayed event keeps its ID and consumers can drop the duplicates
+func eventID(eventType string, eventTime time.Time, fields ...string) string {
+	name := append([]string{eventType, eventTime.UTC().Format(time.RFC3339Nano)}, fields...)
+	return uuid.NewSHA1(eventIDNamespace, []byte(strings.Join(name, "\x00"))).String()
+}
+
+// scoreEventID identifies the score event
+func scoreEventID(event *models.GameEvent) string {
+	return eventID(ScoreEventType, event.EventTS, event.SessionID, event.RoomID, event.Player,
+		event.BalloonColor, strconv.Itoa(event.Score), strconv.FormatBool(event.FavoriteColorBonus))
+}
+
+// applyCloudEvent sets the record headers and value according to the CloudEvents mode,
+// id identifies the event and contentType is the content type of the record value
+func (k *KafkaScoreProducer) applyCloudEvent(record *kgo.Record, id, eventType, subject string, eventTime time.Time, contentType string) error {
+	eventTime = eventTime.UTC()
+
+	switch k.ceMode {
+	case CloudEventsBinary:
+		record.Headers = append(record.Headers,
+			kgo.RecordHeader{Key: "ce_specversion", Value: []byte(CloudEventsSpecVersion)},
+			kgo.RecordHeader{Key: "ce_id", Value: []byte(id)},
+			kgo.RecordHeader{Key: "ce_type", Value: []byte(eventType)},
+			kgo.RecordHeader{Key: "ce_source", Value: []byte(k.ceSource)},
+			kgo.RecordHeader{Key: "ce_subject", Value: []byte(subject)},
+			kgo.RecordHeader{Key: "ce_time", Value: []byte(eventTime.Format(time.RFC3339Nano))},
+			kgo.RecordHeader{Key: headerContentType, Value: []byte(contentType)},
+		)
+	case CloudEventsStructured:
+		ce := CloudEvent{
+			SpecVersion:     CloudEventsSpecVersion,
+			ID:              id,
+			Source:          k.ceSource,
+			Type:            eventType,
+			Subject:         subject,
+			Time:            eventTime,
+			DataContentType: contentType,
+		}
+		// JSON data is embedded as is, binary encodings are base64 encoded
+		if json.Valid(record.Value) {
+			ce.Data = record.Value
+		} else {
+			ce.DataBase64 = record.Value
+		}
+		value, err := json.Marshal(ce)
+		if err != nil {
+			return fmt.Errorf("failed to marshal cloudevent: %w", err)
+		}
+		record.Value = value
+		record.Headers = append(record.Headers,
+			kgo.RecordHeader{Key: headerContentType, Value: []byte(CloudEventsContentType)})
+	}
+	return nil
+}
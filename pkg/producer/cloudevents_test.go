@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"testing"
+	"time"
+)
+
+func recordHeaders(r *kgo.Record) map[string]string {
+	headers := make(map[string]string, len(r.Headers))
+	for _, h := range r.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	return headers
+}
+
+func TestCloudEventsBinaryMode(t *testing.T) {
+	k := &KafkaScoreProducer{topic: "balloon-game", serializer: &serde.JSONSerializer{}}
+	WithCloudEvents(CloudEventsBinary, EventSource("server-1"))(k)
+
+	event := models.NewGameEvent("tester", "red", 100, false)
+	record, err := k.newRecord(context.Background(), event)
+	assert.NoError(t, err)
+
+	headers := recordHeaders(record)
+	assert.Equal(t, CloudEventsSpecVersion, headers["ce_specversion"])
+	assert.Equal(t, ScoreEventType, headers["ce_type"])
+	assert.Equal(t, "/balloon-popper/server-1", headers["ce_source"])
+	assert.Equal(t, "tester", headers["ce_subject"])
+	assert.Equal(t, "application/json", headers["content-type"])
+	_, err = uuid.Parse(headers["ce_id"])
+	assert.NoError(t, err)
+	ts, err := time.Parse(time.RFC3339Nano, headers["ce_time"])
+	assert.NoError(t, err)
+	assert.True(t, event.EventTS.Equal(ts))
+
+	// the value is the plain event
+	var got models.GameEvent
+	assert.NoError(t, json.Unmarshal(record.Value, &got))
+	assert.Equal(t, "tester", got.Player)
+
+	// a retried or replayed event keeps its ID, every other event gets its own
+	retried, err := k.newRecord(context.Background(), event)
+	assert.NoError(t, err)
+	assert.Equal(t, headers["ce_id"], recordHeaders(retried)["ce_id"])
+	var replayed models.GameEvent
+	assert.NoError(t, json.Unmarshal(record.Value, &replayed))
+	record, err = k.newRecord(context.Background(), &replayed)
+	assert.NoError(t, err)
+	assert.Equal(t, headers["ce_id"], recordHeaders(record)["ce_id"])
+	another, err := k.newRecord(context.Background(), models.NewGameEvent("tester", "red", 70, false))
+	assert.NoError(t, err)
+	assert.NotEqual(t, headers["ce_id"], recordHeaders(another)["ce_id"])
+}
+
+func TestCloudEventsStructuredMode(t *testing.T) {
+	k := &KafkaScoreProducer{topic: "balloon-game", serializer: &serde.JSONSerializer{}}
+	WithCloudEvents(CloudEventsStructured, EventSource("server-1"))(k)
+
+	record, err := k.newRecord(context.Background(), models.NewGameEvent("tester", "red", 100, false))
+	assert.NoError(t, err)
+	assert.Equal(t, CloudEventsContentType, recordHeaders(record)["content-type"])
+
+	var ce CloudEvent
+	assert.NoError(t, json.Unmarshal(record.Value, &ce))
+	assert.Equal(t, CloudEventsSpecVersion, ce.SpecVersion)
+	assert.Equal(t, ScoreEventType, ce.Type)
+	assert.Equal(t, "/balloon-popper/server-1", ce.Source)
+	assert.Equal(t, "application/json", ce.DataContentType)
+	assert.NotEmpty(t, ce.ID)
+
+	var got models.GameEvent
+	assert.NoError(t, json.Unmarshal(ce.Data, &got))
+	assert.Equal(t, 100, got.Score)
+}
+
+func TestCloudEventsDisabled(t *testing.T) {
+	k := &KafkaScoreProducer{topic: "balloon-game", serializer: &serde.JSONSerializer{}}
+
+	record, err := k.newRecord(context.Background(), models.NewGameEvent("tester", "red", 100, false))
+	assert.NoError(t, err)
+	assert.Empty(t, record.Headers)
+	assert.Equal(t, []byte("tester"), record.Key)
+}
@@ -0,0 +1,242 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// AcksAll waits for all the in-sync replicas to acknowledge the records
+	AcksAll = "all"
+	// AcksLeader waits for the partition leader only
+	AcksLeader = "leader"
+	// AcksNone does not wait for any acknowledgement
+	AcksNone = "none"
+
+	// PartitionerPlayerHash hashes the player key so all events of a player land on the same partition
+	PartitionerPlayerHash = "player-hash"
+	// PartitionerRoundRobin spreads the records evenly across the partitions
+	PartitionerRoundRobin = "round-robin"
+	// PartitionerSticky fills a batch for one partition before moving to the next, ignoring the key
+	PartitionerSticky = "sticky"
+
+	// DefaultKafkaBootstrapServers is the broker used when none is configured
+	DefaultKafkaBootstrapServers = "localhost:19094"
+)
+
+// ProducerConfig is the Kafka producer configuration, it can be loaded from a YAML or JSON file
+type ProducerConfig struct {
+	// Seeds are the bootstrap brokers
+	Seeds []string `json:"seeds" yaml:"seeds"`
+	// Acks is one of all, leader or none
+	Acks string `json:"acks" yaml:"acks"`
+	// Idempotent enables idempotent writes, it requires Acks to be all
+	Idempotent bool `json:"idempotent" yaml:"idempotent"`
+	// Compression is one of none, gzip, snappy, lz4 or zstd
+	Compression string `json:"compression" yaml:"compression"`
+	// BatchBytes is the maximum size of a record batch, zero uses the client default
+	BatchBytes int32 `json:"batch_bytes" yaml:"batch_bytes"`
+	// Linger is how long to wait for more records before sending a batch
+	Linger time.Duration `json:"linger" yaml:"linger"`
+	// Retries is how many times a record is retried, zero retries until the record times out
+	Retries int `json:"retries" yaml:"retries"`
+	// MaxInFlight is the number of produce requests in flight per broker, only applies when
+	// Idempotent is false, zero uses the client default
+	MaxInFlight int `json:"max_in_flight" yaml:"max_in_flight"`
+	// Partitioner is one of player-hash, round-robin or sticky
+	Partitioner string `json:"partitioner" yaml:"partitioner"`
+	// TLS configures the TLS connection to the brokers
+	TLS TLSConfig `json:"tls" yaml:"tls"`
+	// SASL configures the SASL authentication with the brokers
+	SASL SASLConfig `json:"sasl" yaml:"sasl"`
+}
+
+// DefaultProducerConfig returns the configuration used when nothing is set
+func DefaultProducerConfig() ProducerConfig {
+	return ProducerConfig{
+		Seeds:       []string{DefaultKafkaBootstrapServers},
+		Acks:        AcksAll,
+		Idempotent:  true,
+		Compression: "snappy",
+		Linger:      100 * time.Millisecond, // Wait up to 100ms to batch records
+		Partitioner: PartitionerPlayerHash,
+	}
+}
+
+// LoadProducerConfig reads the configuration from a YAML or JSON file, the settings
+// missing in the file keep their default values
+func LoadProducerConfig(file string) (ProducerConfig, error) {
+	config := DefaultProducerConfig()
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return config, fmt.Errorf("failed to read producer config %s: %w", file, err)
+	}
+	// YAML is a superset of JSON, so this handles both
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse producer config %s: %w", file, err)
+	}
+	return config, nil
+}
+
+// SplitSeeds splits a comma separated list of brokers
+func SplitSeeds(servers string) []string {
+	seeds := make([]string, 0)
+	for _, s := range strings.Split(servers, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			seeds = append(seeds, s)
+		}
+	}
+	return seeds
+}
+
+// Validate checks the configuration is consistent
+func (c *ProducerConfig) Validate() error {
+	var errs []error
+	if len(c.Seeds) == 0 {
+		errs = append(errs, errors.New("at least one seed broker is required"))
+	}
+	if _, err := c.acks(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Idempotent && c.Acks != AcksAll {
+		errs = append(errs, fmt.Errorf("idempotent writes require acks=all, got acks=%s", c.Acks))
+	}
+	if c.Idempotent && c.MaxInFlight > 1 {
+		errs = append(errs, errors.New("max in flight can only be set when idempotent writes are disabled"))
+	}
+	if _, err := c.compression(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := c.partitioner(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.BatchBytes < 0 {
+		errs = append(errs, fmt.Errorf("batch bytes must not be negative, got %d", c.BatchBytes))
+	}
+	if c.Linger < 0 {
+		errs = append(errs, fmt.Errorf("linger must not be negative, got %s", c.Linger))
+	}
+	if c.Retries < 0 {
+		errs = append(errs, fmt.Errorf("retries must not be negative, got %d", c.Retries))
+	}
+	if c.MaxInFlight < 0 {
+		errs = append(errs, fmt.Errorf("max in flight must not be negative, got %d", c.MaxInFlight))
+	}
+	if c.SASL.IsEnabled() {
+		if _, err := c.SASL.Build(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ConnectionOpts returns the client options to connect to the brokers, without the
+// producer settings, for the clients consuming the score topic
+func (c *ProducerConfig) ConnectionOpts() ([]kgo.Opt, error) {
+	if len(c.Seeds) == 0 {
+		return nil, errors.New("at least one seed broker is required")
+	}
+	opts, err := securityOpts(&c.TLS, &c.SASL)
+	if err != nil {
+		return nil, err
+	}
+	return append([]kgo.Opt{kgo.SeedBrokers(c.Seeds...)}, opts...), nil
+}
+
+// clientOpts converts the configuration to the kgo client options
+func (c *ProducerConfig) clientOpts() ([]kgo.Opt, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	acks, _ := c.acks()
+	codec, _ := c.compression()
+	partitioner, _ := c.partitioner()
+
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(c.Seeds...),
+		kgo.RequiredAcks(acks),
+		kgo.ProducerBatchCompression(codec),
+		kgo.ProducerLinger(c.Linger),
+		kgo.RecordPartitioner(partitioner),
+	}
+	if !c.Idempotent {
+		opts = append(opts, kgo.DisableIdempotentWrite())
+		if c.MaxInFlight > 0 {
+			opts = append(opts, kgo.MaxProduceRequestsInflightPerBroker(c.MaxInFlight))
+		}
+	}
+	if c.BatchBytes > 0 {
+		opts = append(opts, kgo.ProducerBatchMaxBytes(c.BatchBytes))
+	}
+	if c.Retries > 0 {
+		opts = append(opts, kgo.RecordRetries(c.Retries))
+	}
+	return opts, nil
+}
+
+func (c *ProducerConfig) acks() (kgo.Acks, error) {
+	switch c.Acks {
+	case AcksAll:
+		return kgo.AllISRAcks(), nil
+	case AcksLeader:
+		return kgo.LeaderAck(), nil
+	case AcksNone:
+		return kgo.NoAck(), nil
+	default:
+		return kgo.AllISRAcks(), fmt.Errorf("unknown acks %q, must be one of all, leader or none", c.Acks)
+	}
+}
+
+func (c *ProducerConfig) compression() (kgo.CompressionCodec, error) {
+	switch c.Compression {
+	case "none":
+		return kgo.NoCompression(), nil
+	case "gzip":
+		return kgo.GzipCompression(), nil
+	case "snappy":
+		return kgo.SnappyCompression(), nil
+	case "lz4":
+		return kgo.Lz4Compression(), nil
+	case "zstd":
+		return kgo.ZstdCompression(), nil
+	default:
+		return kgo.NoCompression(), fmt.Errorf("unknown compression %q, must be one of none, gzip, snappy, lz4 or zstd", c.Compression)
+	}
+}
+
+func (c *ProducerConfig) partitioner() (kgo.Partitioner, error) {
+	switch c.Partitioner {
+	case PartitionerPlayerHash:
+		// nil uses the Kafka compatible murmur2 hash of the key
+		return kgo.StickyKeyPartitioner(nil), nil
+	case PartitionerRoundRobin:
+		return kgo.RoundRobinPartitioner(), nil
+	case PartitionerSticky:
+		return kgo.StickyPartitioner(), nil
+	default:
+		return nil, fmt.Errorf("unknown partitioner %q, must be one of player-hash, round-robin or sticky", c.Partitioner)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDefaultProducerConfig(t *testing.T) {
+	config := DefaultProducerConfig()
+	assert.NoError(t, config.Validate())
+	opts, err := config.clientOpts()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, opts)
+}
+
+func TestSplitSeeds(t *testing.T) {
+	assert.Equal(t, []string{"a:9092", "b:9092"}, SplitSeeds("a:9092, b:9092,"))
+	assert.Empty(t, SplitSeeds(""))
+}
+
+func TestLoadProducerConfig(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "kafka.yaml")
+	assert.NoError(t, os.WriteFile(yamlFile, []byte(`
+seeds: [a:9092, b:9092]
+acks: leader
+idempotent: false
+compression: zstd
+linger: 250ms
+max_in_flight: 5
+partitioner: round-robin
+`), 0600))
+	config, err := LoadProducerConfig(yamlFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a:9092", "b:9092"}, config.Seeds)
+	assert.Equal(t, AcksLeader, config.Acks)
+	assert.False(t, config.Idempotent)
+	assert.Equal(t, "zstd", config.Compression)
+	assert.Equal(t, 250*time.Millisecond, config.Linger)
+	assert.Equal(t, 5, config.MaxInFlight)
+	assert.Equal(t, PartitionerRoundRobin, config.Partitioner)
+	assert.NoError(t, config.Validate())
+
+	jsonFile := filepath.Join(dir, "kafka.json")
+	assert.NoError(t, os.WriteFile(jsonFile, []byte(`{"compression": "lz4", "partitioner": "sticky"}`), 0600))
+	config, err = LoadProducerConfig(jsonFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "lz4", config.Compression)
+	assert.Equal(t, PartitionerSticky, config.Partitioner)
+	// settings missing in the file keep their defaults
+	assert.Equal(t, AcksAll, config.Acks)
+	assert.True(t, config.Idempotent)
+
+	_, err = LoadProducerConfig(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestProducerConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *ProducerConfig)
+		errMsg string
+	}{
+		{"no seeds", func(c *ProducerConfig) { c.Seeds = nil }, "seed broker"},
+		{"unknown acks", func(c *ProducerConfig) { c.Acks = "some" }, "acks"},
+		{"idempotent without acks all", func(c *ProducerConfig) { c.Acks = AcksLeader }, "require acks=all"},
+		{"idempotent with max in flight", func(c *ProducerConfig) { c.MaxInFlight = 5 }, "max in flight"},
+		{"unknown compression", func(c *ProducerConfig) { c.Compression = "brotli" }, "compression"},
+		{"unknown partitioner", func(c *ProducerConfig) { c.Partitioner = "random" }, "partitioner"},
+		{"negative linger", func(c *ProducerConfig) { c.Linger = -time.Second }, "linger"},
+		{"negative retries", func(c *ProducerConfig) { c.Retries = -1 }, "retries"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultProducerConfig()
+			tt.modify(&config)
+			err := config.Validate()
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
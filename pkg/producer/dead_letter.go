@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// DeadLetterEventType is the CloudEvents type of the rejected game messages
+const DeadLetterEventType = "com.github.kameshsampath.balloonpopper.dead_letter"
+
+// headerDeadLetterReason carries the reason code so consumers can filter without decoding
+const headerDeadLetterReason = "dead-letter-reason"
+
+// WithDeadLetterTopic sends the rejected game messages to topic as JSON, keyed by player
+func WithDeadLetterTopic(topic string) Option {
+	return func(k *KafkaScoreProducer) {
+		k.deadLetterTopic = topic
+	}
+}
+
+// SendDeadLetter produces the rejected message without waiting for the broker acknowledgement,
+// failures are logged
+func (k *KafkaScoreProducer) SendDeadLetter(ctx context.Context, letter *models.DeadLetter) error {
+	if k.client == nil {
+		return fmt.Errorf("kafka client not initialized")
+	}
+	if k.deadLetterTopic == "" {
+		return fmt.Errorf("no dead letter topic configured")
+	}
+
+	value, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("failed to serialize dead letter: %w", err)
+	}
+	record := &kgo.Record{
+		Topic:   k.deadLetterTopic,
+		Key:     []byte(letter.Player),
+		Value:   value,
+		Headers: []kgo.RecordHeader{{Key: headerDeadLetterReason, Value: []byte(letter.Reason)}},
+	}
+	if err := k.applyCloudEvent(record,
+		eventID(DeadLetterEventType, letter.EventTS, letter.SessionID, letter.RoomID, letter.Player, letter.Reason, letter.Detail),
+		DeadLetterEventType, letter.Player, letter.EventTS, "application/json"); err != nil {
+		return err
+	}
+
+	// the record outlives the caller, so its cancellation must not fail the record
+	k.client.Produce(context.WithoutCancel(ctx), record, func(_ *kgo.Record, err error) {
+		if err != nil && k.log != nil {
+			k.log.Warnf("Failed to send %s dead letter of %s: %v", letter.Reason, letter.Player, err)
+		}
+	})
+	return nil
+}
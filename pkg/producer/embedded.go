@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package producer
+
+import (
+	"fmt"
+	"github.com/twmb/franz-go/pkg/kfake"
+)
+
+// EmbeddedCluster is an in-process, in-memory Kafka cluster for development and tests,
+// backed by kfake. It does not support transactions.
+type EmbeddedCluster struct {
+	cluster *kfake.Cluster
+}
+
+// StartEmbeddedCluster starts a single broker cluster listening on port, a random port
+// when 0, with the non-empty topics created with partitions partitions
+func StartEmbeddedCluster(port int, partitions int32, topics ...string) (*EmbeddedCluster, error) {
+	seeded := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		if topic != "" {
+			seeded = append(seeded, topic)
+		}
+	}
+	if partitions <= 0 {
+		partitions = 1
+	}
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.Ports(port),
+		kfake.SeedTopics(partitions, seeded...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the embedded kafka cluster: %w", err)
+	}
+	return &EmbeddedCluster{cluster: cluster}, nil
+}
+
+// Seeds returns the addresses of the cluster brokers
+func (c *EmbeddedCluster) Seeds() []string {
+	return c.cluster.ListenAddrs()
+}
+
+// Close shuts the cluster down, discarding all its records
+func (c *EmbeddedCluster) Close() {
+	c.cluster.Close()
+}
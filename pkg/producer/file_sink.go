@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileScoreSink appends the game events as JSON lines to a file
+type FileScoreSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileScoreSink creates a sink that appends to the file at path
+func NewFileScoreSink(path string) (*FileScoreSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file sink requires a file path")
+	}
+	return &FileScoreSink{
+		path: filepath.Clean(path),
+	}, nil
+}
+
+// Start opens the file for appending, creating it if required
+func (f *FileScoreSink) Start() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0750); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", f.path, err)
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", f.path, err)
+	}
+	f.file = file
+	return nil
+}
+
+func (f *FileScoreSink) Stop() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *FileScoreSink) SendScore(ctx context.Context, event *models.GameEvent) error {
+	return f.SendScoreBatch(ctx, []*models.GameEvent{event})
+}
+
+// SendScoreBatch appends each event as a JSON line
+func (f *FileScoreSink) SendScoreBatch(_ context.Context, events []*models.GameEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return fmt.Errorf("file sink %s not started", f.path)
+	}
+	return writeJSONLines(f.file, events)
+}
+
+// SendLifecycle appends the lifecycle event as a JSON line
+func (f *FileScoreSink) SendLifecycle(_ context.Context, event *models.LifecycleEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return fmt.Errorf("file sink %s not started", f.path)
+	}
+	return writeJSONLines(f.file, []*models.LifecycleEvent{event})
+}
+
+// SendDeadLetter appends the rejected message as a JSON line
+func (f *FileScoreSink) SendDeadLetter(_ context.Context, letter *models.DeadLetter) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return fmt.Errorf("file sink %s not started", f.path)
+	}
+	return writeJSONLines(f.file, []*models.DeadLetter{letter})
+}
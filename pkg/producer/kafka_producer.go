@@ -22,23 +22,95 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/serde"
+	"go.uber.org/zap"
+	"sync"
 	"time"
 
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
 type KafkaScoreProducer struct {
-	client *kgo.Client
-	topic  string
+	client     *kgo.Client
+	topic      string
+	serializer serde.Serializer
+	ceMode     string
+	ceSource   string
+	tlsConfig  *TLSConfig
+	saslConfig *SASLConfig
+	// topicConfig creates the topics on Start when set
+	topicConfig *TopicConfig
+	// lifecycleTopic receives the lifecycle events, disabled when empty
+	lifecycleTopic string
+	// deadLetterTopic receives the rejected game messages, disabled when empty
+	deadLetterTopic string
+	// resultsTopic receives the standings of the finished sessions
+	resultsTopic    string
+	transactionalID string
+	txMu            sync.Mutex
+	log             *zap.SugaredLogger
 }
 
-func NewKafkaScoreProducer(bootstrapServers, topic string) (*KafkaScoreProducer, error) {
+// Option configures the KafkaScoreProducer
+type Option func(*KafkaScoreProducer)
+
+// WithSerializer sets how the events are encoded, defaults to JSON
+func WithSerializer(serializer serde.Serializer) Option {
+	return func(k *KafkaScoreProducer) {
+		k.serializer = serializer
+	}
+}
+
+// WithLifecycleTopic sends the lifecycle events to topic as JSON, keyed by session
+func WithLifecycleTopic(topic string) Option {
+	return func(k *KafkaScoreProducer) {
+		k.lifecycleTopic = topic
+	}
+}
+
+// WithLogger sets the logger used to report background failures
+func WithLogger(log *zap.SugaredLogger) Option {
+	return func(k *KafkaScoreProducer) {
+		k.log = log
+	}
+}
+
+// NewKafkaScoreProducer creates a producer for the comma separated bootstrapServers
+// using the DefaultProducerConfig
+func NewKafkaScoreProducer(bootstrapServers, topic string, options ...Option) (*KafkaScoreProducer, error) {
+	config := DefaultProducerConfig()
+	config.Seeds = SplitSeeds(bootstrapServers)
+	return NewKafkaScoreProducerWithConfig(config, topic, options...)
+}
+
+// NewKafkaScoreProducerWithConfig creates a producer using config, the TLS and SASL
+// options override the settings in config
+func NewKafkaScoreProducerWithConfig(config ProducerConfig, topic string, options ...Option) (*KafkaScoreProducer, error) {
+	k := &KafkaScoreProducer{
+		topic:      topic,
+		serializer: &serde.JSONSerializer{},
+		tlsConfig:  &config.TLS,
+		saslConfig: &config.SASL,
+	}
+	for _, option := range options {
+		option(k)
+	}
+
 	// Create Kafka client configuration
-	opts := []kgo.Opt{
-		kgo.SeedBrokers(bootstrapServers),
-		kgo.ProducerLinger(time.Millisecond * 100), // Wait up to 100ms to batch records
-		//this is fail if quorum has just one broker
-		//kgo.RequiredAcks(kgo.LeaderAck()),          // Wait for leader acknowledgment
+	opts, err := config.clientOpts()
+	if err != nil {
+		return nil, fmt.Errorf("invalid producer config: %w", err)
+	}
+	securityOpts, err := k.securityOpts()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, securityOpts...)
+	if k.transactionalID != "" {
+		if !config.Idempotent {
+			return nil, fmt.Errorf("invalid producer config: transactions require idempotent writes")
+		}
+		opts = append(opts, kgo.TransactionalID(k.transactionalID))
 	}
 
 	// Create the Kafka client
@@ -46,11 +118,9 @@ func NewKafkaScoreProducer(bootstrapServers, topic string) (*KafkaScoreProducer,
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kafka client: %w", err)
 	}
+	k.client = client
 
-	return &KafkaScoreProducer{
-		client: client,
-		topic:  topic,
-	}, nil
+	return k, nil
 }
 
 func (k *KafkaScoreProducer) Start() error {
@@ -58,6 +128,14 @@ func (k *KafkaScoreProducer) Start() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if err := k.client.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to ping kafka brokers: %w", err)
+	}
+	return k.ensureTopics(ctx)
+}
+
+// Ready pings the brokers, unlike Start it does not check the topics
+func (k *KafkaScoreProducer) Ready(ctx context.Context) error {
 	if err := k.client.Ping(ctx); err != nil {
 		return fmt.Errorf("failed to ping kafka brokers: %w", err)
 	}
@@ -66,6 +144,13 @@ func (k *KafkaScoreProducer) Start() error {
 
 func (k *KafkaScoreProducer) Stop() error {
 	if k.client != nil {
+		// Deliver the buffered records before closing
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := k.client.Flush(ctx); err != nil {
+			k.client.Close()
+			return fmt.Errorf("failed to flush kafka producer: %w", err)
+		}
 		k.client.Close()
 	}
 	return nil
@@ -76,17 +161,9 @@ func (k *KafkaScoreProducer) SendScore(ctx context.Context, event *models.GameEv
 		return fmt.Errorf("kafka client not initialized")
 	}
 
-	// Convert event to JSON
-	eventJSON, err := json.Marshal(event)
+	record, err := k.newRecord(ctx, event)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
-	}
-
-	// Create record
-	record := &kgo.Record{
-		Topic: k.topic,
-		Value: eventJSON,
-		Key:   []byte(event.Player), // Using player name as key for partitioning
+		return err
 	}
 
 	// Produce the record
@@ -106,16 +183,11 @@ func (k *KafkaScoreProducer) SendScoreBatch(ctx context.Context, events []*model
 
 	records := make([]*kgo.Record, len(events))
 	for i, event := range events {
-		eventJSON, err := json.Marshal(event)
+		record, err := k.newRecord(ctx, event)
 		if err != nil {
-			return fmt.Errorf("failed to marshal event: %w", err)
-		}
-
-		records[i] = &kgo.Record{
-			Topic: k.topic,
-			Value: eventJSON,
-			Key:   []byte(event.Player),
+			return err
 		}
+		records[i] = record
 	}
 
 	// Produce all records
@@ -128,3 +200,93 @@ func (k *KafkaScoreProducer) SendScoreBatch(ctx context.Context, events []*model
 
 	return nil
 }
+
+// SendScoreAsync produces the event without waiting for the broker acknowledgement,
+// done is called from the producer callback with the delivery result
+func (k *KafkaScoreProducer) SendScoreAsync(ctx context.Context, event *models.GameEvent, done func(error)) {
+	if k.client == nil {
+		done(fmt.Errorf("kafka client not initialized"))
+		return
+	}
+
+	record, err := k.newRecord(ctx, event)
+	if err != nil {
+		done(err)
+		return
+	}
+
+	// the record outlives the caller, so its cancellation must not fail the record
+	k.client.Produce(context.WithoutCancel(ctx), record, func(_ *kgo.Record, err error) {
+		if err != nil {
+			done(fmt.Errorf("failed to produce message: %w", err))
+			return
+		}
+		done(nil)
+	})
+}
+
+// SendLifecycle produces the lifecycle event without waiting for the broker acknowledgement,
+// so the game is never held up by the broker, delivery failures are logged. A transactional
+// producer only sends the game_stopped events with the session results.
+func (k *KafkaScoreProducer) SendLifecycle(ctx context.Context, event *models.LifecycleEvent) error {
+	if k.client == nil {
+		return fmt.Errorf("kafka client not initialized")
+	}
+	if k.lifecycleTopic == "" {
+		return fmt.Errorf("no lifecycle topic configured")
+	}
+
+	if k.transactionalID != "" {
+		return fmt.Errorf("a transactional producer only sends the lifecycle events with the session results")
+	}
+
+	record, err := k.lifecycleRecord(event)
+	if err != nil {
+		return err
+	}
+
+	// the record outlives the caller, so its cancellation must not fail the record
+	k.client.Produce(context.WithoutCancel(ctx), record, func(_ *kgo.Record, err error) {
+		if err != nil && k.log != nil {
+			k.log.Warnf("Failed to send %s lifecycle event of session %s: %v", event.Type, event.SessionID, err)
+		}
+	})
+	return nil
+}
+
+// lifecycleRecord encodes the lifecycle event as JSON keyed by the session
+func (k *KafkaScoreProducer) lifecycleRecord(event *models.LifecycleEvent) (*kgo.Record, error) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize lifecycle event: %w", err)
+	}
+	record := &kgo.Record{
+		Topic: k.lifecycleTopic,
+		Value: value,
+		Key:   []byte(event.SessionID), // Keeps the events of a session in order
+	}
+	if err := k.applyCloudEvent(record,
+		eventID(LifecycleEventTypePrefix+event.Type, event.EventTS, event.SessionID, event.RoomID, event.Player),
+		LifecycleEventTypePrefix+event.Type, event.SessionID, event.EventTS, "application/json"); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// newRecord serializes the event to a record keyed by the player
+func (k *KafkaScoreProducer) newRecord(ctx context.Context, event *models.GameEvent) (*kgo.Record, error) {
+	value, err := k.serializer.Serialize(ctx, k.topic, event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize event as %s: %w", k.serializer.Format(), err)
+	}
+
+	record := &kgo.Record{
+		Topic: k.topic,
+		Value: value,
+		Key:   []byte(event.Player), // Using player name as key for partitioning
+	}
+	if err := k.applyCloudEvent(record, scoreEventID(event), ScoreEventType, event.Player, event.EventTS, k.serializer.ContentType()); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"testing"
+	"time"
+)
+
+func TestSendLifecycle(t *testing.T) {
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "scores", "lifecycle"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	config := DefaultProducerConfig()
+	config.Seeds = c.ListenAddrs()
+
+	p, err := NewKafkaScoreProducerWithConfig(config, "scores",
+		WithLifecycleTopic("lifecycle"),
+		WithCloudEvents(CloudEventsBinary, EventSource("test")))
+	assert.NoError(t, err)
+	assert.NoError(t, p.Start())
+
+	started := models.NewLifecycleEvent(models.GameStarted, "s1")
+	started.SessionStats = &models.SessionStats{SessionID: "s1", StartedAt: started.EventTS}
+	joined := models.NewLifecycleEvent(models.PlayerJoined, "s1")
+	joined.Player = "tester"
+	for _, event := range []*models.LifecycleEvent{started, joined} {
+		assert.NoError(t, p.SendLifecycle(context.Background(), event))
+	}
+	assert.NoError(t, p.Stop())
+
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(c.ListenAddrs()...),
+		kgo.ConsumeTopics("lifecycle"),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	assert.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var records []*kgo.Record
+	for len(records) < 2 && ctx.Err() == nil {
+		records = append(records, client.PollFetches(ctx).Records()...)
+	}
+	if !assert.Len(t, records, 2) {
+		return
+	}
+	for i, want := range []string{models.GameStarted, models.PlayerJoined} {
+		assert.Equal(t, "s1", string(records[i].Key))
+		var got models.LifecycleEvent
+		assert.NoError(t, json.Unmarshal(records[i].Value, &got))
+		assert.Equal(t, want, got.Type)
+		headers := make(map[string]string)
+		for _, h := range records[i].Headers {
+			headers[h.Key] = string(h.Value)
+		}
+		assert.Equal(t, LifecycleEventTypePrefix+want, headers["ce_type"])
+		assert.Equal(t, "s1", headers["ce_subject"])
+	}
+}
+
+func TestSendDeadLetter(t *testing.T) {
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "scores", "dlq"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	config := DefaultProducerConfig()
+	config.Seeds = c.ListenAddrs()
+
+	p, err := NewKafkaScoreProducerWithConfig(config, "scores",
+		WithDeadLetterTopic("dlq"),
+		WithCloudEvents(CloudEventsBinary, EventSource("test")))
+	assert.NoError(t, err)
+	assert.NoError(t, p.Start())
+
+	letter := models.NewDeadLetter(models.ReasonUnknownColor, `unknown balloon color "teal"`, "tester")
+	letter.Message = &models.GameMessage{Player: "tester", Character: "Tom", BalloonColor: "teal"}
+	assert.NoError(t, p.SendDeadLetter(context.Background(), letter))
+	assert.NoError(t, p.Stop())
+
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(c.ListenAddrs()...),
+		kgo.ConsumeTopics("dlq"),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	assert.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var records []*kgo.Record
+	for len(records) < 1 && ctx.Err() == nil {
+		records = append(records, client.PollFetches(ctx).Records()...)
+	}
+	if !assert.Len(t, records, 1) {
+		return
+	}
+	assert.Equal(t, "tester", string(records[0].Key))
+	var got models.DeadLetter
+	assert.NoError(t, json.Unmarshal(records[0].Value, &got))
+	assert.Equal(t, letter.Message, got.Message)
+	headers := make(map[string]string)
+	for _, h := range records[0].Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	assert.Equal(t, models.ReasonUnknownColor, headers[headerDeadLetterReason])
+	assert.Equal(t, DeadLetterEventType, headers["ce_type"])
+}
+
+func TestReadyFollowsTheBrokers(t *testing.T) {
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "scores"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultProducerConfig()
+	config.Seeds = c.ListenAddrs()
+
+	p, err := NewKafkaScoreProducerWithConfig(config, "scores")
+	assert.NoError(t, err)
+	assert.NoError(t, p.Start())
+	ob, err := outbox.Open(t.TempDir())
+	assert.NoError(t, err)
+	// the outbox always starts, its readiness is the one of the brokers
+	sink := NewOutboxScoreSink(p, ob, time.Hour, nil)
+	assert.NoError(t, sink.Start())
+	defer sink.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, Ready(ctx, sink))
+
+	c.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.Error(t, Ready(ctx, sink))
+	assert.NoError(t, Ready(ctx, NewMemoryScoreSink()))
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"sync"
+)
+
+// MemoryScoreSink keeps the game events in memory
+type MemoryScoreSink struct {
+	mu        sync.RWMutex
+	events    []*models.GameEvent
+	lifecycle []*models.LifecycleEvent
+	standings []models.PlayerScore
+	dead      []*models.DeadLetter
+}
+
+// NewMemoryScoreSink creates an empty in-memory sink
+func NewMemoryScoreSink() *MemoryScoreSink {
+	return &MemoryScoreSink{
+		events:    make([]*models.GameEvent, 0),
+		lifecycle: make([]*models.LifecycleEvent, 0),
+		dead:      make([]*models.DeadLetter, 0),
+	}
+}
+
+func (m *MemoryScoreSink) Start() error {
+	return nil
+}
+
+func (m *MemoryScoreSink) Stop() error {
+	return nil
+}
+
+func (m *MemoryScoreSink) SendScore(ctx context.Context, event *models.GameEvent) error {
+	return m.SendScoreBatch(ctx, []*models.GameEvent{event})
+}
+
+func (m *MemoryScoreSink) SendScoreBatch(_ context.Context, events []*models.GameEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func (m *MemoryScoreSink) SendLifecycle(_ context.Context, event *models.LifecycleEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lifecycle = append(m.lifecycle, event)
+	return nil
+}
+
+// SendSessionResults keeps the standings and the game_stopped lifecycle event
+func (m *MemoryScoreSink) SendSessionResults(_ context.Context, stopped *models.LifecycleEvent, standings []models.PlayerScore) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, standing := range standings {
+		standing.SessionID = stopped.SessionID
+		m.standings = append(m.standings, standing)
+	}
+	m.lifecycle = append(m.lifecycle, stopped)
+	return nil
+}
+
+// SendDeadLetter keeps the rejected message
+func (m *MemoryScoreSink) SendDeadLetter(_ context.Context, letter *models.DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dead = append(m.dead, letter)
+	return nil
+}
+
+// Events returns a copy of the events received so far
+func (m *MemoryScoreSink) Events() []*models.GameEvent {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	events := make([]*models.GameEvent, len(m.events))
+	copy(events, m.events)
+	return events
+}
+
+// LifecycleEvents returns a copy of the lifecycle events received so far
+func (m *MemoryScoreSink) LifecycleEvents() []*models.LifecycleEvent {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	events := make([]*models.LifecycleEvent, len(m.lifecycle))
+	copy(events, m.lifecycle)
+	return events
+}
+
+// Standings returns a copy of the session standings received so far
+func (m *MemoryScoreSink) Standings() []models.PlayerScore {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	standings := make([]models.PlayerScore, len(m.standings))
+	copy(standings, m.standings)
+	return standings
+}
+
+// DeadLetters returns a copy of the rejected messages received so far
+func (m *MemoryScoreSink) DeadLetters() []*models.DeadLetter {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	letters := make([]*models.DeadLetter, len(m.dead))
+	copy(letters, m.dead)
+	return letters
+}
+
+// Reset discards all the events received so far
+func (m *MemoryScoreSink) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = m.events[:0]
+	m.lifecycle = m.lifecycle[:0]
+	m.standings = m.standings[:0]
+	m.dead = m.dead[:0]
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/outbox"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultOutboxRetryInterval is how often the outbox tries to replay the stored events
+	DefaultOutboxRetryInterval = 5 * time.Second
+	outboxReplayBatchSize      = 100
+)
+
+// OutboxScoreSink wraps a ScoreSink and stores the events that fail to be sent
+// in a durable outbox, replaying them in order once the sink is healthy again.
+type OutboxScoreSink struct {
+	sink          ScoreSink
+	outbox        *outbox.Outbox
+	retryInterval time.Duration
+	log           *zap.SugaredLogger
+	startOnce     sync.Once
+	stopOnce      sync.Once
+	done          chan struct{}
+	wg            sync.WaitGroup
+}
+
+// NewOutboxScoreSink creates a sink that falls back to the outbox when sink fails
+func NewOutboxScoreSink(sink ScoreSink, ob *outbox.Outbox, retryInterval time.Duration, log *zap.SugaredLogger) *OutboxScoreSink {
+	if retryInterval <= 0 {
+		retryInterval = DefaultOutboxRetryInterval
+	}
+	if log == nil {
+		log = zap.NewNop().Sugar()
+	}
+	return &OutboxScoreSink{
+		sink:          sink,
+		outbox:        ob,
+		retryInterval: retryInterval,
+		log:           log,
+		done:          make(chan struct{}),
+	}
+}
+
+// Start starts the wrapped sink and the replay loop. A failure to start the wrapped sink
+// is only logged, the events are kept in the outbox until it becomes available.
+func (o *OutboxScoreSink) Start() error {
+	err := o.sink.Start()
+	if err != nil {
+		o.log.Warnf("Score sink unavailable, storing events in outbox %s: %v", o.outbox.Dir(), err)
+	}
+	o.startOnce.Do(func() {
+		if n := o.outbox.Len(); n > 0 {
+			o.log.Infof("Outbox %s has %d events pending replay", o.outbox.Dir(), n)
+		}
+		o.wg.Add(1)
+		go o.replayLoop()
+	})
+	return nil
+}
+
+// Ready checks the wrapped sink, the events are only stored in the outbox while it is not ready
+func (o *OutboxScoreSink) Ready(ctx context.Context) error {
+	return Ready(ctx, o.sink)
+}
+
+func (o *OutboxScoreSink) Stop() error {
+	o.stopOnce.Do(func() {
+		close(o.done)
+	})
+	o.wg.Wait()
+	if err := o.sink.Stop(); err != nil {
+		return err
+	}
+	return o.outbox.Close()
+}
+
+func (o *OutboxScoreSink) SendScore(ctx context.Context, event *models.GameEvent) error {
+	return o.SendScoreBatch(ctx, []*models.GameEvent{event})
+}
+
+// SendScoreBatch sends the events to the wrapped sink, storing them in the outbox when
+// that fails. While the outbox has a backlog the events go straight to the outbox so
+// that they are replayed in the order they were received.
+func (o *OutboxScoreSink) SendScoreBatch(ctx context.Context, events []*models.GameEvent) error {
+	if o.outbox.Len() == 0 {
+		err := o.sink.SendScoreBatch(ctx, events)
+		if err == nil {
+			return nil
+		}
+		o.log.Warnf("Failed to send %d score(s), storing in outbox: %v", len(events), err)
+	}
+	if err := o.outbox.Append(events...); err != nil {
+		return fmt.Errorf("failed to store score(s) in outbox: %w", err)
+	}
+	return nil
+}
+
+// SendScoreAsync delivers the event through the wrapped sink without blocking when it
+// supports it, storing the event in the outbox when the delivery fails
+func (o *OutboxScoreSink) SendScoreAsync(ctx context.Context, event *models.GameEvent, done func(error)) {
+	async, ok := o.sink.(AsyncScoreSink)
+	if !ok || o.outbox.Len() > 0 {
+		done(o.SendScore(ctx, event))
+		return
+	}
+	async.SendScoreAsync(ctx, event, func(err error) {
+		if err == nil {
+			done(nil)
+			return
+		}
+		o.log.Warnf("Failed to send score, storing in outbox: %v", err)
+		if err := o.outbox.Append(event); err != nil {
+			done(fmt.Errorf("failed to store score in outbox: %w", err))
+			return
+		}
+		done(nil)
+	})
+}
+
+// Backlog returns the number of events waiting in the outbox
+func (o *OutboxScoreSink) Backlog() int {
+	return o.outbox.Len()
+}
+
+// Outbox returns the underlying outbox
+func (o *OutboxScoreSink) Outbox() *outbox.Outbox {
+	return o.outbox
+}
+
+func (o *OutboxScoreSink) replayLoop() {
+	defer o.wg.Done()
+	ticker := time.NewTicker(o.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.done:
+			return
+		case <-ticker.C:
+			o.replay()
+		}
+	}
+}
+
+// replay drains the outbox into the wrapped sink until it is empty or a send fails
+func (o *OutboxScoreSink) replay() {
+	if o.outbox.Len() == 0 {
+		return
+	}
+	n, err := o.outbox.Replay(outboxReplayBatchSize, func(events []*models.GameEvent) error {
+		ctx, cancel := context.WithTimeout(context.Background(), o.retryInterval)
+		defer cancel()
+		return o.sink.SendScoreBatch(ctx, events)
+	})
+	if n > 0 {
+		o.log.Infof("Replayed %d score(s) from outbox, %d remaining", n, o.outbox.Len())
+	}
+	if err != nil {
+		o.log.Debugf("Outbox replay stopped: %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/outbox"
+	"go.uber.org/zap"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// OverflowBlock makes the sender wait until there is room in the queue
+	OverflowBlock = "block"
+	// OverflowDropOldest discards the oldest queued event to make room for the new one
+	OverflowDropOldest = "drop-oldest"
+	// OverflowSpill stores the event in the outbox when the queue is full
+	OverflowSpill = "spill"
+
+	// DefaultPipelineQueueSize is the default number of events the pipeline can buffer
+	DefaultPipelineQueueSize = 10000
+	// DefaultPipelineWorkers is the default number of goroutines delivering the events
+	DefaultPipelineWorkers = 1
+	// DefaultPipelineSendTimeout bounds the delivery of a single event
+	DefaultPipelineSendTimeout = 10 * time.Second
+)
+
+// ErrPipelineStopped is returned when an event is sent after the pipeline is stopped
+var ErrPipelineStopped = errors.New("score pipeline is stopped")
+
+// AsyncScoreSink is implemented by the sinks that can deliver events without blocking,
+// done is called once the delivery succeeded or failed.
+type AsyncScoreSink interface {
+	SendScoreAsync(ctx context.Context, event *models.GameEvent, done func(error))
+}
+
+// PipelineConfig configures the AsyncScorePipeline
+type PipelineConfig struct {
+	// QueueSize is the number of events buffered before the Overflow policy applies
+	QueueSize int
+	// Workers is the number of goroutines taking events off the queue
+	Workers int
+	// Overflow is one of OverflowBlock, OverflowDropOldest or OverflowSpill
+	Overflow string
+	// SendTimeout bounds the delivery of a single event
+	SendTimeout time.Duration
+	// Spill is the outbox used by OverflowSpill
+	Spill *outbox.Outbox
+}
+
+// PipelineMetrics reports the delivery results of the AsyncScorePipeline
+type PipelineMetrics struct {
+	Overflow          string  `json:"overflow"`
+	QueueCapacity     int     `json:"queue_capacity"`
+	QueueDepth        int     `json:"queue_depth"`
+	InFlight          int64   `json:"in_flight"`
+	Enqueued          uint64  `json:"enqueued"`
+	Delivered         uint64  `json:"delivered"`
+	Failed            uint64  `json:"failed"`
+	Dropped           uint64  `json:"dropped"`
+	Spilled           uint64  `json:"spilled"`
+	AvgDeliveryMillis float64 `json:"avg_delivery_ms"`
+	LastError         string  `json:"last_error,omitempty"`
+}
+
+// Validate checks the pipeline configuration
+func (c *PipelineConfig) Validate() error {
+	switch c.Overflow {
+	case OverflowBlock, OverflowDropOldest:
+	case OverflowSpill:
+		if c.Spill == nil {
+			return fmt.Errorf("overflow policy %q requires an outbox", OverflowSpill)
+		}
+	default:
+		return fmt.Errorf("unknown overflow policy %q, must be one of block, drop-oldest or spill", c.Overflow)
+	}
+	if c.QueueSize <= 0 {
+		return fmt.Errorf("pipeline queue size must be greater than zero")
+	}
+	if c.Workers <= 0 {
+		return fmt.Errorf("pipeline workers must be greater than zero")
+	}
+	return nil
+}
+
+type queuedEvent struct {
+	event    *models.GameEvent
+	queuedAt time.Time
+}
+
+// AsyncScorePipeline decouples the senders from the delivery of the events. SendScore
+// only queues the event, worker goroutines deliver them to the wrapped sink using
+// SendScoreAsync when the sink supports it.
+type AsyncScorePipeline struct {
+	sink   ScoreSink
+	config PipelineConfig
+	log    *zap.SugaredLogger
+	queue  chan queuedEvent
+
+	mu      sync.RWMutex // guards stopped against sends on the closed queue
+	started bool
+	stopped bool
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	workers  sync.WaitGroup
+	inFlight sync.WaitGroup
+
+	enqueued       atomic.Uint64
+	delivered      atomic.Uint64
+	failed         atomic.Uint64
+	dropped        atomic.Uint64
+	spilled        atomic.Uint64
+	pending        atomic.Int64
+	deliveryMicros atomic.Uint64
+	lastErr        atomic.Value
+}
+
+// NewAsyncScorePipeline creates a pipeline delivering to sink
+func NewAsyncScorePipeline(sink ScoreSink, config PipelineConfig, log *zap.SugaredLogger) (*AsyncScorePipeline, error) {
+	if config.Overflow == "" {
+		config.Overflow = OverflowBlock
+	}
+	if config.SendTimeout <= 0 {
+		config.SendTimeout = DefaultPipelineSendTimeout
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if log == nil {
+		log = zap.NewNop().Sugar()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &AsyncScorePipeline{
+		sink:   sink,
+		config: config,
+		log:    log,
+		queue:  make(chan queuedEvent, config.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Start starts the wrapped sink and the workers
+func (p *AsyncScorePipeline) Start() error {
+	if err := p.sink.Start(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// run the workers only once
+	if p.stopped || p.started {
+		return nil
+	}
+	p.started = true
+	for i := 0; i < p.config.Workers; i++ {
+		p.workers.Add(1)
+		go p.work()
+	}
+	return nil
+}
+
+// Ready checks the wrapped sink, a stopped pipeline is not ready
+func (p *AsyncScorePipeline) Ready(ctx context.Context) error {
+	p.mu.RLock()
+	stopped := p.stopped
+	p.mu.RUnlock()
+	if stopped {
+		return ErrPipelineStopped
+	}
+	return Ready(ctx, p.sink)
+}
+
+// Stop stops accepting events, waits for the queued ones to be delivered and stops the wrapped sink
+func (p *AsyncScorePipeline) Stop() error {
+	p.mu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	p.workers.Wait()
+	p.inFlight.Wait()
+	p.cancel()
+	return p.sink.Stop()
+}
+
+// SendScore queues the event for delivery, applying the overflow policy when the queue is full
+func (p *AsyncScorePipeline) SendScore(ctx context.Context, event *models.GameEvent) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stopped {
+		return ErrPipelineStopped
+	}
+
+	qe := queuedEvent{event: event, queuedAt: time.Now()}
+	select {
+	case p.queue <- qe:
+		p.enqueued.Add(1)
+		return nil
+	default:
+	}
+
+	switch p.config.Overflow {
+	case OverflowDropOldest:
+		for {
+			select {
+			case p.queue <- qe:
+				p.enqueued.Add(1)
+				return nil
+			default:
+			}
+			select {
+			case <-p.queue:
+				p.dropped.Add(1)
+			default:
+			}
+		}
+	case OverflowSpill:
+		if err := p.config.Spill.Append(event); err != nil {
+			p.failed.Add(1)
+			return fmt.Errorf("failed to spill score to outbox: %w", err)
+		}
+		p.spilled.Add(1)
+		return nil
+	default:
+		select {
+		case p.queue <- qe:
+			p.enqueued.Add(1)
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// SendScoreBatch queues each of the events
+func (p *AsyncScorePipeline) SendScoreBatch(ctx context.Context, events []*models.GameEvent) error {
+	for _, event := range events {
+		if err := p.SendScore(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Metrics returns a snapshot of the pipeline delivery results
+func (p *AsyncScorePipeline) Metrics() PipelineMetrics {
+	m := PipelineMetrics{
+		Overflow:      p.config.Overflow,
+		QueueCapacity: cap(p.queue),
+		QueueDepth:    len(p.queue),
+		Enqueued:      p.enqueued.Load(),
+		Delivered:     p.delivered.Load(),
+		Failed:        p.failed.Load(),
+		Dropped:       p.dropped.Load(),
+		Spilled:       p.spilled.Load(),
+		InFlight:      p.pending.Load(),
+	}
+	if done := m.Delivered + m.Failed; done > 0 {
+		m.AvgDeliveryMillis = float64(p.deliveryMicros.Load()) / float64(done) / 1000
+	}
+	if err, ok := p.lastErr.Load().(string); ok {
+		m.LastError = err
+	}
+	return m
+}
+
+func (p *AsyncScorePipeline) work() {
+	defer p.workers.Done()
+	async, isAsync := p.sink.(AsyncScoreSink)
+
+	for qe := range p.queue {
+		p.inFlight.Add(1)
+		p.pending.Add(1)
+		if isAsync {
+			async.SendScoreAsync(p.ctx, qe.event, p.deliveryCallback(qe))
+			continue
+		}
+		ctx, cancel := context.WithTimeout(p.ctx, p.config.SendTimeout)
+		err := p.sink.SendScore(ctx, qe.event)
+		cancel()
+		p.deliveryCallback(qe)(err)
+	}
+}
+
+// deliveryCallback records the delivery result of the queued event
+func (p *AsyncScorePipeline) deliveryCallback(qe queuedEvent) func(error) {
+	return func(err error) {
+		defer p.inFlight.Done()
+		p.pending.Add(-1)
+		p.deliveryMicros.Add(uint64(time.Since(qe.queuedAt).Microseconds()))
+		if err != nil {
+			p.failed.Add(1)
+			p.lastErr.Store(err.Error())
+			p.log.Warnf("Failed to deliver score for %s: %v", qe.event.Player, err)
+			return
+		}
+		p.delivered.Add(1)
+	}
+}
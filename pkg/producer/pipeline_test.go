@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/outbox"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// blockingSink holds every delivery until release is closed
+type blockingSink struct {
+	*MemoryScoreSink
+	release chan struct{}
+}
+
+func (b *blockingSink) SendScore(ctx context.Context, event *models.GameEvent) error {
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return b.MemoryScoreSink.SendScore(ctx, event)
+}
+
+func TestPipelineDelivers(t *testing.T) {
+	sink := NewMemoryScoreSink()
+	p, err := NewAsyncScorePipeline(sink, PipelineConfig{QueueSize: 10, Workers: 2}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Start())
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, p.SendScore(context.Background(), models.NewGameEvent("tester", "red", i, false)))
+	}
+	assert.NoError(t, p.Stop())
+
+	assert.Len(t, sink.Events(), 5)
+	m := p.Metrics()
+	assert.Equal(t, uint64(5), m.Enqueued)
+	assert.Equal(t, uint64(5), m.Delivered)
+	assert.Zero(t, m.Failed)
+	assert.Zero(t, m.InFlight)
+	assert.ErrorIs(t, p.SendScore(context.Background(), models.NewGameEvent("tester", "red", 1, false)), ErrPipelineStopped)
+}
+
+func TestPipelineOverflow(t *testing.T) {
+	testCases := []struct {
+		name     string
+		overflow string
+		check    func(t *testing.T, m PipelineMetrics, ob *outbox.Outbox)
+	}{
+		{"DropOldest", OverflowDropOldest, func(t *testing.T, m PipelineMetrics, _ *outbox.Outbox) {
+			assert.Equal(t, uint64(3), m.Dropped)
+		}},
+		{"Spill", OverflowSpill, func(t *testing.T, m PipelineMetrics, ob *outbox.Outbox) {
+			assert.Equal(t, uint64(3), m.Spilled)
+			assert.Equal(t, 3, ob.Len())
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ob, err := outbox.Open(t.TempDir())
+			assert.NoError(t, err)
+			defer ob.Close() //nolint:errcheck
+
+			sink := &blockingSink{MemoryScoreSink: NewMemoryScoreSink(), release: make(chan struct{})}
+			p, err := NewAsyncScorePipeline(sink, PipelineConfig{
+				QueueSize: 2,
+				Workers:   1,
+				Overflow:  tc.overflow,
+				Spill:     ob,
+			}, nil)
+			assert.NoError(t, err)
+			assert.NoError(t, p.Start())
+
+			// the first event is picked by the worker and blocks it
+			assert.NoError(t, p.SendScore(context.Background(), models.NewGameEvent("tester", "red", 0, false)))
+			assert.Eventually(t, func() bool { return p.Metrics().InFlight == 1 }, time.Second, time.Millisecond)
+
+			// two fill the queue, three overflow
+			for i := 1; i <= 5; i++ {
+				ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+				assert.NoError(t, p.SendScore(ctx, models.NewGameEvent("tester", "red", i, false)))
+				cancel()
+			}
+			tc.check(t, p.Metrics(), ob)
+
+			close(sink.release)
+			assert.NoError(t, p.Stop())
+			assert.Len(t, sink.Events(), 3)
+		})
+	}
+}
+
+func TestPipelineBlockHonoursContext(t *testing.T) {
+	sink := &blockingSink{MemoryScoreSink: NewMemoryScoreSink(), release: make(chan struct{})}
+	p, err := NewAsyncScorePipeline(sink, PipelineConfig{QueueSize: 1, Workers: 1, Overflow: OverflowBlock}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Start())
+
+	assert.NoError(t, p.SendScore(context.Background(), models.NewGameEvent("tester", "red", 0, false)))
+	assert.Eventually(t, func() bool { return p.Metrics().InFlight == 1 }, time.Second, time.Millisecond)
+	assert.NoError(t, p.SendScore(context.Background(), models.NewGameEvent("tester", "red", 1, false)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.SendScore(ctx, models.NewGameEvent("tester", "red", 2, false)), context.DeadlineExceeded)
+
+	close(sink.release)
+	assert.NoError(t, p.Stop())
+	assert.Len(t, sink.Events(), 2)
+}
+
+func TestPipelineConfigValidate(t *testing.T) {
+	_, err := NewAsyncScorePipeline(NewMemoryScoreSink(), PipelineConfig{QueueSize: 1, Workers: 1, Overflow: OverflowSpill}, nil)
+	assert.Error(t, err, "spill without an outbox")
+	_, err = NewAsyncScorePipeline(NewMemoryScoreSink(), PipelineConfig{QueueSize: 1, Workers: 1, Overflow: "bogus"}, nil)
+	assert.Error(t, err)
+	_, err = NewAsyncScorePipeline(NewMemoryScoreSink(), PipelineConfig{Workers: 1}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/oauth"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// SASLPlain authenticates with username and password in clear text, use it with TLS
+	SASLPlain = "PLAIN"
+	// SASLScramSHA256 authenticates with SCRAM using SHA-256
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	// SASLScramSHA512 authenticates with SCRAM using SHA-512
+	SASLScramSHA512 = "SCRAM-SHA-512"
+	// SASLOAuthBearer authenticates with an OAuth bearer token
+	SASLOAuthBearer = "OAUTHBEARER"
+)
+
+// TLSConfig configures the TLS connection to the brokers, setting CertFile and KeyFile enables mTLS
+type TLSConfig struct {
+	Enabled            bool   `json:"enabled" yaml:"enabled"`
+	CAFile             string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`
+	CertFile           string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile            string `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+	ServerName         string `json:"server_name,omitempty" yaml:"server_name,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"`
+}
+
+// SASLConfig configures the SASL authentication with the brokers
+type SASLConfig struct {
+	Mechanism string `json:"mechanism,omitempty" yaml:"mechanism,omitempty"`
+	Username  string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password  string `json:"-" yaml:"-"`
+	Token     string `json:"-" yaml:"-"`
+}
+
+// WithTLS connects to the brokers using TLS
+func WithTLS(config TLSConfig) Option {
+	return func(k *KafkaScoreProducer) {
+		k.tlsConfig = &config
+	}
+}
+
+// WithSASL authenticates with the brokers using SASL
+func WithSASL(config SASLConfig) Option {
+	return func(k *KafkaScoreProducer) {
+		k.saslConfig = &config
+	}
+}
+
+// IsEnabled is true when TLS is enabled explicitly or any of the certificate files is set
+func (t *TLSConfig) IsEnabled() bool {
+	return t.Enabled || t.CAFile != "" || t.CertFile != "" || t.KeyFile != ""
+}
+
+// Build creates the tls.Config, the system roots are used when CAFile is not set
+func (t *TLSConfig) Build() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify, //nolint:gosec
+	}
+	if t.CAFile != "" {
+		ca, err := os.ReadFile(filepath.Clean(t.CAFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file %s: %w", t.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", t.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return nil, fmt.Errorf("both the client certificate and key files are required for mTLS")
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(filepath.Clean(t.CertFile), filepath.Clean(t.KeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// IsEnabled is true when a SASL mechanism is set
+func (s *SASLConfig) IsEnabled() bool {
+	return s.Mechanism != ""
+}
+
+// Build creates the SASL mechanism with the configured credentials
+func (s *SASLConfig) Build() (sasl.Mechanism, error) {
+	switch strings.ToUpper(s.Mechanism) {
+	case SASLPlain:
+		if s.Username == "" || s.Password == "" {
+			return nil, fmt.Errorf("SASL %s requires a username and password", SASLPlain)
+		}
+		return plain.Auth{User: s.Username, Pass: s.Password}.AsMechanism(), nil
+	case SASLScramSHA256, SASLScramSHA512:
+		if s.Username == "" || s.Password == "" {
+			return nil, fmt.Errorf("SASL %s requires a username and password", s.Mechanism)
+		}
+		auth := scram.Auth{User: s.Username, Pass: s.Password}
+		if strings.ToUpper(s.Mechanism) == SASLScramSHA512 {
+			return auth.AsSha512Mechanism(), nil
+		}
+		return auth.AsSha256Mechanism(), nil
+	case SASLOAuthBearer:
+		if s.Token == "" {
+			return nil, fmt.Errorf("SASL %s requires a token", SASLOAuthBearer)
+		}
+		return oauth.Auth{Token: s.Token}.AsMechanism(), nil
+	default:
+		return nil, fmt.Errorf("unknown SASL mechanism %q, must be one of PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 or OAUTHBEARER", s.Mechanism)
+	}
+}
+
+// securityOpts returns the client options for the configured TLS and SASL settings
+func (k *KafkaScoreProducer) securityOpts() ([]kgo.Opt, error) {
+	return securityOpts(k.tlsConfig, k.saslConfig)
+}
+
+func securityOpts(tlsConfig *TLSConfig, saslConfig *SASLConfig) ([]kgo.Opt, error) {
+	var opts []kgo.Opt
+	if tlsConfig != nil && tlsConfig.IsEnabled() {
+		c, err := tlsConfig.Build()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, kgo.DialTLSConfig(c))
+	}
+	if saslConfig != nil && saslConfig.IsEnabled() {
+		mechanism, err := saslConfig.Build()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, kgo.SASL(mechanism))
+	}
+	return opts, nil
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self-signed certificate and its key as PEM files
+func writeTestCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "balloon-popper"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func TestTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+
+	assert.False(t, (&TLSConfig{}).IsEnabled())
+	assert.True(t, (&TLSConfig{CAFile: certFile}).IsEnabled())
+
+	config, err := (&TLSConfig{CAFile: certFile, CertFile: certFile, KeyFile: keyFile, ServerName: "broker"}).Build()
+	assert.NoError(t, err)
+	assert.NotNil(t, config.RootCAs)
+	assert.Len(t, config.Certificates, 1)
+	assert.Equal(t, "broker", config.ServerName)
+
+	_, err = (&TLSConfig{CertFile: certFile}).Build()
+	assert.Error(t, err, "mTLS requires the key file")
+	_, err = (&TLSConfig{CAFile: keyFile}).Build()
+	assert.Error(t, err, "key file is not a CA certificate")
+	_, err = (&TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}).Build()
+	assert.Error(t, err)
+}
+
+func TestSASLConfig(t *testing.T) {
+	testCases := []struct {
+		config  SASLConfig
+		name    string
+		wantErr bool
+	}{
+		{SASLConfig{Mechanism: SASLPlain, Username: "u", Password: "p"}, "PLAIN", false},
+		{SASLConfig{Mechanism: "scram-sha-256", Username: "u", Password: "p"}, "SCRAM-SHA-256", false},
+		{SASLConfig{Mechanism: SASLScramSHA512, Username: "u", Password: "p"}, "SCRAM-SHA-512", false},
+		{SASLConfig{Mechanism: SASLOAuthBearer, Token: "t"}, "OAUTHBEARER", false},
+		{SASLConfig{Mechanism: SASLScramSHA512, Username: "u"}, "", true},
+		{SASLConfig{Mechanism: SASLOAuthBearer}, "", true},
+		{SASLConfig{Mechanism: "GSSAPI"}, "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.config.Mechanism, func(t *testing.T) {
+			mechanism, err := tc.config.Build()
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.name, mechanism.Name())
+		})
+	}
+}
+
+func TestSecurityOpts(t *testing.T) {
+	certFile, _ := writeTestCertificate(t)
+	k := &KafkaScoreProducer{}
+	opts, err := k.securityOpts()
+	assert.NoError(t, err)
+	assert.Empty(t, opts)
+
+	WithTLS(TLSConfig{CAFile: certFile})(k)
+	WithSASL(SASLConfig{Mechanism: SASLScramSHA256, Username: "u", Password: "p"})(k)
+	opts, err = k.securityOpts()
+	assert.NoError(t, err)
+	assert.Len(t, opts, 2)
+
+	WithSASL(SASLConfig{Mechanism: SASLPlain})(k)
+	_, err = k.securityOpts()
+	assert.Error(t, err)
+}
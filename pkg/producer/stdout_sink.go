@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"io"
+	"os"
+	"sync"
+)
+
+// StdoutScoreSink writes the game events as JSON lines to a writer, defaults to os.Stdout
+type StdoutScoreSink struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewStdoutScoreSink creates a sink that writes to the given writer, os.Stdout when nil
+func NewStdoutScoreSink(out io.Writer) *StdoutScoreSink {
+	if out == nil {
+		out = os.Stdout
+	}
+	return &StdoutScoreSink{
+		out: out,
+	}
+}
+
+func (s *StdoutScoreSink) Start() error {
+	return nil
+}
+
+func (s *StdoutScoreSink) Stop() error {
+	return nil
+}
+
+func (s *StdoutScoreSink) SendScore(ctx context.Context, event *models.GameEvent) error {
+	return s.SendScoreBatch(ctx, []*models.GameEvent{event})
+}
+
+// SendScoreBatch writes each event as a JSON line
+func (s *StdoutScoreSink) SendScoreBatch(_ context.Context, events []*models.GameEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSONLines(s.out, events)
+}
+
+// SendLifecycle writes the lifecycle event as a JSON line
+func (s *StdoutScoreSink) SendLifecycle(_ context.Context, event *models.LifecycleEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSONLines(s.out, []*models.LifecycleEvent{event})
+}
+
+// SendDeadLetter writes the rejected message as a JSON line
+func (s *StdoutScoreSink) SendDeadLetter(_ context.Context, letter *models.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSONLines(s.out, []*models.DeadLetter{letter})
+}
+
+// writeJSONLines encodes each event as a single line of JSON
+func writeJSONLines[T any](w io.Writer, events []T) error {
+	enc := json.NewEncoder(w)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return fmt.Errorf("failed to write event: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// CleanupDelete deletes the records older than the retention
+	CleanupDelete = "delete"
+	// CleanupCompact keeps the latest record of every key
+	CleanupCompact = "compact"
+	// CleanupCompactDelete compacts and deletes the records older than the retention
+	CleanupCompactDelete = "compact,delete"
+)
+
+// TopicConfig is how a missing topic is created
+type TopicConfig struct {
+	// Partitions of the topic, -1 uses the broker default
+	Partitions int32 `json:"partitions" yaml:"partitions"`
+	// ReplicationFactor of the topic, -1 uses the broker default
+	ReplicationFactor int16 `json:"replication_factor" yaml:"replication_factor"`
+	// Retention of the records, 0 uses the broker default and negative keeps them forever
+	Retention time.Duration `json:"retention" yaml:"retention"`
+	// CleanupPolicy is one of delete, compact or compact,delete, empty uses the broker default
+	CleanupPolicy string `json:"cleanup_policy" yaml:"cleanup_policy"`
+}
+
+// DefaultTopicConfig returns the settings used to create the score topic
+func DefaultTopicConfig() TopicConfig {
+	return TopicConfig{
+		Partitions:        3,
+		ReplicationFactor: -1,
+		Retention:         7 * 24 * time.Hour,
+		CleanupPolicy:     CleanupDelete,
+	}
+}
+
+// Validate checks the topic settings
+func (t TopicConfig) Validate() error {
+	if t.Partitions == 0 || t.Partitions < -1 {
+		return fmt.Errorf("topic partitions must be positive or -1, got %d", t.Partitions)
+	}
+	if t.ReplicationFactor == 0 || t.ReplicationFactor < -1 {
+		return fmt.Errorf("topic replication factor must be positive or -1, got %d", t.ReplicationFactor)
+	}
+	switch t.CleanupPolicy {
+	case "", CleanupDelete, CleanupCompact, CleanupCompactDelete:
+	default:
+		return fmt.Errorf("unknown cleanup policy %q, must be one of delete, compact or compact,delete", t.CleanupPolicy)
+	}
+	return nil
+}
+
+// topicConfigs are the topic level configs to set, the broker defaults are left out
+func (t TopicConfig) topicConfigs() map[string]*string {
+	configs := make(map[string]*string)
+	if ms := t.retentionMs(); ms != "" {
+		configs["retention.ms"] = kadm.StringPtr(ms)
+	}
+	if t.CleanupPolicy != "" {
+		configs["cleanup.policy"] = kadm.StringPtr(t.CleanupPolicy)
+	}
+	return configs
+}
+
+func (t TopicConfig) retentionMs() string {
+	switch {
+	case t.Retention < 0:
+		return "-1"
+	case t.Retention > 0:
+		return strconv.FormatInt(t.Retention.Milliseconds(), 10)
+	}
+	return ""
+}
+
+// WithTopicProvisioning creates the score, lifecycle and results topics on Start when they are
+// missing and warns when the settings of an existing topic differ from config
+func WithTopicProvisioning(config TopicConfig) Option {
+	return func(k *KafkaScoreProducer) {
+		k.topicConfig = &config
+	}
+}
+
+// EnsureTopic creates topic when it does not exist, for an existing topic it returns
+// a description of every setting that differs from config
+func EnsureTopic(ctx context.Context, adm *kadm.Client, topic string, config TopicConfig) (bool, []string, error) {
+	topics, err := adm.ListTopics(ctx, topic)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to describe topic %s: %w", topic, err)
+	}
+	if !topics.Has(topic) {
+		if _, err := adm.CreateTopic(ctx, config.Partitions, config.ReplicationFactor, config.topicConfigs(), topic); err != nil {
+			return false, nil, fmt.Errorf("failed to create topic %s: %w", topic, err)
+		}
+		return true, nil, nil
+	}
+	detail := topics[topic]
+	if detail.Err != nil {
+		return false, nil, fmt.Errorf("failed to describe topic %s: %w", topic, detail.Err)
+	}
+
+	var drift []string
+	if config.Partitions > 0 && int32(len(detail.Partitions)) != config.Partitions {
+		drift = append(drift, fmt.Sprintf("partitions is %d, expected %d", len(detail.Partitions), config.Partitions))
+	}
+	if replicas := detail.Partitions.NumReplicas(); config.ReplicationFactor > 0 && replicas != int(config.ReplicationFactor) {
+		drift = append(drift, fmt.Sprintf("replication factor is %d, expected %d", replicas, config.ReplicationFactor))
+	}
+	expected := config.topicConfigs()
+	if len(expected) > 0 {
+		described, err := adm.DescribeTopicConfigs(ctx, topic)
+		if err != nil {
+			return false, nil, fmt.Errorf("failed to describe configs of topic %s: %w", topic, err)
+		}
+		rc, err := described.On(topic, nil)
+		if err == nil {
+			err = rc.Err
+		}
+		if err != nil {
+			return false, nil, fmt.Errorf("failed to describe configs of topic %s: %w", topic, err)
+		}
+		actual := make(map[string]string, len(rc.Configs))
+		for _, c := range rc.Configs {
+			if c.Value != nil {
+				actual[c.Key] = *c.Value
+			}
+		}
+		keys := make([]string, 0, len(expected))
+		for key := range expected {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if want := *expected[key]; !sameConfigValue(key, actual[key], want) {
+				drift = append(drift, fmt.Sprintf("%s is %q, expected %q", key, actual[key], want))
+			}
+		}
+	}
+	return false, drift, nil
+}
+
+// sameConfigValue compares config values, ignoring the order of the cleanup policies
+func sameConfigValue(key, actual, expected string) bool {
+	if key != "cleanup.policy" {
+		return actual == expected
+	}
+	split := func(v string) string {
+		parts := strings.Split(strings.ReplaceAll(v, " ", ""), ",")
+		sort.Strings(parts)
+		return strings.Join(parts, ",")
+	}
+	return split(actual) == split(expected)
+}
+
+// topics returns the score topic and the enabled lifecycle, results and dead letter topics
+func (k *KafkaScoreProducer) topics() []string {
+	topics := []string{k.topic}
+	if k.lifecycleTopic != "" {
+		topics = append(topics, k.lifecycleTopic)
+	}
+	if k.resultsTopic != "" {
+		topics = append(topics, k.resultsTopic)
+	}
+	if k.deadLetterTopic != "" {
+		topics = append(topics, k.deadLetterTopic)
+	}
+	return topics
+}
+
+// ensureTopics provisions the producer topics when topic provisioning is enabled, otherwise
+// it checks they exist, so a missing topic fails the start instead of every record sent to it
+func (k *KafkaScoreProducer) ensureTopics(ctx context.Context) error {
+	// the admin client shares the producer client, so it is not closed here
+	adm := kadm.NewClient(k.client)
+	topics := k.topics()
+	if k.topicConfig == nil {
+		listed, err := adm.ListTopics(ctx, topics...)
+		if err != nil {
+			return fmt.Errorf("failed to describe topics: %w", err)
+		}
+		var missing []string
+		for _, topic := range topics {
+			if !listed.Has(topic) {
+				missing = append(missing, topic)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("kafka topics %s do not exist, create them or enable the topic provisioning", strings.Join(missing, ", "))
+		}
+		return nil
+	}
+	for _, topic := range topics {
+		created, drift, err := EnsureTopic(ctx, adm, topic, *k.topicConfig)
+		if err != nil {
+			return err
+		}
+		if k.log == nil {
+			continue
+		}
+		if created {
+			k.log.Infof("Created topic %s", topic)
+		}
+		for _, d := range drift {
+			k.log.Warnf("Topic %s settings differ from the configured ones: %s", topic, d)
+		}
+	}
+	return nil
+}
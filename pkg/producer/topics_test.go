@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestCluster(t *testing.T) *kfake.Cluster {
+	c, err := kfake.NewCluster(kfake.NumBrokers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestTopicConfigValidate(t *testing.T) {
+	assert.NoError(t, DefaultTopicConfig().Validate())
+	assert.Error(t, TopicConfig{Partitions: 0, ReplicationFactor: 1}.Validate())
+	assert.Error(t, TopicConfig{Partitions: 1, ReplicationFactor: -2}.Validate())
+	assert.Error(t, TopicConfig{Partitions: 1, ReplicationFactor: 1, CleanupPolicy: "forever"}.Validate())
+}
+
+func TestStartCreatesTopic(t *testing.T) {
+	c := newTestCluster(t)
+	config := DefaultProducerConfig()
+	config.Seeds = c.ListenAddrs()
+	topicConfig := TopicConfig{Partitions: 2, ReplicationFactor: 1, Retention: time.Hour, CleanupPolicy: CleanupDelete}
+
+	p, err := NewKafkaScoreProducerWithConfig(config, "scores", WithTopicProvisioning(topicConfig))
+	assert.NoError(t, err)
+	assert.NoError(t, p.Start())
+	defer p.Stop()
+
+	client, err := kgo.NewClient(kgo.SeedBrokers(c.ListenAddrs()...))
+	assert.NoError(t, err)
+	defer client.Close()
+	adm := kadm.NewClient(client)
+	ctx := context.Background()
+
+	topics, err := adm.ListTopics(ctx, "scores")
+	assert.NoError(t, err)
+	assert.True(t, topics.Has("scores"))
+	assert.Len(t, topics["scores"].Partitions, 2)
+
+	// an existing topic is left alone and reports no drift
+	created, drift, err := EnsureTopic(ctx, adm, "scores", topicConfig)
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Empty(t, drift)
+
+	// settings differing from the existing topic are reported
+	created, drift, err = EnsureTopic(ctx, adm, "scores", TopicConfig{
+		Partitions:        4,
+		ReplicationFactor: 1,
+		Retention:         2 * time.Hour,
+		CleanupPolicy:     CleanupCompact,
+	})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	if assert.Len(t, drift, 3) {
+		assert.True(t, strings.HasPrefix(drift[0], "partitions is 2"))
+		assert.True(t, strings.HasPrefix(drift[1], "cleanup.policy"))
+		assert.True(t, strings.HasPrefix(drift[2], "retention.ms"))
+	}
+}
+
+func TestStartChecksTopics(t *testing.T) {
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "scores", "lifecycle"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	config := DefaultProducerConfig()
+	config.Seeds = c.ListenAddrs()
+
+	// the enabled topics must exist unless they are provisioned
+	p, err := NewKafkaScoreProducerWithConfig(config, "scores", WithLifecycleTopic("lifecycle"), WithDeadLetterTopic("dlq"))
+	assert.NoError(t, err)
+	err = p.Start()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "kafka topics dlq do not exist")
+	}
+	assert.NoError(t, p.Stop())
+
+	p, err = NewKafkaScoreProducerWithConfig(config, "scores", WithLifecycleTopic("lifecycle"))
+	assert.NoError(t, err)
+	assert.NoError(t, p.Start())
+	assert.NoError(t, p.Stop())
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"time"
+)
+
+const (
+	// SessionResultEventType is the CloudEvents type of the final player standings of a session
+	SessionResultEventType = "com.github.kameshsampath.balloonpopper.session_result"
+	// endTransactionTimeout bounds the commit or the abort of a transaction
+	endTransactionTimeout = 10 * time.Second
+)
+
+// TransactionalID returns the stable transactional ID of a server instance, so a
+// restarted instance fences off the transactions left open by its previous run
+func TransactionalID(instanceID string) string {
+	return "balloon-popper-" + instanceID
+}
+
+// WithTransactionalID makes the producer transactional. Kafka only accepts records of a
+// transactional producer inside a transaction, so such a producer is only used to send
+// the session results.
+func WithTransactionalID(id string) Option {
+	return func(k *KafkaScoreProducer) {
+		k.transactionalID = id
+	}
+}
+
+// WithResultsTopic sends the final standings of the sessions to topic, keyed by session
+func WithResultsTopic(topic string) Option {
+	return func(k *KafkaScoreProducer) {
+		k.resultsTopic = topic
+	}
+}
+
+// SendSessionResults writes the standings to the results topic and the game_stopped event
+// to the lifecycle topic in a single transaction, consumers reading committed records see
+// either all of them or none
+func (k *KafkaScoreProducer) SendSessionResults(ctx context.Context, stopped *models.LifecycleEvent, standings []models.PlayerScore) error {
+	if k.client == nil {
+		return fmt.Errorf("kafka client not initialized")
+	}
+	if k.transactionalID == "" {
+		return fmt.Errorf("session results require a transactional producer")
+	}
+	if k.resultsTopic == "" {
+		return fmt.Errorf("no results topic configured")
+	}
+
+	records := make([]*kgo.Record, 0, len(standings)+1)
+	for _, standing := range standings {
+		record, err := k.resultRecord(stopped, standing)
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	if k.lifecycleTopic != "" {
+		record, err := k.lifecycleRecord(stopped)
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	return k.transact(ctx, records...)
+}
+
+// transact produces the records in a single transaction, aborting it when a record fails
+func (k *KafkaScoreProducer) transact(ctx context.Context, records ...*kgo.Record) error {
+	// a client runs one transaction at a time
+	k.txMu.Lock()
+	defer k.txMu.Unlock()
+
+	if err := k.client.BeginTransaction(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	produceErr := k.client.ProduceSync(ctx, records...).FirstErr()
+	// the end of a transaction gets its own deadline, as the records may have used up
+	// the one of ctx and canceling the end early leaves the outcome unknown
+	endCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), endTransactionTimeout)
+	defer cancel()
+	if produceErr == nil {
+		err := k.client.EndTransaction(endCtx, kgo.TryCommit)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, kerr.OperationNotAttempted) {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		produceErr = err
+	} else if err := k.client.AbortBufferedRecords(endCtx); err != nil {
+		return errors.Join(produceErr, fmt.Errorf("failed to abort buffered records: %w", err))
+	}
+	if err := k.client.EndTransaction(endCtx, kgo.TryAbort); err != nil {
+		return errors.Join(produceErr, fmt.Errorf("failed to abort transaction: %w", err))
+	}
+	return fmt.Errorf("aborted transaction: %w", produceErr)
+}
+
+// resultRecord encodes the standing of a player as JSON keyed by the session
+func (k *KafkaScoreProducer) resultRecord(stopped *models.LifecycleEvent, standing models.PlayerScore) (*kgo.Record, error) {
+	standing.SessionID = stopped.SessionID
+	value, err := json.Marshal(standing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize standing of %s: %w", standing.Player, err)
+	}
+	record := &kgo.Record{
+		Topic: k.resultsTopic,
+		Value: value,
+		Key:   []byte(stopped.SessionID),
+	}
+	if err := k.applyCloudEvent(record,
+		eventID(SessionResultEventType, stopped.EventTS, stopped.SessionID, standing.Player),
+		SessionResultEventType, stopped.SessionID, stopped.EventTS, "application/json"); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
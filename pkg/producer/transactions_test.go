@@ -0,0 +1,250 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"hash/crc32"
+	"sync"
+	"testing"
+	"time"
+)
+
+// txnCoordinator adds the transaction coordinator requests kfake does not implement yet,
+// recording the transactional produce requests and how each transaction ended. kfake
+// still stores the produced records.
+type txnCoordinator struct {
+	mu       sync.Mutex
+	produced map[string]int
+	ended    []bool
+}
+
+func newTxnCoordinator(t *testing.T, c *kfake.Cluster) *txnCoordinator {
+	// the versions kfake supports, plus the transaction requests
+	client, err := kgo.NewClient(kgo.SeedBrokers(c.ListenAddrs()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions, err := kmsg.NewPtrApiVersionsRequest().RequestWith(context.Background(), client)
+	client.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKeys := append(versions.ApiKeys,
+		kmsg.ApiVersionsResponseApiKey{ApiKey: kmsg.AddPartitionsToTxn.Int16(), MaxVersion: 3},
+		kmsg.ApiVersionsResponseApiKey{ApiKey: kmsg.EndTxn.Int16(), MaxVersion: 3},
+	)
+
+	tc := &txnCoordinator{produced: make(map[string]int)}
+	c.ControlKey(kmsg.ApiVersions.Int16(), func(kreq kmsg.Request) (kmsg.Response, error, bool) {
+		c.KeepControl()
+		resp := kreq.ResponseKind().(*kmsg.ApiVersionsResponse)
+		resp.ApiKeys = apiKeys
+		return resp, nil, true
+	})
+	c.ControlKey(kmsg.InitProducerID.Int16(), func(kreq kmsg.Request) (kmsg.Response, error, bool) {
+		c.KeepControl()
+		// let kfake hand out an idempotent producer ID
+		kreq.(*kmsg.InitProducerIDRequest).TransactionalID = nil
+		return nil, nil, false
+	})
+	c.ControlKey(kmsg.AddPartitionsToTxn.Int16(), func(kreq kmsg.Request) (kmsg.Response, error, bool) {
+		c.KeepControl()
+		req := kreq.(*kmsg.AddPartitionsToTxnRequest)
+		resp := req.ResponseKind().(*kmsg.AddPartitionsToTxnResponse)
+		for _, rt := range req.Topics {
+			st := kmsg.NewAddPartitionsToTxnResponseTopic()
+			st.Topic = rt.Topic
+			for _, p := range rt.Partitions {
+				sp := kmsg.NewAddPartitionsToTxnResponseTopicPartition()
+				sp.Partition = p
+				st.Partitions = append(st.Partitions, sp)
+			}
+			resp.Topics = append(resp.Topics, st)
+		}
+		return resp, nil, true
+	})
+	c.ControlKey(kmsg.Produce.Int16(), func(kreq kmsg.Request) (kmsg.Response, error, bool) {
+		c.KeepControl()
+		req := kreq.(*kmsg.ProduceRequest)
+		if req.TransactionID == nil {
+			return nil, nil, false
+		}
+		// kfake rejects the transactional batches, clear their transactional attribute
+		for i, topic := range req.Topics {
+			for j, partition := range topic.Partitions {
+				var b kmsg.RecordBatch
+				if err := b.ReadFrom(partition.Records); err != nil {
+					return nil, err, true
+				}
+				if b.Attributes&0x10 != 0 {
+					tc.mu.Lock()
+					tc.produced[topic.Topic] += int(b.NumRecords)
+					tc.mu.Unlock()
+				}
+				b.Attributes &^= 0x10
+				raw := b.AppendTo(nil)
+				b.CRC = int32(crc32.Checksum(raw[21:], crc32.MakeTable(crc32.Castagnoli))) //nolint:gosec
+				req.Topics[i].Partitions[j].Records = b.AppendTo(nil)
+			}
+		}
+		req.TransactionID = nil
+		return nil, nil, false
+	})
+	c.ControlKey(kmsg.EndTxn.Int16(), func(kreq kmsg.Request) (kmsg.Response, error, bool) {
+		c.KeepControl()
+		req := kreq.(*kmsg.EndTxnRequest)
+		tc.mu.Lock()
+		tc.ended = append(tc.ended, req.Commit)
+		tc.mu.Unlock()
+		return req.ResponseKind(), nil, true
+	})
+	return tc
+}
+
+func (tc *txnCoordinator) transactions() (map[string]int, []bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	produced := make(map[string]int, len(tc.produced))
+	for k, v := range tc.produced {
+		produced[k] = v
+	}
+	return produced, append([]bool(nil), tc.ended...)
+}
+
+func newResultsProducer(t *testing.T, c *kfake.Cluster, resultsTopic string) *KafkaScoreProducer {
+	config := DefaultProducerConfig()
+	config.Seeds = c.ListenAddrs()
+	p, err := NewKafkaScoreProducerWithConfig(config, "scores",
+		WithTransactionalID(TransactionalID("test")),
+		WithResultsTopic(resultsTopic),
+		WithLifecycleTopic("lifecycle"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, p.Start())
+	t.Cleanup(func() { _ = p.Stop() })
+	return p
+}
+
+func stoppedEvent() (*models.LifecycleEvent, []models.PlayerScore) {
+	stopped := models.NewLifecycleEvent(models.GameStopped, "s1")
+	stopped.SessionStats = &models.SessionStats{SessionID: "s1", TotalPlayers: 2, PlayerList: []string{"tom", "jerry"}}
+	return stopped, []models.PlayerScore{
+		{Rank: 1, Player: "jerry", TotalScore: 300},
+		{Rank: 2, Player: "tom", TotalScore: 200},
+	}
+}
+
+func TestSendSessionResultsCommits(t *testing.T) {
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "scores", "lifecycle", "results"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	tc := newTxnCoordinator(t, c)
+	p := newResultsProducer(t, c, "results")
+
+	stopped, standings := stoppedEvent()
+	assert.NoError(t, p.SendSessionResults(context.Background(), stopped, standings))
+
+	produced, ended := tc.transactions()
+	assert.Equal(t, []bool{true}, ended, "expecting a single committed transaction")
+	assert.Equal(t, 2, produced["results"])
+	assert.Equal(t, 1, produced["lifecycle"])
+
+	// the committed records are read back with read committed isolation
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(c.ListenAddrs()...),
+		kgo.ConsumeTopics("results", "lifecycle"),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()))
+	assert.NoError(t, err)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	topics := make(map[string]int)
+	for n := 0; n < 3 && ctx.Err() == nil; {
+		client.PollFetches(ctx).EachRecord(func(r *kgo.Record) {
+			assert.Equal(t, "s1", string(r.Key))
+			topics[r.Topic]++
+			n++
+		})
+	}
+	assert.Equal(t, map[string]int{"results": 2, "lifecycle": 1}, topics)
+}
+
+func TestSendSessionResultsAborts(t *testing.T) {
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "scores", "lifecycle", "results"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	tc := newTxnCoordinator(t, c)
+	p := newResultsProducer(t, c, "results")
+	// the standings can never be written once the topic is deleted
+	adm := kadm.NewClient(p.client)
+	_, err = adm.DeleteTopics(context.Background(), "results")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stopped, standings := stoppedEvent()
+	assert.Error(t, p.SendSessionResults(ctx, stopped, standings))
+
+	_, ended := tc.transactions()
+	assert.Equal(t, []bool{false}, ended, "expecting the transaction to be aborted")
+}
+
+func TestSendSessionResultsRequiresTransactions(t *testing.T) {
+	k := &KafkaScoreProducer{client: &kgo.Client{}, resultsTopic: "results"}
+	stopped, standings := stoppedEvent()
+	assert.Error(t, k.SendSessionResults(context.Background(), stopped, standings))
+
+	config := DefaultProducerConfig()
+	config.Idempotent = false
+	_, err := NewKafkaScoreProducerWithConfig(config, "scores", WithTransactionalID("id"))
+	assert.Error(t, err)
+}
+
+func TestSendLifecycleOutsideTransactions(t *testing.T) {
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "scores", "lifecycle", "results"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	tc := newTxnCoordinator(t, c)
+	p := newResultsProducer(t, c, "results")
+
+	// the other lifecycle events of a session go through the score producer, never in a transaction
+	joined := models.NewLifecycleEvent(models.PlayerJoined, "s1")
+	joined.Player = "tom"
+	assert.Error(t, p.SendLifecycle(context.Background(), joined))
+	stopped, standings := stoppedEvent()
+	assert.NoError(t, p.SendSessionResults(context.Background(), stopped, standings))
+
+	produced, ended := tc.transactions()
+	assert.Equal(t, []bool{true}, ended)
+	assert.Equal(t, 1, produced["lifecycle"])
+}
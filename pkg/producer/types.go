@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package producer
+
+import (
+	"context"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+)
+
+const (
+	// SinkKafka sends the score events to a Kafka topic
+	SinkKafka = "kafka"
+	// SinkStdout writes the score events as JSON lines to standard output
+	SinkStdout = "stdout"
+	// SinkFile appends the score events as JSON lines to a file
+	SinkFile = "file"
+	// SinkMemory holds the score events in memory, useful for tests and demos
+	SinkMemory = "memory"
+)
+
+// ScoreSink is the destination for the game score events
+type ScoreSink interface {
+	// Start prepares the sink to receive events e.g. verifying the broker connectivity
+	Start() error
+	// Stop flushes and releases any resources held by the sink
+	Stop() error
+	// SendScore sends a single game event
+	SendScore(ctx context.Context, event *models.GameEvent) error
+	// SendScoreBatch sends multiple game events
+	SendScoreBatch(ctx context.Context, events []*models.GameEvent) error
+}
+
+// ReadySink is implemented by the sinks that can tell whether they deliver events,
+// a cheap check for the readiness probe that leaves the setup done by Start alone
+type ReadySink interface {
+	// Ready returns an error while the events cannot be delivered e.g. the brokers are unreachable
+	Ready(ctx context.Context) error
+}
+
+// Ready checks the sink when it implements ReadySink, the other sinks are always ready
+func Ready(ctx context.Context, sink ScoreSink) error {
+	if r, ok := sink.(ReadySink); ok {
+		return r.Ready(ctx)
+	}
+	return nil
+}
+
+// LifecycleSink is the destination for the game lifecycle events, implemented by
+// the score sinks so both streams go to the same place
+type LifecycleSink interface {
+	// SendLifecycle sends a lifecycle event
+	SendLifecycle(ctx context.Context, event *models.LifecycleEvent) error
+}
+
+// SessionResultsSink writes the results of a finished game session
+type SessionResultsSink interface {
+	// SendSessionResults writes the final standings and the game_stopped event together
+	SendSessionResults(ctx context.Context, stopped *models.LifecycleEvent, standings []models.PlayerScore) error
+}
+
+// DeadLetterSink is the destination for the game messages rejected by the validation
+type DeadLetterSink interface {
+	// SendDeadLetter sends a rejected message with the reason it was rejected
+	SendDeadLetter(ctx context.Context, letter *models.DeadLetter) error
+}
+
+var (
+	_ ScoreSink = (*KafkaScoreProducer)(nil)
+	_ ScoreSink = (*StdoutScoreSink)(nil)
+	_ ScoreSink = (*FileScoreSink)(nil)
+	_ ScoreSink = (*MemoryScoreSink)(nil)
+	_ ScoreSink = (*OutboxScoreSink)(nil)
+	_ ScoreSink = (*AsyncScorePipeline)(nil)
+
+	_ ReadySink = (*KafkaScoreProducer)(nil)
+	_ ReadySink = (*OutboxScoreSink)(nil)
+	_ ReadySink = (*AsyncScorePipeline)(nil)
+
+	_ AsyncScoreSink = (*KafkaScoreProducer)(nil)
+	_ AsyncScoreSink = (*OutboxScoreSink)(nil)
+
+	_ LifecycleSink = (*KafkaScoreProducer)(nil)
+	_ LifecycleSink = (*StdoutScoreSink)(nil)
+	_ LifecycleSink = (*FileScoreSink)(nil)
+	_ LifecycleSink = (*MemoryScoreSink)(nil)
+
+	_ SessionResultsSink = (*KafkaScoreProducer)(nil)
+	_ SessionResultsSink = (*MemoryScoreSink)(nil)
+
+	_ DeadLetterSink = (*KafkaScoreProducer)(nil)
+	_ DeadLetterSink = (*StdoutScoreSink)(nil)
+	_ DeadLetterSink = (*FileScoreSink)(nil)
+	_ DeadLetterSink = (*MemoryScoreSink)(nil)
+)
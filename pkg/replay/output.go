@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package replay
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats of a report
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+)
+
+// Views select the parts of a report that are written
+const (
+	ViewAll      = "all"
+	ViewScores   = "scores"
+	ViewTimeline = "timeline"
+)
+
+var (
+	scoresHeader   = []string{"session_id", "rank", "player", "total_score", "bonus_hits", "regular_hits", "negative_hits", "last_updated"}
+	timelineHeader = []string{"event_ts", "session_id", "player", "balloon_color", "score", "favorite_color_bonus", "running_total"}
+)
+
+// ValidateOutput checks the output format and the view, a CSV output holds a single view
+func ValidateOutput(output, view string) error {
+	switch view {
+	case ViewAll, ViewScores, ViewTimeline:
+	default:
+		return fmt.Errorf("unknown view %q, must be one of all, scores or timeline", view)
+	}
+	switch output {
+	case OutputTable, OutputJSON:
+	case OutputCSV:
+		if view == ViewAll {
+			return fmt.Errorf("the csv output needs a single view, scores or timeline")
+		}
+	default:
+		return fmt.Errorf("unknown output %q, must be one of table, json or csv", output)
+	}
+	return nil
+}
+
+// Write writes the view of report to w in the output format
+func Write(w io.Writer, report Report, output, view string) error {
+	if err := ValidateOutput(output, view); err != nil {
+		return err
+	}
+	if view == ViewScores {
+		report.Timeline = nil
+	}
+	if view == ViewTimeline {
+		report.Sessions = nil
+	}
+	switch output {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case OutputCSV:
+		return writeCSV(w, report)
+	default:
+		return writeTable(w, report)
+	}
+}
+
+// scoreRows flattens the player totals of the sessions
+func scoreRows(report Report) [][]string {
+	rows := make([][]string, 0)
+	for _, board := range report.Sessions {
+		for _, ps := range board.Players {
+			rows = append(rows, []string{
+				board.SessionID,
+				strconv.Itoa(ps.Rank),
+				ps.Player,
+				strconv.Itoa(ps.TotalScore),
+				strconv.Itoa(ps.BonusHits),
+				strconv.Itoa(ps.RegularHits),
+				strconv.Itoa(ps.NegativeHits),
+				ps.LastUpdated.Format(time.RFC3339),
+			})
+		}
+	}
+	return rows
+}
+
+// timelineRows flattens the timeline entries
+func timelineRows(report Report) [][]string {
+	rows := make([][]string, 0, len(report.Timeline))
+	for _, entry := range report.Timeline {
+		rows = append(rows, []string{
+			entry.EventTS.Format(time.RFC3339Nano),
+			entry.SessionID,
+			entry.Player,
+			entry.BalloonColor,
+			strconv.Itoa(entry.Score),
+			strconv.FormatBool(entry.FavoriteColorBonus),
+			strconv.Itoa(entry.RunningTotal),
+		})
+	}
+	return rows
+}
+
+func writeCSV(w io.Writer, report Report) error {
+	cw := csv.NewWriter(w)
+	header, rows := scoresHeader, scoreRows(report)
+	if report.Sessions == nil {
+		header, rows = timelineHeader, timelineRows(report)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	return nil
+}
+
+func writeTable(w io.Writer, report Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	write := func(header []string, rows [][]string) {
+		_, _ = fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+		for _, row := range rows {
+			_, _ = fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	}
+	if report.Sessions != nil {
+		write(scoresHeader, scoreRows(report))
+	}
+	if report.Sessions != nil && report.Timeline != nil {
+		_, _ = fmt.Fprintln(tw)
+	}
+	if report.Timeline != nil {
+		write(timelineHeader, timelineRows(report))
+	}
+	return tw.Flush()
+}
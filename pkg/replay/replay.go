@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package replay
+
+import (
+	"github.com/kameshsampath/balloon-popper/pkg/leaderboard"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"sort"
+)
+
+// TimelineEntry is a replayed score event with the running total of its player in the session
+type TimelineEntry struct {
+	models.GameEvent
+	RunningTotal int `json:"running_total"`
+}
+
+// Report holds the player totals of every replayed session and the timeline of the score events
+type Report struct {
+	Sessions []models.Leaderboard `json:"sessions,omitempty"`
+	Timeline []TimelineEntry      `json:"timeline,omitempty"`
+}
+
+// Replay rebuilds the scores of a game from its score events
+type Replay struct {
+	sessionID string
+	board     *leaderboard.Leaderboard
+	events    []models.GameEvent
+}
+
+// New creates a replay of the score events of sessionID, an empty sessionID keeps the events of all sessions
+func New(sessionID string) *Replay {
+	return &Replay{
+		sessionID: sessionID,
+		// a replay keeps the totals of every session it reads
+		board: leaderboard.NewUnbounded(),
+	}
+}
+
+// Record adds event to the replay, events of other sessions are ignored
+func (r *Replay) Record(event *models.GameEvent) {
+	if r.sessionID != "" && event.SessionID != r.sessionID {
+		return
+	}
+	r.board.Record(event)
+	r.events = append(r.events, *event)
+}
+
+// Report returns the ranked players of each session, in the order the sessions started,
+// and the score events ordered by their time
+func (r *Replay) Report() Report {
+	report := Report{
+		Sessions: make([]models.Leaderboard, 0),
+		Timeline: make([]TimelineEntry, 0, len(r.events)),
+	}
+	for _, id := range r.board.Sessions() {
+		report.Sessions = append(report.Sessions, r.board.Top(id, 0))
+	}
+
+	// the events of different partitions are not read in order
+	events := make([]models.GameEvent, len(r.events))
+	copy(events, r.events)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].EventTS.Before(events[j].EventTS)
+	})
+	totals := make(map[[2]string]int)
+	for _, event := range events {
+		key := [2]string{event.SessionID, event.Player}
+		totals[key] += event.Score
+		report.Timeline = append(report.Timeline, TimelineEntry{
+			GameEvent:    event,
+			RunningTotal: totals[key],
+		})
+	}
+	return report
+}
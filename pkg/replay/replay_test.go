@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func scoreEvent(session, player string, score int, bonus bool, offset time.Duration) *models.GameEvent {
+	return &models.GameEvent{
+		Player:             player,
+		BalloonColor:       "red",
+		Score:              score,
+		FavoriteColorBonus: bonus,
+		EventTS:            start.Add(offset),
+		SessionID:          session,
+	}
+}
+
+func testReplay(sessionID string) *Replay {
+	r := New(sessionID)
+	// read out of order, as from different partitions
+	r.Record(scoreEvent("s1", "jerry", 50, true, 2*time.Second))
+	r.Record(scoreEvent("s1", "tom", 10, false, time.Second))
+	r.Record(scoreEvent("s1", "tom", 30, true, 3*time.Second))
+	r.Record(scoreEvent("s2", "tom", 5, false, time.Hour))
+	return r
+}
+
+func TestReport(t *testing.T) {
+	report := testReplay("").Report()
+	if assert.Len(t, report.Sessions, 2) {
+		assert.Equal(t, "s1", report.Sessions[0].SessionID)
+		assert.Equal(t, []models.PlayerScore{
+			{Rank: 1, Player: "jerry", TotalScore: 50, BonusHits: 1, LastUpdated: start.Add(2 * time.Second)},
+			{Rank: 2, Player: "tom", TotalScore: 40, BonusHits: 1, RegularHits: 1, LastUpdated: start.Add(3 * time.Second)},
+		}, report.Sessions[0].Players)
+		assert.Equal(t, "s2", report.Sessions[1].SessionID)
+	}
+	if assert.Len(t, report.Timeline, 4) {
+		assert.Equal(t, "tom", report.Timeline[0].Player)
+		assert.Equal(t, 10, report.Timeline[0].RunningTotal)
+		assert.Equal(t, 40, report.Timeline[2].RunningTotal)
+		// the running total restarts with the session
+		assert.Equal(t, 5, report.Timeline[3].RunningTotal)
+	}
+
+	report = testReplay("s2").Report()
+	assert.Len(t, report.Sessions, 1)
+	assert.Len(t, report.Timeline, 1)
+}
+
+func TestWrite(t *testing.T) {
+	r := testReplay("s1")
+	// a negative hit, the total is reconciled from the hit counts
+	r.Record(scoreEvent("s1", "tom", -20, false, 4*time.Second))
+	report := r.Report()
+
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, report, OutputCSV, ViewScores))
+	assert.Equal(t, `session_id,rank,player,total_score,bonus_hits,regular_hits,negative_hits,last_updated
+s1,1,jerry,50,1,0,0,2025-03-01T10:00:02Z
+s1,2,tom,20,1,1,1,2025-03-01T10:00:04Z
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, Write(&buf, report, OutputCSV, ViewTimeline))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "2025-03-01T10:00:01Z,s1,tom,red,10,false,10", lines[1])
+
+	buf.Reset()
+	assert.NoError(t, Write(&buf, report, OutputJSON, ViewTimeline))
+	var decoded Report
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Nil(t, decoded.Sessions)
+	assert.Len(t, decoded.Timeline, 4)
+
+	buf.Reset()
+	assert.NoError(t, Write(&buf, report, OutputTable, ViewAll))
+	assert.Contains(t, buf.String(), "SESSION_ID  RANK  PLAYER")
+	assert.Contains(t, buf.String(), "NEGATIVE_HITS")
+	assert.Contains(t, buf.String(), "\n\nEVENT_TS")
+
+	assert.Error(t, Write(&buf, report, OutputCSV, ViewAll))
+	assert.Error(t, Write(&buf, report, "yaml", ViewAll))
+}
+
+func TestReportKeepsEverySession(t *testing.T) {
+	// more sessions than the live leaderboard keeps
+	r := New("")
+	for i := range 150 {
+		r.Record(scoreEvent(fmt.Sprintf("s%03d", i), "tom", 10, false, time.Duration(i)*time.Minute))
+	}
+	report := r.Report()
+	assert.Len(t, report.Timeline, 150)
+	if assert.Len(t, report.Sessions, 150) {
+		assert.Equal(t, "s000", report.Sessions[0].SessionID)
+		assert.Equal(t, 10, report.Sessions[0].Players[0].TotalScore)
+	}
+}
@@ -19,10 +19,12 @@ package routes
 
 import (
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/kameshsampath/balloon-popper/pkg/models"
 	"github.com/kameshsampath/balloon-popper/pkg/security"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -55,54 +57,157 @@ func (e *EndpointConfig) Login(c echo.Context) error {
 	})
 }
 
+// StartGame starts a session of the default room
 func (e *EndpointConfig) StartGame(c echo.Context) error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+	return e.startRoom(c, e.defaultRoom())
+}
+
+// StopGame stops the session of the default room
+func (e *EndpointConfig) StopGame(c echo.Context) error {
+	return e.stopRoom(c, e.defaultRoom())
+}
+
+// PauseGame pauses the session of the default room
+func (e *EndpointConfig) PauseGame(c echo.Context) error {
+	return e.pauseRoom(c, e.defaultRoom())
+}
+
+// ResumeGame resumes the paused session of the default room
+func (e *EndpointConfig) ResumeGame(c echo.Context) error {
+	return e.resumeRoom(c, e.defaultRoom())
+}
+
+// startRoom starts a session of room, timed when the body sets duration_seconds
+func (e *EndpointConfig) startRoom(c echo.Context, room *Room) error {
+	var req models.StartRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid start request")
+	}
+	gameStatus, err := e.beginSession(room, req.DurationSeconds)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, gameStatus)
+}
+
+// beginSession starts a session of room that stops after durationSeconds when it is positive
+func (e *EndpointConfig) beginSession(room *Room, durationSeconds int) (models.GameStatus, error) {
+	if err := validateDuration(durationSeconds); err != nil {
+		return models.GameStatus{}, err
+	}
+
+	// deferred first so the publications are sent once the room lock is released
+	defer room.publications.flush()
+	room.mu.Lock()
+	defer room.mu.Unlock()
 
-	if e.gameState.IsActive {
-		return echo.NewHTTPError(http.StatusBadRequest, "Game is already in progress")
+	if room.state.IsActive {
+		return models.GameStatus{}, echo.NewHTTPError(http.StatusBadRequest, "Game is already in progress")
 	}
 
 	now := time.Now().UTC()
-	e.gameState.IsActive = true
-	e.gameState.StartedAt = now
-	e.gameState.EndedAt = time.Time{}
-	e.gameState.CurrentPlayers = make([]string, 0)
+	room.state.SessionID = uuid.NewString()
+	room.state.IsActive = true
+	room.state.StartedAt = now
+	room.state.EndedAt = time.Time{}
+	room.state.CurrentPlayers = make([]string, 0)
+	room.state.DurationSeconds = durationSeconds
+	room.state.IsPaused = false
+	room.pausedFor = 0
+	room.participants = make([]string, 0)
+	room.scores = make(map[string]*models.PlayerScore)
+	room.spawner = nil
+	if e.ServerSpawning {
+		room.spawner = newSpawner()
+	}
 
 	gameStatus := models.GameStatus{
 		Message: "Game started",
 		SessionStats: models.SessionStats{
-			StartedAt: e.gameState.StartedAt,
+			SessionID: room.state.SessionID,
+			StartedAt: room.state.StartedAt,
 		},
 	}
 
-	return c.JSON(http.StatusOK, gameStatus)
+	// queued while holding the lock so the lifecycle events stay in order
+	started := room.lifecycleEvent(models.GameStarted)
+	started.SessionStats = &gameStatus.SessionStats
+	record := room.sessionRecord()
+	room.publications.enqueue(func() {
+		e.publishLifecycle(started)
+		e.recordSession(record)
+	})
+	if durationSeconds > 0 {
+		e.startTimer(room)
+	}
+	e.startSpawner(room)
+
+	return gameStatus, nil
 }
 
-func (e *EndpointConfig) StopGame(c echo.Context) error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+// validateDuration checks the length of a timed game, 0 runs the game until it is stopped
+func validateDuration(durationSeconds int) error {
+	if durationSeconds < 0 || time.Duration(durationSeconds)*time.Second > maxGameDuration {
+		return echo.NewHTTPError(http.StatusBadRequest, "duration_seconds must be between 0 and "+strconv.Itoa(int(maxGameDuration.Seconds())))
+	}
+	return nil
+}
 
-	if !e.gameState.IsActive {
+func (e *EndpointConfig) stopRoom(c echo.Context, room *Room) error {
+	room.mu.Lock()
+	if !room.state.IsActive {
+		room.mu.Unlock()
 		return echo.NewHTTPError(http.StatusBadRequest, "No game in progress")
 	}
+	gameStatus := e.endSession(room)
+	room.mu.Unlock()
+
+	room.publications.flush()
+	return c.JSON(http.StatusOK, gameStatus)
+}
+
+// endSession stops the active session of room, whether stopped by an admin or
+// by its timer, callers hold the room lock and flush the publications once they release it
+func (e *EndpointConfig) endSession(room *Room) models.GameStatus {
+	room.stopTimer()
+	room.stopSpawner()
+	if e.AntiCheat != nil {
+		e.AntiCheat.Forget(room.state.SessionID)
+	}
 
 	now := time.Now().UTC()
-	e.gameState.IsActive = false
-	e.gameState.EndedAt = now
+	room.pausedFor = room.pausedDuration(now)
+	room.state.IsPaused = false
+	room.state.IsActive = false
+	room.state.EndedAt = now
 
-	e.gameState.CurrentPlayers = make([]string, 0)
+	players := room.state.CurrentPlayers
+	room.state.CurrentPlayers = make([]string, 0)
 
 	gameStatus := models.GameStatus{
 		Message: "Game stopped",
 		SessionStats: models.SessionStats{
-			StartedAt:       e.gameState.StartedAt,
-			EndedAt:         e.gameState.EndedAt,
-			DurationSeconds: e.gameState.EndedAt.Sub(e.gameState.StartedAt).Seconds(),
-			TotalPlayers:    len(e.gameState.CurrentPlayers),
-			PlayerList:      e.gameState.CurrentPlayers,
+			SessionID:       room.state.SessionID,
+			StartedAt:       room.state.StartedAt,
+			EndedAt:         room.state.EndedAt,
+			DurationSeconds: room.playedDuration().Seconds(),
+			PausedSeconds:   room.pausedFor.Seconds(),
+			TotalPlayers:    len(players),
+			PlayerList:      players,
 		},
 	}
 
-	return c.JSON(http.StatusOK, gameStatus)
+	stopped := room.lifecycleEvent(models.GameStopped)
+	stopped.SessionStats = &gameStatus.SessionStats
+	record := room.sessionRecord()
+	record.Standings = room.standings()
+	if e.Leaderboard != nil {
+		e.Leaderboard.End(record.SessionID)
+	}
+	room.publications.enqueue(func() {
+		e.publishResults(stopped, record.Standings)
+		e.recordSession(record)
+	})
+
+	return gameStatus
 }
@@ -18,9 +18,13 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/kameshsampath/balloon-popper/pkg/leaderboard"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
 	"github.com/kameshsampath/balloon-popper/pkg/security"
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
@@ -153,4 +157,127 @@ func TestProtectedEndpoints(t *testing.T) {
 	}
 }
 
-//TODO start and stop game tests
+func TestStartStopGameLifecycle(t *testing.T) {
+	sink := producer.NewMemoryScoreSink()
+	ec := &EndpointConfig{
+		config:    models.NewGameConfig(),
+		gameState: models.NewGameState(),
+		Lifecycle: sink,
+	}
+	e := echo.New()
+
+	rec := httptest.NewRecorder()
+	assert.NoError(t, ec.StartGame(e.NewContext(httptest.NewRequest(http.MethodPost, "/admin/start", nil), rec)))
+	var started models.GameStatus
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &started))
+	assert.NotEmpty(t, started.SessionStats.SessionID)
+
+	ec.gameState.CurrentPlayers = append(ec.gameState.CurrentPlayers, "tester")
+
+	rec = httptest.NewRecorder()
+	assert.NoError(t, ec.StopGame(e.NewContext(httptest.NewRequest(http.MethodPost, "/admin/stop", nil), rec)))
+	var stopped models.GameStatus
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stopped))
+	assert.Equal(t, started.SessionStats.SessionID, stopped.SessionStats.SessionID)
+	assert.Equal(t, 1, stopped.SessionStats.TotalPlayers)
+	assert.Equal(t, []string{"tester"}, stopped.SessionStats.PlayerList)
+
+	events := sink.LifecycleEvents()
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, models.GameStarted, events[0].Type)
+		assert.Equal(t, models.GameStopped, events[1].Type)
+		for _, event := range events {
+			assert.Equal(t, started.SessionStats.SessionID, event.SessionID)
+		}
+		if assert.NotNil(t, events[1].SessionStats) {
+			assert.Equal(t, []string{"tester"}, events[1].SessionStats.PlayerList)
+		}
+	}
+}
+
+func TestStopGameSendsResults(t *testing.T) {
+	sink := producer.NewMemoryScoreSink()
+	ec := &EndpointConfig{
+		config:      models.NewGameConfig(),
+		gameState:   models.NewGameState(),
+		Leaderboard: leaderboard.New(),
+		Lifecycle:   sink,
+		Results:     sink,
+	}
+	e := echo.New()
+	assert.NoError(t, ec.StartGame(e.NewContext(httptest.NewRequest(http.MethodPost, "/admin/start", nil), httptest.NewRecorder())))
+	sessionID := ec.gameState.SessionID
+	// the standings come from the room scores, the leaderboard has not seen the pops
+	room := ec.rooms[DefaultRoomID]
+	room.mu.Lock()
+	for _, event := range []*models.GameEvent{
+		models.NewGameEvent("tom", "red", 100, false),
+		models.NewGameEvent("jerry", "gold", 180, true),
+		models.NewGameEvent("tom", "spike", -20, false),
+	} {
+		event.SessionID = sessionID
+		room.score(event.Player, event)
+	}
+	room.mu.Unlock()
+	assert.NoError(t, ec.StopGame(e.NewContext(httptest.NewRequest(http.MethodPost, "/admin/stop", nil), httptest.NewRecorder())))
+
+	standings := sink.Standings()
+	if assert.Len(t, standings, 2) {
+		assert.Equal(t, "jerry", standings[0].Player)
+		assert.Equal(t, sessionID, standings[0].SessionID)
+		assert.Equal(t, 80, standings[1].TotalScore)
+		assert.Equal(t, 1, standings[1].RegularHits)
+		assert.Equal(t, 1, standings[1].NegativeHits)
+	}
+	events := sink.LifecycleEvents()
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, models.GameStopped, events[1].Type)
+	}
+}
+
+// blockingResults holds the session results until released, like an unreachable broker
+type blockingResults struct {
+	sending chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingResults) SendSessionResults(context.Context, *models.LifecycleEvent, []models.PlayerScore) error {
+	close(b.sending)
+	<-b.release
+	return nil
+}
+
+func TestStopGamePublishesOutsideRoomLock(t *testing.T) {
+	results := &blockingResults{sending: make(chan struct{}), release: make(chan struct{})}
+	ec := &EndpointConfig{
+		config:    models.NewGameConfig(),
+		gameState: models.NewGameState(),
+		Results:   results,
+	}
+	e := echo.New()
+	assert.NoError(t, ec.StartGame(e.NewContext(httptest.NewRequest(http.MethodPost, "/admin/start", nil), httptest.NewRecorder())))
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- ec.StopGame(e.NewContext(httptest.NewRequest(http.MethodPost, "/admin/stop", nil), httptest.NewRecorder()))
+	}()
+	<-results.sending
+
+	// the status is served while the results are still being sent
+	status := make(chan models.GameState, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		_ = ec.GameStatus(e.NewContext(httptest.NewRequest(http.MethodGet, "/status", nil), rec))
+		var state models.GameState
+		_ = json.Unmarshal(rec.Body.Bytes(), &state)
+		status <- state
+	}()
+	select {
+	case state := <-status:
+		assert.False(t, state.IsActive)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the status is blocked by the session results")
+	}
+	close(results.release)
+	assert.NoError(t, <-stopped)
+}
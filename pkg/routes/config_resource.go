@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+)
+
+// maxConfigSize caps the size of a game config sent to UpdateGameConfig
+const maxConfigSize = 1 << 20
+
+// GetGameConfig returns the game config of the default room, the base of the other rooms
+func (e *EndpointConfig) GetGameConfig(c echo.Context) error {
+	return e.roomConfig(c, e.defaultRoom())
+}
+
+// UpdateGameConfig replaces the game config with the YAML or JSON body, the settings
+// missing in the body keep their current values. It is rejected while a game config
+// file is watched, as the next reload of the file would revert it.
+func (e *EndpointConfig) UpdateGameConfig(c echo.Context) error {
+	data, err := io.ReadAll(io.LimitReader(c.Request().Body, maxConfigSize))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to read the game config")
+	}
+	// the body is merged on the config it replaces
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	if e.configFile != "" {
+		return echo.NewHTTPError(http.StatusConflict, "The game config is loaded from "+e.configFile+", edit the file instead")
+	}
+	e.mu.Lock()
+	base := e.config
+	e.mu.Unlock()
+	if base == nil {
+		base = models.NewGameConfig()
+	}
+	config, err := models.ParseGameConfig(data, base)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid game config: "+err.Error())
+	}
+	if err := e.applyConfig(config); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid game config: "+err.Error())
+	}
+	return c.JSON(http.StatusOK, e.clientConfig(config))
+}
+
+// ApplyConfig validates config and makes it the config of the default room and the
+// base of the created rooms, which keep their overrides. The players of the rooms
+// are sent the config they play with from now on
+func (e *EndpointConfig) ApplyConfig(config *models.GameConfig) error {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	return e.applyConfig(config)
+}
+
+// applyConfig applies config to the rooms, callers hold the config lock until every
+// room has it so concurrent changes never leave the rooms with configs of different changes
+func (e *EndpointConfig) applyConfig(config *models.GameConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.config = config
+	e.initRooms()
+	configs := make(map[*Room]*models.GameConfig, len(e.rooms))
+	for _, room := range e.rooms {
+		if room.ID == DefaultRoomID {
+			configs[room] = config
+			continue
+		}
+		merged, err := mergeConfig(config, room.overrides)
+		if err != nil {
+			e.Logger.Warnf("Room %s keeps its game config, its overrides do not apply to the new one: %v", room.ID, err)
+			continue
+		}
+		configs[room] = merged
+	}
+	e.mu.Unlock()
+
+	for room, roomConfig := range configs {
+		room.mu.Lock()
+		room.config = roomConfig
+		room.mu.Unlock()
+		room.broadcast(models.ConfigChanged{Type: "config_changed", Config: e.clientConfig(roomConfig)})
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newConfigTest(t *testing.T) (*EndpointConfig, *echo.Echo, *httptest.Server) {
+	ec := &EndpointConfig{
+		config:    models.NewGameConfig(),
+		gameState: models.NewGameState(),
+		Logger:    logger.Get(),
+	}
+	e := echo.New()
+	e.POST("/admin/start", ec.StartGame)
+	e.GET("/admin/config", ec.GetGameConfig)
+	e.PUT("/admin/config", ec.UpdateGameConfig)
+	e.POST("/admin/rooms", ec.CreateRoom)
+	e.GET("/ws/:player", ec.WebSocket)
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+	return ec, e, srv
+}
+
+func TestUpdateGameConfig(t *testing.T) {
+	ec, e, srv := newConfigTest(t)
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/start", "").Code)
+	rec := serve(e, http.MethodPost, "/admin/rooms", `{"join_code":"SCR1","colors":{"red":500}}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/tester", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close() //nolint:errcheck
+	_ = ws.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	// a favorite color without a score is rejected, the running config is kept
+	rec = serve(e, http.MethodPut, "/admin/config", `{"colors":{"red":10},"character_favorites":{"Mario":["red","black"]},"character_negatives":{}}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `favorite color \"black\" of character \"Mario\" is not in colors`)
+	assert.Equal(t, 100, ec.defaultRoom().config.Colors["red"])
+
+	rec = serve(e, http.MethodPut, "/admin/config", `{"bonus_probability":0.5,"colors":{"red":10,"blue":20,"black":5}}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "the default negative colors are missing")
+	rec = serve(e, http.MethodPut, "/admin/config", `
+colors: {red: 10, blue: 20, green: 30}
+character_favorites: {Mario: [red]}
+character_negatives: {Mario: [blue]}
+bonus_probability: 0.5
+`)
+	if !assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) {
+		return
+	}
+
+	var changed struct {
+		Type   string            `json:"type"`
+		Config models.GameConfig `json:"config"`
+	}
+	assert.NoError(t, ws.ReadJSON(&changed))
+	assert.Equal(t, "config_changed", changed.Type)
+	assert.Equal(t, map[string]int{"red": 10, "blue": 20, "green": 30}, changed.Config.Colors)
+	assert.Equal(t, 0.5, changed.Config.BonusProbability)
+	// the settings missing in the body keep their values
+	assert.Equal(t, models.NegativePenalty{Ratio: 0.5, Min: 10}, changed.Config.NegativePenalty)
+
+	var got models.GameConfig
+	rec = serve(e, http.MethodGet, "/admin/config", "")
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, changed.Config, got)
+
+	// the created rooms keep their overrides on top of the new config
+	room := ec.findRoom("SCR1")
+	if assert.NotNil(t, room) {
+		assert.Equal(t, 500, room.config.Colors["red"])
+		assert.Equal(t, 20, room.config.Colors["blue"])
+	}
+
+	// the pops are scored with the new config
+	assert.NoError(t, ws.WriteJSON(models.GameMessage{Player: "tester", Character: "Mario", BalloonColor: "green"}))
+	var update models.ScoreUpdate
+	if assert.NoError(t, ws.ReadJSON(&update)) && assert.NotNil(t, update.Event) {
+		assert.Equal(t, 30, update.Event.Score)
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	ec, e, _ := newConfigTest(t)
+	file := filepath.Join(t.TempDir(), "game.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("bonus_probability: 0.2\n"), 0o600))
+	stop, err := ec.WatchConfig(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	bonus := func() float64 {
+		room := ec.defaultRoom()
+		room.mu.RLock()
+		defer room.mu.RUnlock()
+		return room.config.BonusProbability
+	}
+	assert.NoError(t, os.WriteFile(file, []byte("bonus_probability: 0.4\n"), 0o600))
+	assert.Eventually(t, func() bool { return bonus() == 0.4 }, 5*time.Second, 20*time.Millisecond)
+
+	// an invalid file keeps the running config
+	assert.NoError(t, os.WriteFile(file, []byte("bonus_probability: 4\n"), 0o600))
+	time.Sleep(5 * configReloadDelay)
+	assert.Equal(t, 0.4, bonus())
+
+	// the changes of the config go through the file, a reload would revert the others
+	rec := serve(e, http.MethodPut, "/admin/config", "bonus_probability: 0.3\n")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, 0.4, bonus())
+	assert.NoError(t, stop())
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPut, "/admin/config", "bonus_probability: 0.3\n").Code)
+}
+
+func TestApplyConfigConcurrently(t *testing.T) {
+	ec, e, _ := newConfigTest(t)
+	assert.Equal(t, http.StatusCreated, serve(e, http.MethodPost, "/admin/rooms", `{"join_code":"SCR1","colors":{"red":500}}`).Code)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config := models.NewGameConfig()
+			config.BonusProbability = float64(i+1) / 100
+			assert.NoError(t, ec.ApplyConfig(config))
+		}()
+	}
+	wg.Wait()
+
+	// every room plays the config of the last change
+	ec.mu.RLock()
+	want := ec.config.BonusProbability
+	ec.mu.RUnlock()
+	for _, room := range []*Room{ec.defaultRoom(), ec.findRoom("SCR1")} {
+		room.mu.RLock()
+		assert.Equal(t, want, room.config.BonusProbability, room.ID)
+		room.mu.RUnlock()
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"path/filepath"
+	"time"
+)
+
+// configReloadDelay lets the writes of an editor settle before the game config is reloaded
+const configReloadDelay = 100 * time.Millisecond
+
+// WatchConfig reloads the game config from file whenever it changes, keeping the
+// running config when the file is invalid, until the returned function is called.
+// Meanwhile the file is the only source of the config changes.
+func (e *EndpointConfig) WatchConfig(file string) (func() error, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch game config %s: %w", file, err)
+	}
+	// the directory is watched as the editors replace the file rather than writing it
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		_ = watcher.Close()
+		return nil, fmt.Errorf("failed to watch game config %s: %w", file, err)
+	}
+	name := filepath.Clean(file)
+	e.configMu.Lock()
+	e.configFile = name
+	e.configMu.Unlock()
+	go func() {
+		var reload <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == name && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+					reload = time.After(configReloadDelay)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				e.Logger.Warnf("Failed to watch game config %s: %v", file, err)
+			case <-reload:
+				reload = nil
+				e.reloadConfig(file)
+			}
+		}
+	}()
+	return func() error {
+		e.configMu.Lock()
+		e.configFile = ""
+		e.configMu.Unlock()
+		return watcher.Close()
+	}, nil
+}
+
+func (e *EndpointConfig) reloadConfig(file string) {
+	config, err := models.LoadGameConfig(file)
+	if err == nil {
+		err = e.ApplyConfig(config)
+	}
+	if err != nil {
+		e.Logger.Warnf("Keeping the running game config, failed to reload %s: %v", file, err)
+		return
+	}
+	e.Logger.Infof("Reloaded the game config from %s", file)
+}
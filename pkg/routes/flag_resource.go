@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"errors"
+	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/anticheat"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+// errDisconnected tells the read loop that the anti-cheat closed the connection
+var errDisconnected = errors.New("disconnected by the anti-cheat")
+
+// ListFlags lists the players flagged by the anti-cheat, the last raised first,
+// optionally of the player or room query parameters
+func (e *EndpointConfig) ListFlags(c echo.Context) error {
+	if e.AntiCheat == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Anti-cheat is not enabled")
+	}
+	return c.JSON(http.StatusOK, e.AntiCheat.Flags(c.QueryParam("player"), c.QueryParam("room")))
+}
+
+// enforce applies the anti-cheat action of flag to the message that raised it, it returns
+// whether the message can still be scored, or an error when the connection is over
+func (e *EndpointConfig) enforce(conn *playerConn, flag *models.Flag) (bool, error) {
+	if flag == nil {
+		return true, nil
+	}
+	// the repeats are counted on the flag rather than logged
+	if flag.Count == 1 {
+		e.Logger.Warnf("Flagged player %s of room %s: %s, %s", flag.Player, flag.RoomID, flag.Reason, flag.Detail)
+	}
+	switch flag.Action {
+	case anticheat.ActionIgnore:
+		return false, conn.writeJSON(models.ErrorFrame{
+			Type:    "error",
+			Code:    flag.Reason,
+			Message: flag.Detail,
+		})
+	case anticheat.ActionDisconnect:
+		_ = conn.ws.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, flag.Reason), time.Now().Add(writeWait))
+		return false, errDisconnected
+	}
+	return true, nil
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/anticheat"
+	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newAntiCheatTest(t *testing.T, action string) (*echo.Echo, *websocket.Conn) {
+	ec := &EndpointConfig{
+		config:    models.NewGameConfig(),
+		gameState: models.NewGameState(),
+		AntiCheat: anticheat.New(anticheat.Config{Rate: 0.1, Burst: 2, FavoriteRatio: 1, Action: action}),
+		Logger:    logger.Get(),
+	}
+	e := echo.New()
+	e.POST("/admin/start", ec.StartGame)
+	e.GET("/admin/flags", ec.ListFlags)
+	e.GET("/ws/:player", ec.WebSocket)
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/start", "").Code)
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/bot", nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { _ = ws.Close() })
+	_ = ws.SetReadDeadline(time.Now().Add(10 * time.Second))
+	return e, ws
+}
+
+func TestAntiCheatIgnore(t *testing.T) {
+	e, ws := newAntiCheatTest(t, anticheat.ActionIgnore)
+
+	pop := models.GameMessage{Player: "bot", Character: "Mario", BalloonColor: "green"}
+	for range 2 {
+		assert.NoError(t, ws.WriteJSON(pop))
+		assert.Equal(t, "score_update", readFrame(t, ws)["type"])
+	}
+	// the pops over the burst are not scored
+	for range 3 {
+		assert.NoError(t, ws.WriteJSON(pop))
+		frame := readFrame(t, ws)
+		assert.Equal(t, "error", frame["type"])
+		assert.Equal(t, anticheat.ReasonRateLimited, frame["code"])
+	}
+
+	rec := serve(e, http.MethodGet, "/admin/flags?player=bot", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var flags []models.Flag
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &flags))
+	if assert.Len(t, flags, 1) {
+		assert.Equal(t, anticheat.ReasonRateLimited, flags[0].Reason)
+		assert.Equal(t, anticheat.ActionIgnore, flags[0].Action)
+		assert.Equal(t, DefaultRoomID, flags[0].RoomID)
+		assert.Equal(t, 3, flags[0].Count)
+	}
+}
+
+func TestAntiCheatDisconnect(t *testing.T) {
+	_, ws := newAntiCheatTest(t, anticheat.ActionDisconnect)
+
+	pop := models.GameMessage{Player: "bot", Character: "Mario", BalloonColor: "green"}
+	for range 3 {
+		assert.NoError(t, ws.WriteJSON(pop))
+	}
+	for range 2 {
+		assert.Equal(t, "score_update", readFrame(t, ws)["type"])
+	}
+	var frame map[string]any
+	err := ws.ReadJSON(&frame)
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "expected a policy violation close, got %v", err)
+}
+
+func TestAntiCheatFlagsBotUnderServerSpawning(t *testing.T) {
+	ec := &EndpointConfig{
+		config:         models.NewGameConfig(),
+		gameState:      models.NewGameState(),
+		AntiCheat:      anticheat.New(anticheat.Config{Rate: 100, Burst: 100, FavoriteRatio: anticheat.DefaultFavoriteRatio, Action: anticheat.ActionIgnore}),
+		Logger:         logger.Get(),
+		ServerSpawning: true,
+	}
+	e := echo.New()
+	e.POST("/admin/start", ec.StartGame)
+	e.GET("/ws/:player", ec.WebSocket)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/start", "").Code)
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/bot?character=Mario", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close() //nolint:errcheck
+	_ = ws.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	// spawn the favorite balloons of the bot at once rather than waiting for the spawn loop
+	room := ec.findRoom(DefaultRoomID)
+	room.mu.RLock()
+	sp, config := room.spawner, room.config
+	room.mu.RUnlock()
+	favorites := make([]string, 0)
+	for len(favorites) < 30 {
+		if b := sp.spawn(config, "bot", "Mario", 1, time.Now()); b.Kind == models.BalloonBonus {
+			favorites = append(favorites, b.ID)
+		}
+	}
+
+	// the bot pops every one of them as soon as it spawned
+	codes := make([]any, 0)
+	for _, id := range favorites {
+		assert.NoError(t, ws.WriteJSON(models.GameMessage{Type: "pop", BalloonID: id}))
+		frame := readFrame(t, ws)
+		for frame["type"] == "balloon_spawned" {
+			frame = readFrame(t, ws)
+		}
+		codes = append(codes, frame["code"])
+	}
+	assert.Contains(t, codes, anticheat.ReasonFavoriteRatio)
+	flags := ec.AntiCheat.Flags("bot", "")
+	if assert.Len(t, flags, 1) {
+		assert.Equal(t, anticheat.ReasonFavoriteRatio, flags[0].Reason)
+	}
+}
+
+func TestAntiCheatIgnoredPopKeepsBalloon(t *testing.T) {
+	ec := &EndpointConfig{
+		config:         models.NewGameConfig(),
+		gameState:      models.NewGameState(),
+		AntiCheat:      anticheat.New(anticheat.Config{Rate: 100, Burst: 100, FavoriteRatio: 1, Action: anticheat.ActionIgnore}),
+		Logger:         logger.Get(),
+		ServerSpawning: true,
+	}
+	e := echo.New()
+	e.POST("/admin/start", ec.StartGame)
+	e.GET("/ws/:player", ec.WebSocket)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/start", "").Code)
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/bot?character=Mario", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close() //nolint:errcheck
+	_ = ws.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	// more balloons than could have been spawned in the window of the too fast check
+	room := ec.findRoom(DefaultRoomID)
+	room.mu.RLock()
+	sp, config := room.spawner, room.config
+	room.mu.RUnlock()
+	balloons := make([]string, 0)
+	for len(balloons) < 44 {
+		if b := sp.spawn(config, "bot", "Mario", 1, time.Now()); b.Kind != models.BalloonBonus {
+			balloons = append(balloons, b.ID)
+		}
+	}
+	pop := func(id string) map[string]any {
+		assert.NoError(t, ws.WriteJSON(models.GameMessage{Type: "pop", BalloonID: id}))
+		frame := readFrame(t, ws)
+		for frame["type"] == "balloon_spawned" {
+			frame = readFrame(t, ws)
+		}
+		return frame
+	}
+
+	last := balloons[len(balloons)-1]
+	for _, id := range balloons[:len(balloons)-1] {
+		assert.Equal(t, "score_update", pop(id)["type"])
+	}
+	assert.Equal(t, anticheat.ReasonTooFast, pop(last)["code"])
+	// the ignored pop did not use the balloon up
+	assert.Equal(t, anticheat.ReasonTooFast, pop(last)["code"])
+}
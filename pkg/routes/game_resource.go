@@ -19,8 +19,10 @@ package routes
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/anticheat"
 	"github.com/kameshsampath/balloon-popper/pkg/models"
 	"github.com/labstack/echo/v4"
 	"net/http"
@@ -33,105 +35,236 @@ func (e *EndpointConfig) Root(c echo.Context) error {
 }
 
 func (e *EndpointConfig) GetConfig(c echo.Context) error {
-	return c.JSON(http.StatusOK, e.config)
+	return e.roomConfig(c, e.defaultRoom())
 }
 
 func (e *EndpointConfig) GameStatus(c echo.Context) error {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+	return e.roomStatus(c, e.defaultRoom())
+}
 
-	gameStatus := models.NewGameState()
-	gameStatus.IsActive = e.gameState.IsActive
-	gameStatus.StartedAt = e.gameState.StartedAt
-	gameStatus.EndedAt = e.gameState.EndedAt
-	gameStatus.CurrentPlayers = e.gameState.CurrentPlayers
-	gameStatus.PlayerCount = len(e.gameState.CurrentPlayers)
+func (e *EndpointConfig) WebSocket(c echo.Context) error {
+	return e.play(c, e.defaultRoom(), c.Param("player"))
+}
 
-	return c.JSON(http.StatusOK, gameStatus)
+func (e *EndpointConfig) roomConfig(c echo.Context, room *Room) error {
+	room.mu.RLock()
+	config := room.config
+	room.mu.RUnlock()
+	return c.JSON(http.StatusOK, e.clientConfig(config))
 }
 
-func (e *EndpointConfig) WebSocket(c echo.Context) error {
+// clientConfig copies config telling the clients whether the server spawns the balloons
+func (e *EndpointConfig) clientConfig(config *models.GameConfig) *models.GameConfig {
+	client := *config
+	client.BalloonSpawning = SpawningClient
+	if e.ServerSpawning {
+		client.BalloonSpawning = SpawningServer
+	}
+	return &client
+}
+
+func (e *EndpointConfig) roomStatus(c echo.Context, room *Room) error {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	return c.JSON(http.StatusOK, room.snapshot())
+}
+
+// play scores the balloon pops of playerName in the game of room until the game
+// stops or the player disconnects
+func (e *EndpointConfig) play(c echo.Context, room *Room, playerName string) error {
 	log := e.Logger
-	e.mu.Lock()
-	if !e.gameState.IsActive {
-		e.mu.Unlock()
+	character := c.QueryParam("character")
+	room.mu.RLock()
+	if !room.state.IsActive {
+		room.mu.RUnlock()
 		return echo.NewHTTPError(http.StatusForbidden, "No active game session")
 	}
-	e.mu.Unlock()
+	_, knownCharacter := room.config.CharacterFavorites[character]
+	room.mu.RUnlock()
+	// the server spawns the balloons of the character the player plays
+	if e.ServerSpawning && !knownCharacter {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown character %q", character))
+	}
 
-	playerName := c.Param("player")
 	ws, err := e.upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
 		return fmt.Errorf("failed to upgrade connection: %v", err)
 	}
 	defer ws.Close() //nolint:errcheck
+	conn := &playerConn{ws: ws, player: playerName, character: character}
 
 	// Add player to current session
-	e.mu.Lock()
-	if !contains(e.gameState.CurrentPlayers, playerName) {
-		e.gameState.CurrentPlayers = append(e.gameState.CurrentPlayers, playerName)
+	room.mu.Lock()
+	room.connect(conn)
+	if !contains(room.state.CurrentPlayers, playerName) {
+		room.state.CurrentPlayers = append(room.state.CurrentPlayers, playerName)
+		joined := room.lifecycleEvent(models.PlayerJoined)
+		joined.Player = playerName
+		room.publications.enqueue(func() { e.publishLifecycle(joined) })
+	}
+	if !contains(room.participants, playerName) {
+		room.participants = append(room.participants, playerName)
+		record := room.sessionRecord()
+		room.publications.enqueue(func() { e.recordSession(record) })
+	}
+	left := room.lifecycleEvent(models.PlayerLeft)
+	left.Player = playerName
+	var watch *anticheat.Watch
+	if e.AntiCheat != nil {
+		watch = e.AntiCheat.Watch(room.ID, room.state.SessionID, playerName)
+	}
+	sp, level, joinConfig := room.spawner, room.level(time.Now()), room.config
+	spawnNow := sp != nil && !room.state.IsPaused
+	room.mu.Unlock()
+	room.publications.flush()
+
+	// the first balloon does not wait for the next spawn
+	if spawnNow {
+		spawnFor(conn, sp, joinConfig, level, time.Now())
 	}
-	e.mu.Unlock()
 
 	// Remove player when done
 	defer func() {
-		e.mu.Lock()
-		e.gameState.CurrentPlayers = removeString(e.gameState.CurrentPlayers, playerName)
-		e.mu.Unlock()
-		log.Infof("Player %s disconnected", playerName)
+		room.mu.Lock()
+		delete(room.conns, conn)
+		room.state.CurrentPlayers = removeString(room.state.CurrentPlayers, playerName)
+		left.EventTS = time.Now().UTC()
+		room.publications.enqueue(func() { e.publishLifecycle(left) })
+		room.mu.Unlock()
+		room.publications.flush()
+		log.Infof("Player %s disconnected from room %s", playerName, room.ID)
 	}()
 
 	for {
 		// Check if game is still active
-		e.mu.RLock()
-		isActive := e.gameState.IsActive
-		e.mu.RUnlock()
+		room.mu.RLock()
+		isActive := room.state.IsActive
+		sessionID := room.state.SessionID
+		sp := room.spawner
+		room.mu.RUnlock()
 
 		if !isActive {
 			return nil
 		}
 
 		// Read message
-		var msg models.GameMessage
-		if err := ws.ReadJSON(&msg); err != nil {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Infof("WebSocket error: %v", err)
 			}
 			return nil
 		}
 
+		// Limit the rate of the messages, a script can send thousands a second
+		if watch != nil {
+			if ok, err := e.enforce(conn, watch.Allow(time.Now())); err != nil {
+				return nil
+			} else if !ok {
+				continue
+			}
+		}
+
+		// Reject the pops sent while the game is paused, the config can change while playing
+		room.mu.RLock()
+		paused := room.state.IsPaused
+		config := room.config
+		room.mu.RUnlock()
+		if paused {
+			if err := conn.writeJSON(models.ErrorFrame{
+				Type:    "error",
+				Code:    models.CodeGamePaused,
+				Message: "The game is paused, pops are not scored until it resumes",
+			}); err != nil {
+				log.Infof("Failed to send error frame: %v", err)
+				return nil
+			}
+			continue
+		}
+		var msg models.GameMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			letter := models.NewDeadLetter(models.ReasonMalformedMessage, fmt.Sprintf("invalid game message: %v", err), playerName)
+			letter.SessionID = sessionID
+			letter.RoomID = room.ID
+			if len(data) > maxRawPayload {
+				data = data[:maxRawPayload]
+			}
+			letter.Raw = string(data)
+			if err := e.rejectMessage(conn, letter); err != nil {
+				log.Infof("Failed to send error frame: %v", err)
+				return nil
+			}
+			continue
+		}
+
 		log.Infof("Recevied message %s", msg)
 
+		// Reject the messages that cannot be scored rather than scoring them as 0 points
+		balloon, reason, detail := poppedBalloon(config, sp, playerName, &msg, time.Now())
+		if reason != "" {
+			letter := models.NewDeadLetter(reason, detail, playerName)
+			letter.SessionID = sessionID
+			letter.RoomID = room.ID
+			letter.Message = &msg
+			if err := e.rejectMessage(conn, letter); err != nil {
+				log.Infof("Failed to send error frame: %v", err)
+				return nil
+			}
+			continue
+		}
+
 		// Process game event
-		isNegativeHit := msg.NegativeHit
-		isFavoriteHit := contains(e.config.CharacterFavorites[msg.Character], msg.BalloonColor)
-		score := e.config.Colors[msg.BalloonColor]
-		//double the score for bonus hits
-		if isFavoriteHit {
-			score = score * 2
-		} else if isNegativeHit {
-			score = -(score / 2)
+		score, isFavoriteHit := balloonScore(config, balloon)
+		if watch != nil {
+			var spawned *anticheat.Spawned
+			if sp != nil {
+				spawned = sp.spawned(playerName, msg.BalloonID, time.Now())
+			}
+			ok, err := e.enforce(conn, watch.Pop(time.Now(), isFavoriteHit, spawned))
+			if !ok && sp != nil {
+				// the pop is not scored, so the balloon is still there to be popped
+				sp.unpop(msg.BalloonID)
+			}
+			if err != nil {
+				return nil
+			} else if !ok {
+				continue
+			}
 		}
 		event := models.NewGameEvent(
 			playerName,
-			msg.BalloonColor,
+			balloon.Color,
 			score,
 			isFavoriteHit,
 		)
+		event.SessionID = sessionID
+		event.RoomID = room.ID
 
-		// Send to Kafka with context
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		if err := e.KafkaProducer.SendScore(ctx, event); err != nil {
-			log.Infof("Failed to send score to Kafka: %v", err)
+		// Send to the score sink with context, with the async pipeline this only queues the event
+		if e.ScoreSink != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := e.ScoreSink.SendScore(ctx, event); err != nil {
+				log.Infof("Failed to send score: %v", err)
+			}
+			cancel()
 		}
-		cancel()
 
-		// Send score update
+		// Send score update, with the running total the client shows
 		update := models.ScoreUpdate{
 			Type:  "score_update",
 			Event: event,
 		}
-		if err := ws.WriteJSON(update); err != nil {
+		if balloon.Kind == models.BalloonNegative {
+			update.Penalty = -score
+		}
+		room.mu.Lock()
+		if room.state.SessionID == sessionID && room.scores != nil {
+			ps := room.score(playerName, event)
+			update.Total = ps.TotalScore
+			update.BonusHits, update.RegularHits, update.NegativeHits = ps.BonusHits, ps.RegularHits, ps.NegativeHits
+		}
+		room.mu.Unlock()
+		if err := conn.writeJSON(update); err != nil {
 			log.Infof("Failed to send score update: %v", err)
 			return nil
 		}
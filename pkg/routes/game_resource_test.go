@@ -19,19 +19,24 @@ package routes
 
 import (
 	"encoding/json"
+	"github.com/gorilla/websocket"
 	"github.com/kameshsampath/balloon-popper/pkg/logger"
 	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 const defaultConfigJSON = `
 {
   "colors": {
+    "black": 70,
     "blue": 75,
     "brown": 35,
     "gold": 90,
@@ -64,7 +69,29 @@ const defaultConfigJSON = `
     "Tweety": ["yellow", "orange"],
     "Woody": ["brown", "yellow"]
   },
-  "bonus_probability": 0.15
+  "character_negatives": {
+    "Jerry": ["orange", "gold"],
+    "Tom": ["black", "purple"],
+    "Mickey": ["maroon", "grey"],
+    "Donald": ["purple", "red"],
+    "Bugs_Bunny": ["black", "brown"],
+    "Daffy_Duck": ["grey", "brown"],
+    "SpongeBob": ["gold", "orange"],
+    "Patrick": ["red", "yellow"],
+    "Pikachu": ["gold", "maroon"],
+    "Mario": ["maroon", "purple"],
+    "Sonic": ["purple", "yellow"],
+    "Woody": ["orange", "gold"],
+    "Buzz": ["yellow", "pink"],
+    "Scooby": ["orange", "yellow"],
+    "Popeye": ["purple", "maroon"],
+    "Pink_Panther": ["red", "blue"],
+    "Road_Runner": ["grey", "pink"],
+    "Tweety": ["gold", "brown"]
+  },
+  "negative_penalty": {"ratio": 0.5, "min": 10},
+  "bonus_probability": 0.15,
+  "balloon_spawning": "client"
 }
 `
 
@@ -133,3 +160,189 @@ func TestGetGameStatus(t *testing.T) {
 		assert.Equal(t, want.CurrentPlayers, got.CurrentPlayers, "CurrentPlayers field doesn't match")
 	}
 }
+
+func TestWebSocketWithMemorySink(t *testing.T) {
+	sink := producer.NewMemoryScoreSink()
+	ec := EndpointConfig{
+		config:    models.NewGameConfig(),
+		gameState: models.NewGameState(),
+		ScoreSink: sink,
+		Lifecycle: sink,
+		Logger:    logger.Get(),
+	}
+	ec.gameState.IsActive = true
+	ec.gameState.SessionID = "s1"
+
+	e := echo.New()
+	e.GET("/ws/:player", ec.WebSocket)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/tester"
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close() //nolint:errcheck
+
+	testCases := []struct {
+		name  string
+		msg   models.GameMessage
+		score int
+		bonus bool
+	}{
+		{"Regular", models.GameMessage{Player: "tester", Character: "Mario", BalloonColor: "green"}, 60, false},
+		{"Favorite", models.GameMessage{Player: "tester", Character: "Mario", BalloonColor: "red"}, 200, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NoError(t, ws.WriteJSON(tc.msg))
+
+			var update models.ScoreUpdate
+			assert.NoError(t, ws.ReadJSON(&update))
+			assert.Equal(t, "score_update", update.Type)
+			if assert.NotNil(t, update.Event) {
+				assert.Equal(t, "tester", update.Event.Player)
+				assert.Equal(t, tc.score, update.Event.Score)
+				assert.Equal(t, tc.bonus, update.Event.FavoriteColorBonus)
+			}
+		})
+	}
+
+	events := sink.Events()
+	if assert.Len(t, events, len(testCases)) {
+		for i, tc := range testCases {
+			assert.Equal(t, tc.msg.BalloonColor, events[i].BalloonColor)
+			assert.Equal(t, tc.score, events[i].Score)
+			assert.Equal(t, "s1", events[i].SessionID)
+		}
+	}
+
+	assert.NoError(t, ws.Close())
+	assert.Eventually(t, func() bool {
+		return len(sink.LifecycleEvents()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	lifecycle := sink.LifecycleEvents()
+	assert.Equal(t, models.PlayerJoined, lifecycle[0].Type)
+	assert.Equal(t, models.PlayerLeft, lifecycle[1].Type)
+	assert.Equal(t, "tester", lifecycle[1].Player)
+}
+
+func TestWebSocketRejectsInvalidMessages(t *testing.T) {
+	sink := producer.NewMemoryScoreSink()
+	ec := EndpointConfig{
+		config:      models.NewGameConfig(),
+		gameState:   models.NewGameState(),
+		ScoreSink:   sink,
+		DeadLetters: sink,
+		Logger:      logger.Get(),
+	}
+	ec.gameState.IsActive = true
+	ec.gameState.SessionID = "s1"
+
+	e := echo.New()
+	e.GET("/ws/:player", ec.WebSocket)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/tester"
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close() //nolint:errcheck
+
+	testCases := []struct {
+		name    string
+		payload string
+		code    string
+	}{
+		{"Malformed", `{"balloon_color": `, models.ReasonMalformedMessage},
+		{"UnknownColor", `{"player":"tester","character":"Mario","balloon_color":"teal"}`, models.ReasonUnknownColor},
+		{"UnknownCharacter", `{"player":"tester","character":"Garfield","balloon_color":"red"}`, models.ReasonUnknownCharacter},
+		{"OtherPlayer", `{"player":"cheater","character":"Mario","balloon_color":"red"}`, models.ReasonInvalidPlayer},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(tc.payload)))
+
+			var frame models.ErrorFrame
+			assert.NoError(t, ws.ReadJSON(&frame))
+			assert.Equal(t, "error", frame.Type)
+			assert.Equal(t, tc.code, frame.Code)
+			assert.NotEmpty(t, frame.Message)
+		})
+	}
+
+	// the connection stays usable after a rejected message
+	assert.NoError(t, ws.WriteJSON(models.GameMessage{Player: "tester", Character: "Mario", BalloonColor: "green"}))
+	var update models.ScoreUpdate
+	assert.NoError(t, ws.ReadJSON(&update))
+	assert.Equal(t, "score_update", update.Type)
+
+	assert.Len(t, sink.Events(), 1)
+	letters := sink.DeadLetters()
+	if assert.Len(t, letters, len(testCases)) {
+		for i, tc := range testCases {
+			assert.Equal(t, tc.code, letters[i].Reason)
+			assert.Equal(t, "tester", letters[i].Player)
+			assert.Equal(t, "s1", letters[i].SessionID)
+		}
+		assert.Equal(t, `{"balloon_color": `, letters[0].Raw)
+		if assert.NotNil(t, letters[1].Message) {
+			assert.Equal(t, "teal", letters[1].Message.BalloonColor)
+		}
+	}
+}
+
+func TestValidateMessage(t *testing.T) {
+	config := models.NewGameConfig()
+	valid := models.GameMessage{Character: "Mickey", BalloonColor: "black"}
+
+	reason, _ := validateMessage(config, "Jane Doe-1", &valid)
+	assert.Empty(t, reason)
+	for _, player := range []string{"", "<script>", strings.Repeat("x", 33)} {
+		reason, _ = validateMessage(config, player, &valid)
+		assert.Equal(t, models.ReasonInvalidPlayer, reason, player)
+	}
+	// every color of the game has a score, black included
+	reason, _ = validateMessage(config, "tester", &models.GameMessage{Character: "Mario", BalloonColor: "white"})
+	assert.Equal(t, models.ReasonUnknownColor, reason)
+}
+
+func TestNegativeHits(t *testing.T) {
+	e, srv, _ := newTimerTest(t)
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/start", "").Code)
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/tester", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close() //nolint:errcheck
+
+	testCases := []struct {
+		color    string
+		negative bool
+		score    int
+		penalty  int
+		total    int
+		// the hit counts the client shows
+		regular, negatives int
+	}{
+		// the server ignores the negative_hit of the client
+		{"green", true, 60, 0, 60, 1, 0},
+		{"maroon", false, -40, 40, 20, 1, 1},
+		{"red", false, 200, 0, 220, 1, 1},
+	}
+	for _, tc := range testCases {
+		assert.NoError(t, ws.WriteJSON(models.GameMessage{Player: "tester", Character: "Mario", BalloonColor: tc.color, NegativeHit: tc.negative}))
+		var update models.ScoreUpdate
+		if assert.NoError(t, ws.ReadJSON(&update)) && assert.NotNil(t, update.Event, tc.color) {
+			assert.Equal(t, tc.score, update.Event.Score, tc.color)
+			assert.Equal(t, tc.penalty, update.Penalty, tc.color)
+			assert.Equal(t, tc.total, update.Total, tc.color)
+			assert.Equal(t, tc.regular, update.RegularHits, tc.color)
+			assert.Equal(t, tc.negatives, update.NegativeHits, tc.color)
+		}
+	}
+}
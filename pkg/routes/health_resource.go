@@ -18,20 +18,48 @@
 package routes
 
 import (
+	"context"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"time"
 )
 
+// readyTimeout bounds the readiness check of the score sink
+const readyTimeout = 2 * time.Second
+
 // Live checks for the liveliness of the API endpoints
 func (e *EndpointConfig) Live(c echo.Context) error {
 	return c.JSON(http.StatusOK, "OK")
 }
 
-// Ready checks for the readiness of the API dependency, especially KafkaProducer
+// Ready checks for the readiness of the API dependency, especially ScoreSink
 func (e *EndpointConfig) Ready(c echo.Context) error {
-	if err := e.KafkaProducer.Start(); err == nil {
+	if e.ScoreSink == nil {
+		return c.JSON(http.StatusNotFound, "YDAER")
+	}
+	// the sink was started with the server, only check that it can still deliver
+	ctx, cancel := context.WithTimeout(c.Request().Context(), readyTimeout)
+	defer cancel()
+	if err := producer.Ready(ctx, e.ScoreSink); err == nil {
 		return nil
 	}
 
 	return c.JSON(http.StatusNotFound, "YDAER")
 }
+
+// OutboxStatus reports the backlog of score events waiting to be replayed from the outbox
+func (e *EndpointConfig) OutboxStatus(c echo.Context) error {
+	if e.Outbox == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Outbox is not enabled")
+	}
+	return c.JSON(http.StatusOK, e.Outbox.Stats())
+}
+
+// PipelineStatus reports the delivery metrics of the asynchronous score pipeline
+func (e *EndpointConfig) PipelineStatus(c echo.Context) error {
+	if e.Pipeline == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Async score pipeline is not enabled")
+	}
+	return c.JSON(http.StatusOK, e.Pipeline.Metrics())
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Topics of the embedded cluster used by the kafka harness
+const (
+	harnessScoreTopic      = "scores"
+	harnessLifecycleTopic  = "lifecycle"
+	harnessDeadLetterTopic = "dlq"
+)
+
+// kafkaHarness runs the game endpoints against a Kafka producer connected to an
+// embedded cluster, so the tests can assert on the records the game produces
+type kafkaHarness struct {
+	t       *testing.T
+	cluster *producer.EmbeddedCluster
+	ec      *EndpointConfig
+	echo    *echo.Echo
+	srv     *httptest.Server
+}
+
+func newKafkaHarness(t *testing.T) *kafkaHarness {
+	cluster, err := producer.StartEmbeddedCluster(0, 1, harnessScoreTopic, harnessLifecycleTopic, harnessDeadLetterTopic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := producer.DefaultProducerConfig()
+	config.Seeds = cluster.Seeds()
+	p, err := producer.NewKafkaScoreProducerWithConfig(config, harnessScoreTopic,
+		producer.WithLifecycleTopic(harnessLifecycleTopic),
+		producer.WithDeadLetterTopic(harnessDeadLetterTopic),
+		producer.WithLogger(logger.Get()))
+	if err != nil {
+		cluster.Close()
+		t.Fatal(err)
+	}
+	if err := p.Start(); err != nil {
+		cluster.Close()
+		t.Fatal(err)
+	}
+
+	ec := &EndpointConfig{
+		config:      models.NewGameConfig(),
+		gameState:   models.NewGameState(),
+		ScoreSink:   p,
+		Lifecycle:   p,
+		DeadLetters: p,
+		Logger:      logger.Get(),
+	}
+	e := echo.New()
+	e.GET("/ws/:player", ec.WebSocket)
+	h := &kafkaHarness{
+		t:       t,
+		cluster: cluster,
+		ec:      ec,
+		echo:    e,
+		srv:     httptest.NewServer(e),
+	}
+	t.Cleanup(func() {
+		h.srv.Close()
+		_ = p.Stop()
+		cluster.Close()
+	})
+	return h
+}
+
+// startGame starts a game session and returns its ID
+func (h *kafkaHarness) startGame() string {
+	rec := httptest.NewRecorder()
+	assert.NoError(h.t, h.ec.StartGame(h.echo.NewContext(httptest.NewRequest(http.MethodPost, "/admin/start", nil), rec)))
+	var status models.GameStatus
+	assert.NoError(h.t, json.Unmarshal(rec.Body.Bytes(), &status))
+	return status.SessionStats.SessionID
+}
+
+// stopGame stops the game session
+func (h *kafkaHarness) stopGame() {
+	rec := httptest.NewRecorder()
+	assert.NoError(h.t, h.ec.StopGame(h.echo.NewContext(httptest.NewRequest(http.MethodPost, "/admin/stop", nil), rec)))
+}
+
+// dial connects player to the game
+func (h *kafkaHarness) dial(player string) *websocket.Conn {
+	wsURL := "ws" + strings.TrimPrefix(h.srv.URL, "http") + "/ws/" + player
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return ws
+}
+
+// consume reads n records of topic from the start, failing after 10 seconds
+func (h *kafkaHarness) consume(topic string, n int) []*kgo.Record {
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(h.cluster.Seeds()...),
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	records := make([]*kgo.Record, 0, n)
+	for len(records) < n && ctx.Err() == nil {
+		records = append(records, client.PollFetches(ctx).Records()...)
+	}
+	if !assert.Len(h.t, records, n, "records of %s", topic) {
+		h.t.FailNow()
+	}
+	return records
+}
+
+func TestWebSocketToKafka(t *testing.T) {
+	h := newKafkaHarness(t)
+	sessionID := h.startGame()
+	assert.NotEmpty(t, sessionID)
+
+	ws := h.dial("tester")
+	pops := []models.GameMessage{
+		{Player: "tester", Character: "Mario", BalloonColor: "green"},
+		{Player: "tester", Character: "Mario", BalloonColor: "red"},
+		{Player: "tester", Character: "Mario", BalloonColor: "teal"},
+	}
+	for _, pop := range pops {
+		assert.NoError(t, ws.WriteJSON(pop))
+		_, _, err := ws.ReadMessage()
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, ws.Close())
+	// wait for the player to leave before stopping the game
+	assert.Eventually(t, func() bool {
+		room := h.ec.defaultRoom()
+		room.mu.RLock()
+		defer room.mu.RUnlock()
+		return len(room.state.CurrentPlayers) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	h.stopGame()
+
+	scores := h.consume(harnessScoreTopic, 2)
+	for i, want := range []int{60, 200} {
+		assert.Equal(t, "tester", string(scores[i].Key))
+		var event models.GameEvent
+		assert.NoError(t, json.Unmarshal(scores[i].Value, &event))
+		assert.Equal(t, want, event.Score)
+		assert.Equal(t, sessionID, event.SessionID)
+		assert.Equal(t, DefaultRoomID, event.RoomID)
+	}
+
+	lifecycle := h.consume(harnessLifecycleTopic, 4)
+	for i, want := range []string{models.GameStarted, models.PlayerJoined, models.PlayerLeft, models.GameStopped} {
+		var event models.LifecycleEvent
+		assert.NoError(t, json.Unmarshal(lifecycle[i].Value, &event))
+		assert.Equal(t, want, event.Type)
+		assert.Equal(t, sessionID, event.SessionID)
+		assert.Equal(t, DefaultRoomID, event.RoomID)
+	}
+
+	letters := h.consume(harnessDeadLetterTopic, 1)
+	var letter models.DeadLetter
+	assert.NoError(t, json.Unmarshal(letters[0].Value, &letter))
+	assert.Equal(t, models.ReasonUnknownColor, letter.Reason)
+	assert.Equal(t, sessionID, letter.SessionID)
+	assert.Equal(t, DefaultRoomID, letter.RoomID)
+}
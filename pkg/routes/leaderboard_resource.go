@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package routes
+
+import (
+	"github.com/kameshsampath/balloon-popper/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+// GetLeaderboard lists the top players of a session, the current one unless the
+// session query parameter is set
+func (e *EndpointConfig) GetLeaderboard(c echo.Context) error {
+	if e.Leaderboard == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Leaderboard is not enabled")
+	}
+	limit := leaderboard.DefaultLimit
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be a positive number")
+		}
+		limit = n
+	}
+	return c.JSON(http.StatusOK, e.Leaderboard.Top(e.leaderboardSession(c), limit))
+}
+
+// GetPlayerScore returns the score and rank of a player in a session
+func (e *EndpointConfig) GetPlayerScore(c echo.Context) error {
+	if e.Leaderboard == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Leaderboard is not enabled")
+	}
+	player := c.Param("player")
+	ps, ok := e.Leaderboard.Player(e.leaderboardSession(c), player)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "No score for player "+player)
+	}
+	return c.JSON(http.StatusOK, ps)
+}
+
+// leaderboardSession picks the session from the query, the default room or the
+// latest session on the leaderboard, e.g. after a restart
+func (e *EndpointConfig) leaderboardSession(c echo.Context) string {
+	if id := c.QueryParam("session"); id != "" {
+		return id
+	}
+	room := e.defaultRoom()
+	room.mu.RLock()
+	id := room.state.SessionID
+	room.mu.RUnlock()
+	if id != "" {
+		return id
+	}
+	return e.Leaderboard.Latest()
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package routes
+
+import (
+	"encoding/json"
+	"github.com/kameshsampath/balloon-popper/pkg/leaderboard"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLeaderboardEndpoints(t *testing.T) {
+	board := leaderboard.New()
+	ec := EndpointConfig{
+		config:      models.NewGameConfig(),
+		gameState:   models.NewGameState(),
+		Leaderboard: board,
+	}
+	ec.gameState.SessionID = "current"
+	for _, event := range []*models.GameEvent{
+		models.NewGameEvent("tom", "red", 100, false),
+		models.NewGameEvent("jerry", "gold", 180, true),
+		models.NewGameEvent("spike", "blue", 75, false),
+	} {
+		event.SessionID = "current"
+		board.Record(event)
+	}
+	old := models.NewGameEvent("tom", "red", 5, false)
+	old.SessionID = "old"
+	board.Record(old)
+
+	e := echo.New()
+	e.GET("/leaderboard", ec.GetLeaderboard)
+	e.GET("/leaderboard/:player", ec.GetPlayerScore)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := get("/leaderboard?limit=2")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var top models.Leaderboard
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &top))
+	assert.Equal(t, "current", top.SessionID)
+	if assert.Len(t, top.Players, 2) {
+		assert.Equal(t, "jerry", top.Players[0].Player)
+		assert.Equal(t, "tom", top.Players[1].Player)
+	}
+
+	rec = get("/leaderboard?session=old")
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &top))
+	assert.Len(t, top.Players, 1)
+
+	assert.Equal(t, http.StatusBadRequest, get("/leaderboard?limit=zero").Code)
+
+	rec = get("/leaderboard/spike")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var ps models.PlayerScore
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ps))
+	assert.Equal(t, 3, ps.Rank)
+	assert.Equal(t, 75, ps.TotalScore)
+
+	assert.Equal(t, http.StatusNotFound, get("/leaderboard/nobody").Code)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+// pauseRoom halts the session of room, the pops are rejected and the time of a
+// timed game stands still until the session is resumed
+func (e *EndpointConfig) pauseRoom(c echo.Context, room *Room) error {
+	room.mu.Lock()
+	if !room.state.IsActive {
+		room.mu.Unlock()
+		return echo.NewHTTPError(http.StatusBadRequest, "No game in progress")
+	}
+	if room.state.IsPaused {
+		room.mu.Unlock()
+		return echo.NewHTTPError(http.StatusBadRequest, "Game is already paused")
+	}
+	now := time.Now().UTC()
+	room.stopTimer()
+	room.stopSpawner()
+	room.state.IsPaused = true
+	room.pausedAt = now
+	frame, gameStatus := e.pauseTransition(room, models.GamePaused, "Game paused", now)
+	room.mu.Unlock()
+	room.publications.flush()
+
+	room.broadcast(frame)
+	return c.JSON(http.StatusOK, gameStatus)
+}
+
+// resumeRoom resumes the paused session of room, restarting the timer of a timed game
+func (e *EndpointConfig) resumeRoom(c echo.Context, room *Room) error {
+	room.mu.Lock()
+	if !room.state.IsActive || !room.state.IsPaused {
+		room.mu.Unlock()
+		return echo.NewHTTPError(http.StatusBadRequest, "Game is not paused")
+	}
+	now := time.Now().UTC()
+	room.pausedFor = room.pausedDuration(now)
+	room.state.IsPaused = false
+	if room.state.DurationSeconds > 0 {
+		e.startTimer(room)
+	}
+	e.startSpawner(room)
+	frame, gameStatus := e.pauseTransition(room, models.GameResumed, "Game resumed", now)
+	room.mu.Unlock()
+	room.publications.flush()
+
+	room.broadcast(frame)
+	return c.JSON(http.StatusOK, gameStatus)
+}
+
+// pauseTransition queues the lifecycle event of a pause or resume and returns the
+// frame telling the players and the status of the session, callers hold the room lock
+func (e *EndpointConfig) pauseTransition(room *Room, eventType, message string, now time.Time) (models.PauseFrame, models.GameStatus) {
+	paused := room.pausedDuration(now).Seconds()
+	gameStatus := models.GameStatus{
+		Message: message,
+		SessionStats: models.SessionStats{
+			SessionID:     room.state.SessionID,
+			StartedAt:     room.state.StartedAt,
+			PausedSeconds: paused,
+		},
+	}
+	event := room.lifecycleEvent(eventType)
+	event.SessionStats = &gameStatus.SessionStats
+	room.publications.enqueue(func() { e.publishLifecycle(event) })
+	return models.PauseFrame{
+		Type:          eventType,
+		SessionID:     room.state.SessionID,
+		At:            now,
+		PausedSeconds: paused,
+	}, gameStatus
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readFrame reads the frames of ws, skipping the time_remaining ticks and the spawned balloons
+func readFrame(t *testing.T, ws *websocket.Conn) map[string]any {
+	for {
+		var frame map[string]any
+		if !assert.NoError(t, ws.ReadJSON(&frame)) || (frame["type"] != "time_remaining" && frame["type"] != "balloon_spawned") {
+			return frame
+		}
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	e, srv, sink := newTimerTest(t)
+
+	assert.Equal(t, http.StatusBadRequest, serve(e, http.MethodPost, "/admin/pause", "").Code)
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/start", `{"duration_seconds":1}`).Code)
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/tester", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close() //nolint:errcheck
+	_ = ws.SetReadDeadline(time.Now().Add(10 * time.Second))
+	pop := models.GameMessage{Player: "tester", Character: "Mario", BalloonColor: "green"}
+
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/pause", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(e, http.MethodPost, "/admin/pause", "").Code)
+	assert.Equal(t, models.GamePaused, readFrame(t, ws)["type"])
+	assert.NoError(t, ws.WriteJSON(pop))
+	frame := readFrame(t, ws)
+	assert.Equal(t, "error", frame["type"])
+	assert.Equal(t, models.CodeGamePaused, frame["code"])
+
+	// the paused game outlives its duration
+	time.Sleep(1200 * time.Millisecond)
+	state := gameStatus(t, e)
+	assert.True(t, state.IsActive)
+	assert.True(t, state.IsPaused)
+	assert.GreaterOrEqual(t, state.PausedSeconds, 1.2)
+	assert.Equal(t, 1, state.RemainingSeconds)
+
+	rec := serve(e, http.MethodPost, "/admin/resume", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resumed models.GameStatus
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resumed))
+	assert.GreaterOrEqual(t, resumed.SessionStats.PausedSeconds, 1.2)
+	assert.Equal(t, http.StatusBadRequest, serve(e, http.MethodPost, "/admin/resume", "").Code)
+	assert.Equal(t, models.GameResumed, readFrame(t, ws)["type"])
+	assert.NoError(t, ws.WriteJSON(pop))
+	assert.Equal(t, "score_update", readFrame(t, ws)["type"])
+
+	// the timer stops the game once the unpaused time is up
+	assert.Eventually(t, func() bool {
+		return !gameStatus(t, e).IsActive
+	}, 5*time.Second, 10*time.Millisecond)
+	var stopped *models.LifecycleEvent
+	for _, event := range sink.LifecycleEvents() {
+		if event.Type == models.GameStopped {
+			stopped = event
+		}
+	}
+	if assert.NotNil(t, stopped) && assert.NotNil(t, stopped.SessionStats) {
+		assert.GreaterOrEqual(t, stopped.SessionStats.PausedSeconds, 1.2)
+		assert.InDelta(t, 1, stopped.SessionStats.DurationSeconds, 0.3)
+	}
+}
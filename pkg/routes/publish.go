@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package routes
+
+import "sync"
+
+// publishQueue keeps the lifecycle events and the session records of a room in the
+// order they happened, so they can be sent after the room lock is released without
+// a slow broker holding up the game
+type publishQueue struct {
+	mu       sync.Mutex
+	pending  []func()
+	flushing bool
+}
+
+// enqueue adds a publication, callers hold the room lock so the queue follows the room state
+func (q *publishQueue) enqueue(publish func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, publish)
+}
+
+// flush runs the pending publications in order, callers must not hold the room lock.
+// When another caller is already flushing, it runs the publications added in the meantime.
+func (q *publishQueue) flush() {
+	q.mu.Lock()
+	if q.flushing {
+		q.mu.Unlock()
+		return
+	}
+	q.flushing = true
+	for len(q.pending) > 0 {
+		publish := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+		publish()
+		q.mu.Lock()
+	}
+	q.flushing = false
+	q.mu.Unlock()
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"errors"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// CreateRoom creates a room with its own game, the body may override the default game config
+func (e *EndpointConfig) CreateRoom(c echo.Context) error {
+	var req models.RoomRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid room request")
+	}
+	room, err := e.addRoom(&req)
+	if errors.Is(err, errJoinCodeTaken) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if e.Logger != nil {
+		e.Logger.Infof("Created room %s with join code %s", room.ID, room.JoinCode)
+	}
+	return c.JSON(http.StatusCreated, room.Info())
+}
+
+// ListRooms lists the rooms, the default room first
+func (e *EndpointConfig) ListRooms(c echo.Context) error {
+	rooms := e.listRooms()
+	infos := make([]models.RoomInfo, 0, len(rooms))
+	for _, room := range rooms {
+		infos = append(infos, room.Info())
+	}
+	return c.JSON(http.StatusOK, infos)
+}
+
+// GetRoom describes the room of the :id path parameter
+func (e *EndpointConfig) GetRoom(c echo.Context) error {
+	room, err := e.pathRoom(c, "id")
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, room.Info())
+}
+
+// StartRoom starts a session of the room of the :id path parameter
+func (e *EndpointConfig) StartRoom(c echo.Context) error {
+	room, err := e.pathRoom(c, "id")
+	if err != nil {
+		return err
+	}
+	return e.startRoom(c, room)
+}
+
+// StopRoom stops the session of the room of the :id path parameter
+func (e *EndpointConfig) StopRoom(c echo.Context) error {
+	room, err := e.pathRoom(c, "id")
+	if err != nil {
+		return err
+	}
+	return e.stopRoom(c, room)
+}
+
+// PauseRoom pauses the session of the room of the :id path parameter
+func (e *EndpointConfig) PauseRoom(c echo.Context) error {
+	room, err := e.pathRoom(c, "id")
+	if err != nil {
+		return err
+	}
+	return e.pauseRoom(c, room)
+}
+
+// ResumeRoom resumes the paused session of the room of the :id path parameter
+func (e *EndpointConfig) ResumeRoom(c echo.Context) error {
+	room, err := e.pathRoom(c, "id")
+	if err != nil {
+		return err
+	}
+	return e.resumeRoom(c, room)
+}
+
+// RoomConfig returns the game config of the room of the :room path parameter
+func (e *EndpointConfig) RoomConfig(c echo.Context) error {
+	room, err := e.pathRoom(c, "room")
+	if err != nil {
+		return err
+	}
+	return e.roomConfig(c, room)
+}
+
+// RoomStatus returns the game state of the room of the :room path parameter
+func (e *EndpointConfig) RoomStatus(c echo.Context) error {
+	room, err := e.pathRoom(c, "room")
+	if err != nil {
+		return err
+	}
+	return e.roomStatus(c, room)
+}
+
+// RoomWebSocket plays in the game of the room of the :room path parameter
+func (e *EndpointConfig) RoomWebSocket(c echo.Context) error {
+	room, err := e.pathRoom(c, "room")
+	if err != nil {
+		return err
+	}
+	return e.play(c, room, c.Param("player"))
+}
+
+// pathRoom finds the room by the ID or join code of the path parameter name
+func (e *EndpointConfig) pathRoom(c echo.Context, name string) (*Room, error) {
+	key := c.Param(name)
+	room := e.findRoom(key)
+	if room == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "Unknown room "+key)
+	}
+	return room, nil
+}
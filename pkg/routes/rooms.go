@@ -0,0 +1,355 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/kameshsampath/balloon-popper/pkg/leaderboard"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"maps"
+	"math/big"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRoomID identifies the room of the /admin/start, /status and /ws/:player endpoints
+const DefaultRoomID = "default"
+
+const (
+	// joinCodeAlphabet leaves out the characters that are easily mistaken for one another, e.g. 0 and O
+	joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	joinCodeLength   = 6
+)
+
+var (
+	joinCodePattern  = regexp.MustCompile(`^[A-Z0-9]{4,12}$`)
+	errJoinCodeTaken = errors.New("join code is already used by another room")
+)
+
+// Room is a game with its own state, config, players and lifecycle
+type Room struct {
+	ID        string
+	JoinCode  string
+	Name      string
+	CreatedAt time.Time
+	mu        sync.RWMutex // For thread-safe state access
+	state     *models.GameState
+	config    *models.GameConfig
+	// overrides are the config changes of a created room, applied again when the base config changes
+	overrides *models.RoomRequest
+	// participants lists every player who joined the current session, including those who left
+	participants []string
+	conns        map[*playerConn]struct{}
+	// scores are the running scores of the players of the current session, the final standings are ranked from them
+	scores map[string]*models.PlayerScore
+	// timerDone stops the timer of a timed session
+	timerDone chan struct{}
+	// pausedAt is when the current pause started, pausedFor the length of the previous pauses of the session
+	pausedAt  time.Time
+	pausedFor time.Duration
+	// spawner tracks the balloons of the session when the server spawns them, spawnDone stops spawning
+	spawner   *spawner
+	spawnDone chan struct{}
+	// publications are the lifecycle events and session records waiting to be sent
+	publications publishQueue
+}
+
+// Info returns a snapshot of the room and its game state
+func (r *Room) Info() models.RoomInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return models.RoomInfo{
+		ID:        r.ID,
+		JoinCode:  r.JoinCode,
+		Name:      r.Name,
+		CreatedAt: r.CreatedAt,
+		Config:    r.config,
+		State:     r.snapshot(),
+	}
+}
+
+// snapshot copies the game state, callers hold the room lock
+func (r *Room) snapshot() models.GameState {
+	state := *r.state
+	state.CurrentPlayers = slices.Clone(r.state.CurrentPlayers)
+	state.PlayerCount = len(r.state.CurrentPlayers)
+	now := time.Now()
+	state.PausedSeconds = r.pausedDuration(now).Seconds()
+	if r.state.IsActive && r.state.DurationSeconds > 0 {
+		state.RemainingSeconds = remainingSeconds(r.endsAt(now), now)
+	}
+	return state
+}
+
+// endsAt is when the timed session of the room is up, the pauses extend the
+// session, callers hold the room lock
+func (r *Room) endsAt(now time.Time) time.Time {
+	return r.state.StartedAt.Add(time.Duration(r.state.DurationSeconds)*time.Second + r.pausedDuration(now))
+}
+
+// pausedDuration is the time the session spent paused until now, callers hold the room lock
+func (r *Room) pausedDuration(now time.Time) time.Duration {
+	if r.state.IsPaused {
+		return r.pausedFor + now.Sub(r.pausedAt)
+	}
+	return r.pausedFor
+}
+
+// connect adds the connection of a player to the room, callers hold the room lock
+func (r *Room) connect(conn *playerConn) {
+	if r.conns == nil {
+		r.conns = make(map[*playerConn]struct{})
+	}
+	r.conns[conn] = struct{}{}
+}
+
+// broadcast writes v to every player connected to the room
+func (r *Room) broadcast(v any) {
+	r.mu.RLock()
+	conns := slices.Collect(maps.Keys(r.conns))
+	r.mu.RUnlock()
+	for _, conn := range conns {
+		// a failed write closes the connection, the read loop of the player then cleans it up
+		_ = conn.writeJSON(v)
+	}
+}
+
+// lifecycleEvent creates an event of the current session of the room, callers hold the room lock
+func (r *Room) lifecycleEvent(eventType string) *models.LifecycleEvent {
+	event := models.NewLifecycleEvent(eventType, r.state.SessionID)
+	event.RoomID = r.ID
+	return event
+}
+
+// sessionRecord describes the current session of the room, callers hold the room lock
+func (r *Room) sessionRecord() models.SessionRecord {
+	record := models.SessionRecord{
+		SessionID:    r.state.SessionID,
+		RoomID:       r.ID,
+		Active:       r.state.IsActive,
+		StartedAt:    r.state.StartedAt,
+		Participants: slices.Clone(r.participants),
+	}
+	if !r.state.IsActive {
+		record.EndedAt = r.state.EndedAt
+		record.DurationSeconds = r.playedDuration().Seconds()
+		record.PausedSeconds = r.pausedFor.Seconds()
+	}
+	return record
+}
+
+// score adds the pop to the running score of the player and returns it, callers hold the room lock
+func (r *Room) score(player string, event *models.GameEvent) models.PlayerScore {
+	ps, ok := r.scores[player]
+	if !ok {
+		ps = &models.PlayerScore{SessionID: r.state.SessionID, Player: player}
+		r.scores[player] = ps
+	}
+	ps.TotalScore += event.Score
+	switch {
+	case event.FavoriteColorBonus:
+		ps.BonusHits++
+	case event.Score < 0:
+		ps.NegativeHits++
+	default:
+		ps.RegularHits++
+	}
+	ps.LastUpdated = event.EventTS
+	return *ps
+}
+
+// standings ranks the players who scored in the session, callers hold the room lock
+func (r *Room) standings() []models.PlayerScore {
+	players := make([]models.PlayerScore, 0, len(r.scores))
+	for _, ps := range r.scores {
+		players = append(players, *ps)
+	}
+	return leaderboard.Rank(players)
+}
+
+// playedDuration is the length of the ended session without its pauses, callers hold the room lock
+func (r *Room) playedDuration() time.Duration {
+	return r.state.EndedAt.Sub(r.state.StartedAt) - r.pausedFor
+}
+
+// initRooms registers the default room backed by the game state and config of
+// the endpoints, callers hold the endpoints lock
+func (e *EndpointConfig) initRooms() {
+	if e.rooms == nil {
+		e.rooms = make(map[string]*Room)
+	}
+	if _, ok := e.rooms[DefaultRoomID]; !ok {
+		e.rooms[DefaultRoomID] = &Room{
+			ID:        DefaultRoomID,
+			CreatedAt: time.Now().UTC(),
+			state:     e.gameState,
+			config:    e.config,
+		}
+	}
+}
+
+// defaultRoom returns the room of the endpoints without a room in their path
+func (e *EndpointConfig) defaultRoom() *Room {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.initRooms()
+	return e.rooms[DefaultRoomID]
+}
+
+// findRoom looks a room up by its ID or its case-insensitive join code
+func (e *EndpointConfig) findRoom(key string) *Room {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.initRooms()
+	if room, ok := e.rooms[key]; ok {
+		return room
+	}
+	code := strings.ToUpper(key)
+	for _, room := range e.rooms {
+		if room.JoinCode != "" && room.JoinCode == code {
+			return room
+		}
+	}
+	return nil
+}
+
+// listRooms returns the rooms in the order they were created, the default room first
+func (e *EndpointConfig) listRooms() []*Room {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.initRooms()
+	rooms := slices.Collect(maps.Values(e.rooms))
+	sort.SliceStable(rooms, func(i, j int) bool {
+		if rooms[i].ID == DefaultRoomID || rooms[j].ID == DefaultRoomID {
+			return rooms[i].ID == DefaultRoomID
+		}
+		return rooms[i].CreatedAt.Before(rooms[j].CreatedAt)
+	})
+	return rooms
+}
+
+// addRoom creates a room with the config overrides of req, generating a join code when none is given
+func (e *EndpointConfig) addRoom(req *models.RoomRequest) (*Room, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.JoinCode))
+	if code != "" && !joinCodePattern.MatchString(code) {
+		return nil, fmt.Errorf("invalid join code %q, must be 4 to 12 letters or digits", req.JoinCode)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	config, err := mergeConfig(e.config, req)
+	if err != nil {
+		return nil, err
+	}
+	e.initRooms()
+	if code != "" && e.joinCodeUsed(code) {
+		return nil, errJoinCodeTaken
+	}
+	for code == "" || e.joinCodeUsed(code) {
+		if code, err = newJoinCode(); err != nil {
+			return nil, err
+		}
+	}
+	room := &Room{
+		ID:        uuid.NewString(),
+		JoinCode:  code,
+		Name:      strings.TrimSpace(req.Name),
+		CreatedAt: time.Now().UTC(),
+		state:     models.NewGameState(),
+		config:    config,
+		overrides: req,
+	}
+	e.rooms[room.ID] = room
+	return room, nil
+}
+
+// joinCodeUsed tells whether a room has the join code, callers hold the endpoints lock
+func (e *EndpointConfig) joinCodeUsed(code string) bool {
+	for _, room := range e.rooms {
+		if room.JoinCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func newJoinCode() (string, error) {
+	var b strings.Builder
+	size := big.NewInt(int64(len(joinCodeAlphabet)))
+	for i := 0; i < joinCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate the join code: %w", err)
+		}
+		b.WriteByte(joinCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// mergeConfig copies base and applies the overrides of req, the colors and
+// character favorites of req are added to or replace those of base
+func mergeConfig(base *models.GameConfig, req *models.RoomRequest) (*models.GameConfig, error) {
+	if base == nil {
+		base = models.NewGameConfig()
+	}
+	config := &models.GameConfig{
+		Colors:             maps.Clone(base.Colors),
+		CharacterFavorites: maps.Clone(base.CharacterFavorites),
+		CharacterNegatives: maps.Clone(base.CharacterNegatives),
+		NegativePenalty:    base.NegativePenalty,
+		BonusProbability:   base.BonusProbability,
+	}
+	if config.CharacterNegatives == nil {
+		config.CharacterNegatives = make(map[string][]string)
+	}
+	for color, score := range req.Colors {
+		if score <= 0 {
+			return nil, fmt.Errorf("invalid score %d for color %q, must be positive", score, color)
+		}
+		config.Colors[color] = score
+	}
+	for character, favorites := range req.CharacterFavorites {
+		if len(favorites) == 0 {
+			return nil, fmt.Errorf("character %q has no favorite colors", character)
+		}
+		config.CharacterFavorites[character] = slices.Clone(favorites)
+	}
+	// a character without negative colors gets no negative balloons
+	for character, negatives := range req.CharacterNegatives {
+		config.CharacterNegatives[character] = slices.Clone(negatives)
+	}
+	if p := req.NegativePenalty; p != nil {
+		config.NegativePenalty = *p
+	}
+	if p := req.BonusProbability; p != nil {
+		if *p < 0 || *p > 1 {
+			return nil, fmt.Errorf("invalid bonus probability %v, must be between 0 and 1", *p)
+		}
+		config.BonusProbability = *p
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
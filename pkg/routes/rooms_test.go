@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRooms(t *testing.T) {
+	sink := producer.NewMemoryScoreSink()
+	ec := &EndpointConfig{
+		config:    models.NewGameConfig(),
+		gameState: models.NewGameState(),
+		ScoreSink: sink,
+		Lifecycle: sink,
+		Logger:    logger.Get(),
+	}
+	e := echo.New()
+	e.POST("/admin/rooms", ec.CreateRoom)
+	e.GET("/admin/rooms", ec.ListRooms)
+	e.POST("/admin/rooms/:id/start", ec.StartRoom)
+	e.GET("/rooms/:room/status", ec.RoomStatus)
+	e.GET("/status", ec.GameStatus)
+	e.GET("/ws/:room/:player", ec.RoomWebSocket)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/admin/rooms", `{"name":"Screen 1","join_code":"scr1","colors":{"red":500}}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var room models.RoomInfo
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &room))
+	assert.Equal(t, "SCR1", room.JoinCode)
+	assert.Equal(t, 500, room.Config.Colors["red"])
+	assert.Equal(t, 75, room.Config.Colors["blue"])
+	assert.Equal(t, 100, ec.config.Colors["red"], "the default config is not changed")
+
+	rec = do(http.MethodPost, "/admin/rooms", `{"join_code":"SCR1"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec = do(http.MethodPost, "/admin/rooms", `{"bonus_probability":2}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	// a favorite color cannot be a negative color of the same character
+	rec = do(http.MethodPost, "/admin/rooms", `{"character_negatives":{"Mario":["red"]}}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = do(http.MethodPost, "/admin/rooms", `{}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var other models.RoomInfo
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &other))
+	assert.Len(t, other.JoinCode, joinCodeLength)
+
+	var rooms []models.RoomInfo
+	assert.NoError(t, json.Unmarshal(do(http.MethodGet, "/admin/rooms", "").Body.Bytes(), &rooms))
+	if assert.Len(t, rooms, 3) {
+		assert.Equal(t, DefaultRoomID, rooms[0].ID)
+		assert.Equal(t, room.ID, rooms[1].ID)
+	}
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/admin/rooms/nope/start", "").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/admin/rooms/"+room.ID+"/start", "").Code)
+
+	// the room plays its own game while the default room and the other room are idle
+	var state models.GameState
+	assert.NoError(t, json.Unmarshal(do(http.MethodGet, "/rooms/scr1/status", "").Body.Bytes(), &state))
+	assert.True(t, state.IsActive)
+	assert.NoError(t, json.Unmarshal(do(http.MethodGet, "/status", "").Body.Bytes(), &state))
+	assert.False(t, state.IsActive)
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/"+other.ID+"/tester", nil)
+	assert.Error(t, err)
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	}
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/scr1/tester", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close() //nolint:errcheck
+	assert.NoError(t, ws.WriteJSON(models.GameMessage{Player: "tester", Character: "Tom", BalloonColor: "red"}))
+	var update models.ScoreUpdate
+	assert.NoError(t, ws.ReadJSON(&update))
+	if assert.NotNil(t, update.Event) {
+		assert.Equal(t, 500, update.Event.Score)
+		assert.Equal(t, room.ID, update.Event.RoomID)
+	}
+
+	for _, event := range sink.LifecycleEvents() {
+		assert.Equal(t, room.ID, event.RoomID)
+	}
+}
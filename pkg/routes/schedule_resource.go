@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// ListSchedules lists the scheduled games, the next one first
+func (e *EndpointConfig) ListSchedules(c echo.Context) error {
+	if e.Scheduler == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Scheduler is not enabled")
+	}
+	return c.JSON(http.StatusOK, e.Scheduler.List())
+}
+
+// CreateSchedule schedules timed games in a room, the default room when room_id is not set
+func (e *EndpointConfig) CreateSchedule(c echo.Context) error {
+	if e.Scheduler == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Scheduler is not enabled")
+	}
+	var schedule models.Schedule
+	if err := c.Bind(&schedule); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid schedule")
+	}
+	if err := validateDuration(schedule.DurationSeconds); err != nil {
+		return err
+	}
+	room := e.defaultRoom()
+	if schedule.RoomID != "" {
+		if room = e.findRoom(schedule.RoomID); room == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Unknown room "+schedule.RoomID)
+		}
+	}
+	schedule.RoomID = room.ID
+	schedule, err := e.Scheduler.Add(schedule)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, schedule)
+}
+
+// DeleteSchedule removes the :id schedule, the game it already started keeps running
+func (e *EndpointConfig) DeleteSchedule(c echo.Context) error {
+	if e.Scheduler == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Scheduler is not enabled")
+	}
+	id := c.Param("id")
+	ok, err := e.Scheduler.Remove(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "Unknown schedule "+id)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CheckScheduled verifies the room of a schedule exists, the created rooms only live in memory
+// so their schedules are orphaned by a restart
+func (e *EndpointConfig) CheckScheduled(roomID string) error {
+	if e.findRoom(roomID) == nil {
+		return fmt.Errorf("unknown room %s", roomID)
+	}
+	return nil
+}
+
+// StartScheduled starts a game for the scheduler, the same way as the start endpoints
+func (e *EndpointConfig) StartScheduled(roomID string, durationSeconds int) error {
+	room := e.findRoom(roomID)
+	if room == nil {
+		return fmt.Errorf("unknown room %s", roomID)
+	}
+	_, err := e.beginSession(room, durationSeconds)
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return fmt.Errorf("%v", he.Message)
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
+	"github.com/kameshsampath/balloon-popper/pkg/scheduler"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestScheduledGame(t *testing.T) {
+	sink := producer.NewMemoryScoreSink()
+	ec := &EndpointConfig{
+		config:    models.NewGameConfig(),
+		gameState: models.NewGameState(),
+		Lifecycle: sink,
+		Logger:    logger.Get(),
+	}
+	s, err := scheduler.New("", ec.StartScheduled, logger.Get())
+	if !assert.NoError(t, err) {
+		return
+	}
+	s.Start()
+	defer s.Stop()
+	ec.Scheduler = s
+
+	e := echo.New()
+	e.GET("/status", ec.GameStatus)
+	e.GET("/admin/schedules", ec.ListSchedules)
+	e.POST("/admin/schedules", ec.CreateSchedule)
+	e.DELETE("/admin/schedules/:id", ec.DeleteSchedule)
+
+	at := time.Now().Add(200 * time.Millisecond).Format(time.RFC3339Nano)
+	rec := serve(e, http.MethodPost, "/admin/schedules", fmt.Sprintf(`{"at":%q,"duration_seconds":1}`, at))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, http.StatusBadRequest, serve(e, http.MethodPost, "/admin/schedules", `{"cron":"@hourly","duration_seconds":1,"room_id":"nope"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(e, http.MethodPost, "/admin/schedules", `{"cron":"@hourly"}`).Code)
+	rec = serve(e, http.MethodPost, "/admin/schedules", `{"cron":"*/30 * * * *","duration_seconds":600}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var every models.Schedule
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &every))
+	assert.Equal(t, DefaultRoomID, every.RoomID)
+
+	var schedules []models.Schedule
+	assert.NoError(t, json.Unmarshal(serve(e, http.MethodGet, "/admin/schedules", "").Body.Bytes(), &schedules))
+	assert.Len(t, schedules, 2)
+
+	// the scheduled game starts and stops by itself
+	assert.Eventually(t, func() bool {
+		return gameStatus(t, e).IsActive
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return !gameStatus(t, e).IsActive
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, countStopped(sink))
+
+	assert.Equal(t, http.StatusNoContent, serve(e, http.MethodDelete, "/admin/schedules/"+every.ID, "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(e, http.MethodDelete, "/admin/schedules/"+every.ID, "").Code)
+	assert.NoError(t, json.Unmarshal(serve(e, http.MethodGet, "/admin/schedules", "").Body.Bytes(), &schedules))
+	assert.Empty(t, schedules)
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/sessions"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+// ListSessions lists the recorded sessions, latest first, optionally of a room
+// or played between the from and to RFC3339 query parameters
+func (e *EndpointConfig) ListSessions(c echo.Context) error {
+	if e.Sessions == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Session history is not enabled")
+	}
+	filter := sessions.Filter{RoomID: c.QueryParam("room")}
+	for name, t := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		v := c.QueryParam(name)
+		if v == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, name+" must be an RFC3339 time, e.g. 2025-03-01T14:00:00Z")
+		}
+		*t = parsed
+	}
+	return c.JSON(http.StatusOK, e.Sessions.List(filter))
+}
+
+// GetSession returns the record of the :id session
+func (e *EndpointConfig) GetSession(c echo.Context) error {
+	if e.Sessions == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Session history is not enabled")
+	}
+	id := c.Param("id")
+	record, ok := e.Sessions.Get(id)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "Unknown session "+id)
+	}
+	return c.JSON(http.StatusOK, record)
+}
+
+// recordSession saves the session record when a session store is configured,
+// callers queue it on the room publications so the records are saved in order
+func (e *EndpointConfig) recordSession(record models.SessionRecord) {
+	if e.Sessions == nil {
+		return
+	}
+	if err := e.Sessions.Save(record); err != nil && e.Logger != nil {
+		e.Logger.Warnf("Failed to save the record of session %s: %v", record.SessionID, err)
+	}
+}
+
+// SessionRunning tells whether the session is the active one of its room, the sessions
+// recorded as active by a previous run are not as the rooms only live in memory
+func (e *EndpointConfig) SessionRunning(roomID, sessionID string) bool {
+	if roomID == "" {
+		roomID = DefaultRoomID
+	}
+	room := e.findRoom(roomID)
+	if room == nil {
+		return false
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	return room.state.IsActive && room.state.SessionID == sessionID
+}
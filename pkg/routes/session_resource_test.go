@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/leaderboard"
+	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
+	"github.com/kameshsampath/balloon-popper/pkg/sessions"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionHistory(t *testing.T) {
+	store, err := sessions.Open(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+	board := leaderboard.New()
+	ec := &EndpointConfig{
+		config:      models.NewGameConfig(),
+		gameState:   models.NewGameState(),
+		ScoreSink:   leaderboard.NewTapScoreSink(producer.NewMemoryScoreSink(), board),
+		Leaderboard: board,
+		Sessions:    store,
+		Logger:      logger.Get(),
+	}
+	e := echo.New()
+	e.POST("/admin/start", ec.StartGame)
+	e.POST("/admin/stop", ec.StopGame)
+	e.GET("/ws/:player", ec.WebSocket)
+	e.GET("/sessions", ec.ListSessions)
+	e.GET("/sessions/:id", ec.GetSession)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	get := func(path string, v any) int {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if v != nil && rec.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+		}
+		return rec.Code
+	}
+	play := func() string {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/start", nil))
+		var status models.GameStatus
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+
+		ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/tester", nil)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		assert.NoError(t, ws.WriteJSON(models.GameMessage{Player: "tester", Character: "Mario", BalloonColor: "red"}))
+		_, _, err = ws.ReadMessage()
+		assert.NoError(t, err)
+		assert.NoError(t, ws.Close())
+		// the player left before the game stopped and is still a participant
+		assert.Eventually(t, func() bool {
+			room := ec.defaultRoom()
+			room.mu.RLock()
+			defer room.mu.RUnlock()
+			return len(room.state.CurrentPlayers) == 0
+		}, 5*time.Second, 10*time.Millisecond)
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/admin/stop", nil))
+		return status.SessionStats.SessionID
+	}
+
+	first := play()
+	second := play()
+	assert.NotEqual(t, first, second)
+
+	var record models.SessionRecord
+	assert.Equal(t, http.StatusOK, get("/sessions/"+first, &record))
+	assert.Equal(t, DefaultRoomID, record.RoomID)
+	assert.False(t, record.Active)
+	assert.False(t, record.EndedAt.Before(record.StartedAt))
+	assert.Equal(t, []string{"tester"}, record.Participants)
+	if assert.Len(t, record.Standings, 1) {
+		assert.Equal(t, 200, record.Standings[0].TotalScore)
+	}
+
+	var records []models.SessionRecord
+	assert.Equal(t, http.StatusOK, get("/sessions", &records))
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, second, records[0].SessionID)
+	}
+	assert.Equal(t, http.StatusOK, get("/sessions?to="+record.StartedAt.Add(-time.Second).Format(time.RFC3339), &records))
+	assert.Empty(t, records)
+	assert.Equal(t, http.StatusBadRequest, get("/sessions?from=2pm", nil))
+	assert.Equal(t, http.StatusNotFound, get("/sessions/nope", nil))
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/kameshsampath/balloon-popper/pkg/anticheat"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Where the balloons are spawned
+const (
+	SpawningServer = "server"
+	SpawningClient = "client"
+)
+
+// The balloon mix and the difficulty progression the browser used to spawn
+const (
+	negativeProbability = 0.15
+	levelDuration       = 20 * time.Second
+	baseSpawnInterval   = 2 * time.Second
+	spawnIntervalStep   = 250 * time.Millisecond
+	baseBalloonSpeed    = 2.0
+	maxSpeedMultiplier  = 4.0
+	speedIncreaseRate   = 0.3
+	// balloonLifetime is how long a balloon of the base speed can be popped, the faster balloons expire sooner
+	balloonLifetime = 10 * time.Second
+	// expiredRetention is how long the expired balloons are remembered to tell them from unknown ones
+	expiredRetention = time.Minute
+)
+
+type spawnedBalloon struct {
+	models.Balloon
+	player    string
+	spawnedAt time.Time
+	popped    bool
+}
+
+// spawner creates the balloons of a session and tracks them until they are popped or expire
+type spawner struct {
+	mu       sync.Mutex
+	balloons map[string]*spawnedBalloon
+	// favorites counts the bonus balloons spawned for each player
+	favorites map[string]int
+}
+
+func newSpawner() *spawner {
+	return &spawner{
+		balloons:  make(map[string]*spawnedBalloon),
+		favorites: make(map[string]int),
+	}
+}
+
+// spawn creates a balloon for player playing character, at the speed of level
+func (s *spawner) spawn(config *models.GameConfig, player, character string, level int, now time.Time) models.Balloon {
+	favorites := config.CharacterFavorites[character]
+	negatives := config.CharacterNegatives[character]
+	var regular []string
+	for color := range config.Colors {
+		if !contains(favorites, color) && !contains(negatives, color) {
+			regular = append(regular, color)
+		}
+	}
+
+	kind, colors := models.BalloonRegular, regular
+	if r := rand.Float64(); len(negatives) > 0 && r < negativeProbability {
+		kind, colors = models.BalloonNegative, negatives
+	} else if len(favorites) > 0 && (r < negativeProbability+config.BonusProbability || len(regular) == 0) {
+		kind, colors = models.BalloonBonus, favorites
+	}
+
+	variation := 0.75 + rand.Float64()*(0.5+min(0.5, float64(level-1)*0.1))
+	speed := baseBalloonSpeed * min(maxSpeedMultiplier, 1+float64(level-1)*speedIncreaseRate) * variation
+	if kind == models.BalloonNegative {
+		speed *= 0.6 - min(0.2, float64(level-1)*0.04)
+	} else if level > 2 && rand.Float64() < 0.1 {
+		speed *= 1.5
+	}
+
+	b := &spawnedBalloon{
+		Balloon: models.Balloon{
+			ID:        uuid.NewString(),
+			Color:     colors[rand.IntN(len(colors))],
+			Kind:      kind,
+			Speed:     speed,
+			X:         rand.Float64(),
+			ExpiresAt: now.Add(time.Duration(float64(balloonLifetime) * baseBalloonSpeed / speed)).UTC(),
+		},
+		player:    player,
+		spawnedAt: now,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, old := range s.balloons {
+		if now.Sub(old.ExpiresAt) > expiredRetention {
+			delete(s.balloons, id)
+		}
+	}
+	s.balloons[b.ID] = b
+	if kind == models.BalloonBonus {
+		s.favorites[player]++
+	}
+	return b.Balloon
+}
+
+// spawned tells the anti-cheat the number of bonus balloons spawned for player during the
+// session and how long after its spawn the balloon id was popped
+func (s *spawner) spawned(player, id string, now time.Time) *anticheat.Spawned {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	spawned := &anticheat.Spawned{Favorites: s.favorites[player]}
+	if b, ok := s.balloons[id]; ok {
+		spawned.Reaction = now.Sub(b.spawnedAt)
+	}
+	return spawned
+}
+
+// pop marks the balloon id of player as popped, it returns the reason code and
+// why the pop cannot be scored, or an empty reason
+func (s *spawner) pop(id, player string, now time.Time) (models.Balloon, string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.balloons[id]
+	if !ok || b.player != player {
+		return models.Balloon{}, models.ReasonUnknownBalloon, fmt.Sprintf("unknown balloon %q", id)
+	}
+	if b.popped {
+		return models.Balloon{}, models.ReasonPoppedBalloon, fmt.Sprintf("balloon %q was already popped", id)
+	}
+	if now.After(b.ExpiresAt) {
+		return models.Balloon{}, models.ReasonExpiredBalloon, fmt.Sprintf("balloon %q expired at %s", id, b.ExpiresAt.Format(time.RFC3339Nano))
+	}
+	b.popped = true
+	return b.Balloon, "", ""
+}
+
+// unpop makes the balloon id poppable again, for a pop the anti-cheat did not let through
+func (s *spawner) unpop(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.balloons[id]; ok {
+		b.popped = false
+	}
+}
+
+// balloonScore doubles the score of the color for the bonus balloons and takes the
+// penalty off for the negative ones, it returns whether the pop earned the favorite color bonus
+func balloonScore(config *models.GameConfig, b models.Balloon) (int, bool) {
+	score := config.Colors[b.Color]
+	switch b.Kind {
+	case models.BalloonBonus:
+		return score * 2, true
+	case models.BalloonNegative:
+		return -penalty(config, b.Color), false
+	}
+	return score, false
+}
+
+// penalty is the number of points a negative hit of color takes off
+func penalty(config *models.GameConfig, color string) int {
+	rules := config.NegativePenalty
+	return max(rules.Min, int(math.Round(float64(config.Colors[color])*rules.Ratio)))
+}
+
+// spawnInterval shortens the time between two balloons as the level goes up
+func spawnInterval(level int) time.Duration {
+	return max(models.MinSpawnInterval, baseSpawnInterval-time.Duration(level-1)*spawnIntervalStep)
+}
+
+// level goes up every levelDuration of play, callers hold the room lock
+func (r *Room) level(now time.Time) int {
+	played := now.Sub(r.state.StartedAt) - r.pausedDuration(now)
+	return 1 + int(played/levelDuration)
+}
+
+// startSpawner spawns the balloons of the players of room until the session is
+// paused or stopped, callers hold the room lock
+func (e *EndpointConfig) startSpawner(room *Room) {
+	if room.spawner == nil {
+		return
+	}
+	done := make(chan struct{})
+	room.spawnDone = done
+	go e.runSpawner(room, room.spawner, done)
+}
+
+// stopSpawner stops spawning the balloons of the session, callers hold the room lock
+func (r *Room) stopSpawner() {
+	if r.spawnDone != nil {
+		close(r.spawnDone)
+		r.spawnDone = nil
+	}
+}
+
+func (e *EndpointConfig) runSpawner(room *Room, sp *spawner, done <-chan struct{}) {
+	for {
+		now := time.Now()
+		room.mu.RLock()
+		level := room.level(now)
+		config := room.config
+		conns := slices.Collect(maps.Keys(room.conns))
+		room.mu.RUnlock()
+		for _, conn := range conns {
+			spawnFor(conn, sp, config, level, now)
+		}
+
+		timer := time.NewTimer(spawnInterval(level))
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// spawnFor sends a new balloon to the player of conn
+func spawnFor(conn *playerConn, sp *spawner, config *models.GameConfig, level int, now time.Time) {
+	// a failed write closes the connection, the read loop of the player then cleans it up
+	_ = conn.writeJSON(models.BalloonSpawned{
+		Type:    "balloon_spawned",
+		Balloon: sp.spawn(config, conn.player, conn.character, level, now),
+	})
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpawner(t *testing.T) {
+	config := models.NewGameConfig()
+	sp := newSpawner()
+	now := time.Now()
+
+	for range 200 {
+		b := sp.spawn(config, "tester", "Mario", 3, now)
+		assert.True(t, b.ExpiresAt.After(now))
+		assert.True(t, b.X >= 0 && b.X < 1)
+		switch b.Kind {
+		case models.BalloonBonus:
+			assert.Contains(t, []string{"red", "blue"}, b.Color)
+		case models.BalloonNegative:
+			assert.Contains(t, []string{"maroon", "purple"}, b.Color)
+		default:
+			assert.NotContains(t, []string{"red", "blue", "maroon", "purple"}, b.Color)
+		}
+	}
+
+	b := sp.spawn(config, "tester", "Mario", 1, now)
+	_, reason, _ := sp.pop(b.ID, "other", now)
+	assert.Equal(t, models.ReasonUnknownBalloon, reason)
+	_, reason, _ = sp.pop("nope", "tester", now)
+	assert.Equal(t, models.ReasonUnknownBalloon, reason)
+	popped, reason, _ := sp.pop(b.ID, "tester", now)
+	assert.Empty(t, reason)
+	assert.Equal(t, b, popped)
+	_, reason, _ = sp.pop(b.ID, "tester", now)
+	assert.Equal(t, models.ReasonPoppedBalloon, reason)
+
+	b = sp.spawn(config, "tester", "Mario", 1, now)
+	_, reason, _ = sp.pop(b.ID, "tester", b.ExpiresAt.Add(time.Millisecond))
+	assert.Equal(t, models.ReasonExpiredBalloon, reason)
+
+	score, bonus := balloonScore(config, models.Balloon{Color: "red", Kind: models.BalloonBonus})
+	assert.Equal(t, 200, score)
+	assert.True(t, bonus)
+	score, _ = balloonScore(config, models.Balloon{Color: "maroon", Kind: models.BalloonNegative})
+	assert.Equal(t, -40, score)
+	// the colors scoring nothing take the minimum penalty
+	config.Colors["black"] = 0
+	score, _ = balloonScore(config, models.Balloon{Color: "black", Kind: models.BalloonNegative})
+	assert.Equal(t, -10, score)
+	score, _ = balloonScore(config, models.Balloon{Color: "green", Kind: models.BalloonRegular})
+	assert.Equal(t, 60, score)
+
+	assert.Equal(t, 2*time.Second, spawnInterval(1))
+	assert.Equal(t, models.MinSpawnInterval, spawnInterval(20))
+}
+
+func TestServerSpawning(t *testing.T) {
+	ec := &EndpointConfig{
+		config:         models.NewGameConfig(),
+		gameState:      models.NewGameState(),
+		Logger:         logger.Get(),
+		ServerSpawning: true,
+	}
+	e := echo.New()
+	e.POST("/admin/start", ec.StartGame)
+	e.GET("/ws/:player", ec.WebSocket)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/start", "").Code)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/tester"
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?character=Nobody", nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+
+	ws, _, err := websocket.DefaultDialer.Dial(url+"?character=Mario", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close() //nolint:errcheck
+	_ = ws.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	var spawned models.BalloonSpawned
+	assert.NoError(t, ws.ReadJSON(&spawned))
+	assert.Equal(t, "balloon_spawned", spawned.Type)
+
+	pop := models.GameMessage{Type: "pop", BalloonID: spawned.Balloon.ID}
+	assert.NoError(t, ws.WriteJSON(pop))
+	frame := readFrame(t, ws)
+	assert.Equal(t, "score_update", frame["type"])
+	event := frame["event"].(map[string]any)
+	assert.Equal(t, spawned.Balloon.Color, event["balloon_color"])
+
+	assert.NoError(t, ws.WriteJSON(pop))
+	assert.Equal(t, models.ReasonPoppedBalloon, readFrame(t, ws)["code"])
+
+	// the clients can no longer claim the color they popped
+	assert.NoError(t, ws.WriteJSON(models.GameMessage{Player: "tester", Character: "Mario", BalloonColor: "red"}))
+	assert.Equal(t, models.ReasonUnknownBalloon, readFrame(t, ws)["code"])
+}
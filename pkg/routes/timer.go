@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// maxGameDuration bounds the length of a timed game
+	maxGameDuration = 24 * time.Hour
+	// defaultTickInterval is how often the players of a timed game are told the remaining time
+	defaultTickInterval = time.Second
+	// writeWait bounds the time a write to a player may take
+	writeWait = 5 * time.Second
+)
+
+// playerConn serializes the writes to the WebSocket of a player, as the
+// connection supports a single concurrent writer
+type playerConn struct {
+	mu        sync.Mutex
+	ws        *websocket.Conn
+	player    string
+	character string
+}
+
+func (p *playerConn) writeJSON(v any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_ = p.ws.SetWriteDeadline(time.Now().Add(writeWait))
+	return p.ws.WriteJSON(v)
+}
+
+// remainingSeconds rounds the time left until endsAt up to whole seconds
+func remainingSeconds(endsAt, now time.Time) int {
+	return max(0, int(math.Ceil(endsAt.Sub(now).Seconds())))
+}
+
+// startTimer stops the timed session of room when its time is up, callers hold the room lock
+func (e *EndpointConfig) startTimer(room *Room) {
+	done := make(chan struct{})
+	room.timerDone = done
+	interval := e.tickInterval
+	if interval <= 0 {
+		interval = defaultTickInterval
+	}
+	go e.runTimer(room, room.state.SessionID, room.endsAt(time.Now()), interval, done)
+}
+
+// stopTimer stops the timer of the session of room, if any, callers hold the room lock
+func (r *Room) stopTimer() {
+	if r.timerDone != nil {
+		close(r.timerDone)
+		r.timerDone = nil
+	}
+}
+
+// runTimer tells the players of room the remaining time of the session every interval until
+// it is up, or until the session is stopped and done closed
+func (e *EndpointConfig) runTimer(room *Room, sessionID string, endsAt time.Time, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	timer := time.NewTimer(time.Until(endsAt))
+	defer timer.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			room.broadcast(models.TimeRemaining{
+				Type:             "time_remaining",
+				SessionID:        sessionID,
+				RemainingSeconds: remainingSeconds(endsAt, now),
+				EndsAt:           endsAt,
+			})
+		case <-timer.C:
+			// the wall clock may have gone back since the session started, it then ends later
+			remaining := e.expire(room, sessionID, endsAt)
+			if remaining <= 0 {
+				return
+			}
+			timer.Reset(remaining)
+		}
+	}
+}
+
+// expire stops the session unless it was already stopped, manually or by another
+// timer, or was paused in the meantime. It returns the time left when the session
+// is not over yet by the wall clock, or zero.
+func (e *EndpointConfig) expire(room *Room, sessionID string, endsAt time.Time) time.Duration {
+	room.mu.Lock()
+	if !room.state.IsActive || room.state.SessionID != sessionID || room.state.IsPaused {
+		room.mu.Unlock()
+		return 0
+	}
+	now := time.Now()
+	if remaining := room.endsAt(now).Sub(now); remaining > 0 {
+		room.mu.Unlock()
+		return remaining
+	}
+	e.endSession(room)
+	room.mu.Unlock()
+	room.publications.flush()
+
+	if e.Logger != nil {
+		e.Logger.Infof("Time is up for session %s of room %s", sessionID, room.ID)
+	}
+	room.broadcast(models.TimeRemaining{
+		Type:      "time_remaining",
+		SessionID: sessionID,
+		EndsAt:    endsAt,
+	})
+	return 0
+}
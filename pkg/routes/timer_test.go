@@ -0,0 +1,170 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/producer"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTimerTest serves the game endpoints of the default room with the ticks sped up
+func newTimerTest(t *testing.T) (*echo.Echo, *httptest.Server, *producer.MemoryScoreSink) {
+	sink := producer.NewMemoryScoreSink()
+	ec := &EndpointConfig{
+		config:       models.NewGameConfig(),
+		gameState:    models.NewGameState(),
+		Lifecycle:    sink,
+		Logger:       logger.Get(),
+		tickInterval: 50 * time.Millisecond,
+	}
+	e := echo.New()
+	e.POST("/admin/start", ec.StartGame)
+	e.POST("/admin/stop", ec.StopGame)
+	e.POST("/admin/pause", ec.PauseGame)
+	e.POST("/admin/resume", ec.ResumeGame)
+	e.GET("/status", ec.GameStatus)
+	e.GET("/ws/:player", ec.WebSocket)
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+	return e, srv, sink
+}
+
+func serve(e *echo.Echo, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func gameStatus(t *testing.T, e *echo.Echo) models.GameState {
+	var state models.GameState
+	assert.NoError(t, json.Unmarshal(serve(e, http.MethodGet, "/status", "").Body.Bytes(), &state))
+	return state
+}
+
+func countStopped(sink *producer.MemoryScoreSink) int {
+	n := 0
+	for _, event := range sink.LifecycleEvents() {
+		if event.Type == models.GameStopped {
+			n++
+		}
+	}
+	return n
+}
+
+func TestTimedGame(t *testing.T) {
+	e, srv, sink := newTimerTest(t)
+
+	assert.Equal(t, http.StatusBadRequest, serve(e, http.MethodPost, "/admin/start", `{"duration_seconds":-1}`).Code)
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/start", `{"duration_seconds":1}`).Code)
+	state := gameStatus(t, e)
+	assert.True(t, state.IsActive)
+	assert.Equal(t, 1, state.DurationSeconds)
+	assert.Equal(t, 1, state.RemainingSeconds)
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/tester", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close() //nolint:errcheck
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	ticks := 0
+	for {
+		var tick models.TimeRemaining
+		if !assert.NoError(t, ws.ReadJSON(&tick)) {
+			return
+		}
+		assert.Equal(t, "time_remaining", tick.Type)
+		ticks++
+		if tick.RemainingSeconds == 0 {
+			break
+		}
+	}
+	assert.Greater(t, ticks, 1)
+
+	assert.False(t, gameStatus(t, e).IsActive)
+	assert.Equal(t, http.StatusBadRequest, serve(e, http.MethodPost, "/admin/stop", "").Code)
+	assert.Equal(t, 1, countStopped(sink))
+}
+
+func TestTimedGameManualStop(t *testing.T) {
+	e, _, sink := newTimerTest(t)
+
+	// the timer of a stopped session must not stop the next session
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/start", `{"duration_seconds":1}`).Code)
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/stop", "").Code)
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/start", "").Code)
+	time.Sleep(1200 * time.Millisecond)
+	state := gameStatus(t, e)
+	assert.True(t, state.IsActive)
+	assert.Zero(t, state.RemainingSeconds)
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/stop", "").Code)
+
+	// manual stops racing the timer stop the session once
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/start", `{"duration_seconds":1}`).Code)
+	time.Sleep(990 * time.Millisecond)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve(e, http.MethodPost, "/admin/stop", "")
+		}()
+	}
+	wg.Wait()
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, gameStatus(t, e).IsActive)
+	assert.Equal(t, 3, countStopped(sink))
+}
+
+func TestTimedGameWallClockBack(t *testing.T) {
+	ec := &EndpointConfig{
+		config:       models.NewGameConfig(),
+		gameState:    models.NewGameState(),
+		Logger:       logger.Get(),
+		tickInterval: 50 * time.Millisecond,
+	}
+	e := echo.New()
+	e.POST("/admin/start", ec.StartGame)
+	assert.Equal(t, http.StatusOK, serve(e, http.MethodPost, "/admin/start", `{"duration_seconds":1}`).Code)
+
+	// the wall clock goes back half a second, the timer fires before the session is over by the wall clock
+	room := ec.defaultRoom()
+	room.mu.Lock()
+	room.state.StartedAt = room.state.StartedAt.Add(500 * time.Millisecond)
+	room.mu.Unlock()
+
+	assert.Eventually(t, func() bool {
+		room.mu.RLock()
+		defer room.mu.RUnlock()
+		return !room.state.IsActive
+	}, 5*time.Second, 20*time.Millisecond, "the game stops once the wall clock catches up")
+}
@@ -18,11 +18,17 @@
 package routes
 
 import (
+	"context"
 	"fmt"
 	"github.com/gorilla/websocket"
+	"github.com/kameshsampath/balloon-popper/pkg/anticheat"
+	"github.com/kameshsampath/balloon-popper/pkg/leaderboard"
 	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/kameshsampath/balloon-popper/pkg/outbox"
 	"github.com/kameshsampath/balloon-popper/pkg/producer"
+	"github.com/kameshsampath/balloon-popper/pkg/scheduler"
 	"github.com/kameshsampath/balloon-popper/pkg/security"
+	"github.com/kameshsampath/balloon-popper/pkg/sessions"
 	"go.uber.org/zap"
 	"net/http"
 	"sync"
@@ -31,14 +37,33 @@ import (
 
 // EndpointConfig is the marker interface for defining routes
 type EndpointConfig struct {
-	Manager       *security.JWTManager
-	mu            sync.RWMutex // For thread-safe gameState access
-	gameState     *models.GameState
-	config        *models.GameConfig
-	KafkaProducer *producer.KafkaScoreProducer
-	upgrader      websocket.Upgrader
-	Users         []models.UserCredentials
-	Logger        *zap.SugaredLogger
+	Manager     *security.JWTManager
+	mu          sync.RWMutex       // For thread-safe rooms access
+	gameState   *models.GameState  // state of the default room
+	config      *models.GameConfig // config of the default room, the base of the other rooms
+	rooms       map[string]*Room
+	configMu    sync.Mutex // serializes the config changes, so the rooms get the config of the same change
+	configFile  string     // the watched game config file, the only source of the config changes when set
+	ScoreSink   producer.ScoreSink
+	Outbox      *outbox.Outbox
+	Pipeline    *producer.AsyncScorePipeline
+	Leaderboard *leaderboard.Leaderboard
+	Lifecycle   producer.LifecycleSink
+	Results     producer.SessionResultsSink
+	DeadLetters producer.DeadLetterSink
+	Sessions    *sessions.Store
+	Scheduler   *scheduler.Scheduler
+	AntiCheat   *anticheat.Guard
+	upgrader    websocket.Upgrader
+	Users       []models.UserCredentials
+	Logger      *zap.SugaredLogger
+
+	// ServerSpawning spawns the balloons on the server and scores the pops by balloon ID,
+	// otherwise the clients spawn the balloons and claim the color they popped
+	ServerSpawning bool
+
+	// tickInterval is how often the players of a timed game are told the remaining time
+	tickInterval time.Duration
 }
 
 // NewEndpoints gives handle to REST EndpointConfig
@@ -79,6 +104,32 @@ func NewEndpoints(privateKeyFile string, passphrase string) (*EndpointConfig, er
 	}, nil
 }
 
+// publishLifecycle sends the lifecycle event when a lifecycle sink is configured
+func (e *EndpointConfig) publishLifecycle(event *models.LifecycleEvent) {
+	if e.Lifecycle == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Lifecycle.SendLifecycle(ctx, event); err != nil && e.Logger != nil {
+		e.Logger.Warnf("Failed to send %s lifecycle event: %v", event.Type, err)
+	}
+}
+
+// publishResults writes the final standings together with the game_stopped event
+// when a results sink is configured, otherwise it sends the event alone
+func (e *EndpointConfig) publishResults(stopped *models.LifecycleEvent, standings []models.PlayerScore) {
+	if e.Results == nil {
+		e.publishLifecycle(stopped)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Results.SendSessionResults(ctx, stopped, standings); err != nil && e.Logger != nil {
+		e.Logger.Errorf("Failed to send the results of session %s: %v", stopped.SessionID, err)
+	}
+}
+
 // Helper functions
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package routes
+
+import (
+	"context"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"regexp"
+	"time"
+)
+
+// maxRawPayload caps the unparsable payload kept in a dead letter
+const maxRawPayload = 1024
+
+// playerNamePattern accepts up to 32 letters, digits, spaces, dots, dashes and underscores
+var playerNamePattern = regexp.MustCompile(`^[\p{L}\p{N}_ .-]{1,32}$`)
+
+// validateMessage checks the message of player against the game config, it returns the
+// reason code and why the message was rejected, or an empty reason when it can be scored
+func validateMessage(config *models.GameConfig, player string, msg *models.GameMessage) (string, string) {
+	if reason, detail := validatePlayer(player, msg); reason != "" {
+		return reason, detail
+	}
+	if _, ok := config.CharacterFavorites[msg.Character]; !ok {
+		return models.ReasonUnknownCharacter, fmt.Sprintf("unknown character %q", msg.Character)
+	}
+	// the validated configs have a score for every favorite and negative color
+	if _, ok := config.Colors[msg.BalloonColor]; !ok {
+		return models.ReasonUnknownColor, fmt.Sprintf("unknown balloon color %q", msg.BalloonColor)
+	}
+	return "", ""
+}
+
+// validatePlayer checks the name of player and that msg was sent by player
+func validatePlayer(player string, msg *models.GameMessage) (string, string) {
+	if !playerNamePattern.MatchString(player) {
+		return models.ReasonInvalidPlayer, fmt.Sprintf("invalid player name %q", player)
+	}
+	if msg.Player != "" && msg.Player != player {
+		return models.ReasonInvalidPlayer, fmt.Sprintf("message of player %q sent on the connection of %q", msg.Player, player)
+	}
+	return "", ""
+}
+
+// poppedBalloon resolves the balloon msg pops, the one sp spawned for player or, when
+// the clients spawn the balloons, the one the message describes
+func poppedBalloon(config *models.GameConfig, sp *spawner, player string, msg *models.GameMessage, now time.Time) (models.Balloon, string, string) {
+	if sp != nil {
+		if reason, detail := validatePlayer(player, msg); reason != "" {
+			return models.Balloon{}, reason, detail
+		}
+		return sp.pop(msg.BalloonID, player, now)
+	}
+	if reason, detail := validateMessage(config, player, msg); reason != "" {
+		return models.Balloon{}, reason, detail
+	}
+	b := models.Balloon{Color: msg.BalloonColor, Kind: models.BalloonRegular}
+	// the negative hits are told by their color rather than trusting the negative_hit of the client
+	if contains(config.CharacterFavorites[msg.Character], msg.BalloonColor) {
+		b.Kind = models.BalloonBonus
+	} else if contains(config.CharacterNegatives[msg.Character], msg.BalloonColor) {
+		b.Kind = models.BalloonNegative
+	}
+	return b, "", ""
+}
+
+// rejectMessage sends the letter to the dead letter sink, when configured, and an error
+// frame telling the player why the message was not scored
+func (e *EndpointConfig) rejectMessage(conn *playerConn, letter *models.DeadLetter) error {
+	e.Logger.Warnf("Rejected message of %s: %s", letter.Player, letter.Detail)
+	if e.DeadLetters != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := e.DeadLetters.SendDeadLetter(ctx, letter); err != nil {
+			e.Logger.Warnf("Failed to send %s dead letter: %v", letter.Reason, err)
+		}
+		cancel()
+	}
+	return conn.writeJSON(models.ErrorFrame{
+		Type:    "error",
+		Code:    letter.Reason,
+		Message: letter.Detail,
+	})
+}
@@ -0,0 +1,335 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StartFunc starts a game in the room that stops after durationSeconds
+type StartFunc func(roomID string, durationSeconds int) error
+
+// CheckFunc verifies a game can still be started in the room
+type CheckFunc func(roomID string) error
+
+type entry struct {
+	models.Schedule
+	cron cron.Schedule
+}
+
+// Scheduler starts the games of its schedules when they are due and keeps the
+// schedules in a JSON file so they survive restarts, an empty file keeps them in memory only
+type Scheduler struct {
+	mu        sync.Mutex
+	file      string
+	schedules map[string]*entry
+	start     StartFunc
+	log       *zap.SugaredLogger
+	wake      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// New creates a scheduler calling start, loading the schedules saved in file
+func New(file string, start StartFunc, log *zap.SugaredLogger) (*Scheduler, error) {
+	s := &Scheduler{
+		file:      file,
+		schedules: make(map[string]*entry),
+		start:     start,
+		log:       log,
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if err := s.load(time.Now()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the saved schedules, computing the next runs of the cron schedules from
+// now and dropping the one-off games that are already over, the ones that already
+// started run for the rest of their planned duration
+func (s *Scheduler) load(now time.Time) error {
+	if s.file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schedules %s: %w", s.file, err)
+	}
+	var schedules []models.Schedule
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return fmt.Errorf("failed to parse schedules %s: %w", s.file, err)
+	}
+	for _, schedule := range schedules {
+		e, err := newEntry(schedule, now)
+		if err != nil {
+			return fmt.Errorf("invalid schedule %s in %s: %w", schedule.ID, s.file, err)
+		}
+		if e.cron == nil && e.At.Add(time.Duration(e.DurationSeconds)*time.Second).Before(now) {
+			s.log.Warnf("Dropping the game scheduled at %s, it was missed while the server was down", e.At)
+			continue
+		}
+		s.schedules[e.ID] = e
+	}
+	return nil
+}
+
+// newEntry validates schedule and computes its next run after now
+func newEntry(schedule models.Schedule, now time.Time) (*entry, error) {
+	if schedule.DurationSeconds <= 0 {
+		return nil, errors.New("duration_seconds must be positive")
+	}
+	e := &entry{Schedule: schedule}
+	switch {
+	case schedule.Cron != "" && schedule.At != nil:
+		return nil, errors.New("set either cron or at, not both")
+	case schedule.Cron != "":
+		c, err := cron.ParseStandard(schedule.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", schedule.Cron, err)
+		}
+		e.cron = c
+		e.NextRun = c.Next(now).UTC()
+	case schedule.At != nil:
+		at := schedule.At.UTC()
+		e.At = &at
+		e.NextRun = at
+	default:
+		return nil, errors.New("either cron or at is required")
+	}
+	return e, nil
+}
+
+// Add validates and saves schedule, a one-off game must start in the future
+func (s *Scheduler) Add(schedule models.Schedule) (models.Schedule, error) {
+	now := time.Now()
+	if schedule.At != nil && !schedule.At.After(now) {
+		return models.Schedule{}, errors.New("at must be in the future")
+	}
+	schedule.ID = uuid.NewString()
+	schedule.CreatedAt = now.UTC()
+	e, err := newEntry(schedule, now)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedules[e.ID] = e
+	if err := s.save(); err != nil {
+		delete(s.schedules, e.ID)
+		return models.Schedule{}, err
+	}
+	s.notify()
+	return e.Schedule, nil
+}
+
+// Remove deletes the schedule id, it returns false when there is no such schedule
+func (s *Scheduler) Remove(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.schedules[id]
+	if !ok {
+		return false, nil
+	}
+	delete(s.schedules, id)
+	if err := s.save(); err != nil {
+		s.schedules[id] = e
+		return false, err
+	}
+	s.notify()
+	return true, nil
+}
+
+// DisableOrphans disables the schedules whose room check rejects, e.g. a room lost on a
+// restart. They are kept with the reason, so they are listed until removed, but never run.
+func (s *Scheduler) DisableOrphans(check CheckFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	disabled := 0
+	for _, e := range s.schedules {
+		if e.Disabled != "" {
+			continue
+		}
+		if err := check(e.RoomID); err != nil {
+			e.Disabled = err.Error()
+			disabled++
+			s.log.Warnf("Disabled schedule %s of room %s: %v", e.ID, e.RoomID, err)
+		}
+	}
+	if disabled == 0 {
+		return nil
+	}
+	s.notify()
+	return s.save()
+}
+
+// List returns the schedules, the next one to run first
+func (s *Scheduler) List() []models.Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedules := make([]models.Schedule, 0, len(s.schedules))
+	for _, e := range s.schedules {
+		schedules = append(schedules, e.Schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].NextRun.Before(schedules[j].NextRun)
+	})
+	return schedules
+}
+
+// Start runs the due schedules in the background until Stop is called
+func (s *Scheduler) Start() {
+	go s.run()
+}
+
+// Stop stops running the schedules, the games already started keep their timers
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		var next time.Time
+		for _, e := range s.schedules {
+			if e.Disabled != "" {
+				continue
+			}
+			if next.IsZero() || e.NextRun.Before(next) {
+				next = e.NextRun
+			}
+		}
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		var due <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			due = timer.C
+		}
+		select {
+		case <-s.stop:
+		case <-s.wake:
+		case now := <-due:
+			s.runDue(now)
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+	}
+}
+
+// runDue starts the games of the schedules due at now, the cron schedules move
+// on to their next run and the one-off schedules are removed
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	var due []models.Schedule
+	for id, e := range s.schedules {
+		if e.Disabled != "" || e.NextRun.After(now) {
+			continue
+		}
+		if e.cron != nil {
+			due = append(due, e.Schedule)
+			e.NextRun = e.cron.Next(now).UTC()
+			continue
+		}
+		delete(s.schedules, id)
+		// a one-off game started late, e.g. after a restart, still ends when it was planned to
+		schedule := e.Schedule
+		ends := e.At.Add(time.Duration(e.DurationSeconds) * time.Second)
+		if schedule.DurationSeconds = int(ends.Sub(now).Round(time.Second) / time.Second); schedule.DurationSeconds <= 0 {
+			s.log.Warnf("Dropping the game scheduled at %s, it was missed", e.At)
+			continue
+		}
+		due = append(due, schedule)
+	}
+	if len(due) > 0 {
+		if err := s.save(); err != nil {
+			s.log.Errorf("Failed to save the schedules: %v", err)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, schedule := range due {
+		if err := s.start(schedule.RoomID, schedule.DurationSeconds); err != nil {
+			s.log.Warnf("Failed to start the game of schedule %s in room %s: %v", schedule.ID, schedule.RoomID, err)
+			continue
+		}
+		s.log.Infof("Started the %d seconds game of schedule %s in room %s", schedule.DurationSeconds, schedule.ID, schedule.RoomID)
+	}
+}
+
+// notify wakes the run loop up to pick the next run again
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// save replaces the schedules file by renaming a temporary file, callers hold the lock
+func (s *Scheduler) save() error {
+	if s.file == "" {
+		return nil
+	}
+	schedules := make([]models.Schedule, 0, len(s.schedules))
+	for _, e := range s.schedules {
+		schedules = append(schedules, e.Schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+	data, err := json.MarshalIndent(schedules, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.file), 0750); err != nil {
+		return fmt.Errorf("failed to create the directory of %s: %w", s.file, err)
+	}
+	tmp := s.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write schedules %s: %w", s.file, err)
+	}
+	if err := os.Rename(tmp, s.file); err != nil {
+		return fmt.Errorf("failed to write schedules %s: %w", s.file, err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/logger"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type started struct {
+	room     string
+	duration int
+}
+
+// recorder records the games started by the scheduler
+type recorder struct {
+	mu    sync.Mutex
+	games []started
+}
+
+func (r *recorder) start(roomID string, durationSeconds int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.games = append(r.games, started{roomID, durationSeconds})
+	return nil
+}
+
+func (r *recorder) started() []started {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]started(nil), r.games...)
+}
+
+func TestScheduler(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "schedules.json")
+	games := &recorder{}
+	s, err := New(file, games.start, logger.Get())
+	if !assert.NoError(t, err) {
+		return
+	}
+	s.Start()
+
+	at := time.Now().Add(200 * time.Millisecond)
+	once, err := s.Add(models.Schedule{At: &at, DurationSeconds: 60, RoomID: "lobby"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, once.ID)
+	hourly, err := s.Add(models.Schedule{Cron: "0 * * * *", DurationSeconds: 300})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, hourly.NextRun.Minute())
+	assert.Len(t, s.List(), 2)
+
+	past := time.Now().Add(-time.Minute)
+	for _, invalid := range []models.Schedule{
+		{Cron: "every day", DurationSeconds: 60},
+		{Cron: "@hourly", DurationSeconds: 0},
+		{At: &past, DurationSeconds: 60},
+		{At: &at, Cron: "@hourly", DurationSeconds: 60},
+		{DurationSeconds: 60},
+	} {
+		_, err := s.Add(invalid)
+		assert.Error(t, err)
+	}
+
+	// the one-off game runs once and is removed
+	assert.Eventually(t, func() bool {
+		return len(games.started()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, started{"lobby", 60}, games.started()[0])
+	assert.Len(t, s.List(), 1)
+	s.Stop()
+
+	// the schedules survive a restart
+	s, err = New(file, games.start, logger.Get())
+	if !assert.NoError(t, err) {
+		return
+	}
+	schedules := s.List()
+	if assert.Len(t, schedules, 1) {
+		assert.Equal(t, hourly.ID, schedules[0].ID)
+	}
+	ok, err := s.Remove(hourly.ID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = s.Remove(hourly.ID)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Empty(t, s.List())
+}
+
+func TestSchedulerEvery(t *testing.T) {
+	games := &recorder{}
+	s, err := New("", games.start, logger.Get())
+	if !assert.NoError(t, err) {
+		return
+	}
+	s.Start()
+	defer s.Stop()
+	_, err = s.Add(models.Schedule{Cron: "@every 1s", DurationSeconds: 1})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(games.started()) >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSchedulerDisableOrphans(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "schedules.json")
+	games := &recorder{}
+	s, err := New(file, games.start, logger.Get())
+	if !assert.NoError(t, err) {
+		return
+	}
+	lobby, err := s.Add(models.Schedule{Cron: "@every 1s", DurationSeconds: 1, RoomID: "lobby"})
+	assert.NoError(t, err)
+	_, err = s.Add(models.Schedule{Cron: "@every 1s", DurationSeconds: 1, RoomID: "default"})
+	assert.NoError(t, err)
+
+	// the lobby room did not survive the restart
+	check := func(roomID string) error {
+		if roomID != "default" {
+			return fmt.Errorf("unknown room %s", roomID)
+		}
+		return nil
+	}
+	for range 2 {
+		s, err = New(file, games.start, logger.Get())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, s.DisableOrphans(check))
+		for _, schedule := range s.List() {
+			if schedule.ID == lobby.ID {
+				assert.Equal(t, "unknown room lobby", schedule.Disabled)
+			} else {
+				assert.Empty(t, schedule.Disabled)
+			}
+		}
+	}
+
+	s.Start()
+	defer s.Stop()
+	assert.Eventually(t, func() bool {
+		return len(games.started()) >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	for _, game := range games.started() {
+		assert.Equal(t, "default", game.room, "a disabled schedule never runs")
+	}
+}
+
+func TestSchedulerStartedLate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "schedules.json")
+	begun := time.Now().Add(-30 * time.Second)
+	missed := time.Now().Add(-2 * time.Minute)
+	data, err := json.Marshal([]models.Schedule{
+		{ID: "late", At: &begun, DurationSeconds: 60, RoomID: "lobby"},
+		{ID: "missed", At: &missed, DurationSeconds: 60, RoomID: "lobby"},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(file, data, 0o600))
+
+	// the server was down when the game was due, it still ends when planned
+	games := &recorder{}
+	s, err := New(file, games.start, logger.Get())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, s.List(), 1)
+	s.runDue(time.Now())
+	assert.Equal(t, []started{{"lobby", 30}}, games.started())
+	assert.Empty(t, s.List())
+}
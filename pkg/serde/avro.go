@@ -0,0 +1,296 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package serde
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AvroSchema is the Avro schema of the GameEvent
+const AvroSchema = `{
+  "type": "record",
+  "name": "GameEvent",
+  "namespace": "com.github.kameshsampath.balloonpopper",
+  "fields": [
+    {"name": "player", "type": "string"},
+    {"name": "balloon_color", "type": "string"},
+    {"name": "score", "type": "int"},
+    {"name": "favorite_color_bonus", "type": "boolean"},
+    {"name": "event_ts", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+    {"name": "session_id", "type": "string", "default": ""},
+    {"name": "room_id", "type": "string", "default": ""}
+  ]
+}`
+
+var errAvroShortBuffer = errors.New("avro: unexpected end of data")
+
+// avroReaderField is a field of AvroSchema, optional when it has a default
+type avroReaderField struct {
+	typ      string
+	optional bool
+}
+
+// avroReaderFields are the fields of AvroSchema the data is resolved to
+var avroReaderFields = map[string]avroReaderField{
+	"player":               {typ: "string"},
+	"balloon_color":        {typ: "string"},
+	"score":                {typ: "int"},
+	"favorite_color_bonus": {typ: "boolean"},
+	"event_ts":             {typ: "long"},
+	"session_id":           {typ: "string", optional: true},
+	"room_id":              {typ: "string", optional: true},
+}
+
+// avroField is a field of the schema the data was written with
+type avroField struct {
+	name string
+	typ  string
+}
+
+// AvroSerializer encodes the events using the Avro binary encoding of AvroSchema
+type AvroSerializer struct {
+	schemas *schemaIDs
+	mu      sync.Mutex
+	writers map[int][]avroField
+}
+
+// NewAvroSerializer creates an Avro serializer registering AvroSchema with registry
+func NewAvroSerializer(registry *RegistryClient) *AvroSerializer {
+	return &AvroSerializer{
+		schemas: newSchemaIDs(registry, SchemaTypeAvro, AvroSchema),
+		writers: make(map[int][]avroField),
+	}
+}
+
+func (a *AvroSerializer) Format() string {
+	return FormatAvro
+}
+
+func (a *AvroSerializer) ContentType() string {
+	return "application/avro"
+}
+
+func (a *AvroSerializer) Serialize(ctx context.Context, topic string, event *models.GameEvent) ([]byte, error) {
+	id, err := a.schemas.id(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	buf := appendWireHeader(make([]byte, 0, 64), id)
+	buf = appendAvroString(buf, event.Player)
+	buf = appendAvroString(buf, event.BalloonColor)
+	buf = binary.AppendVarint(buf, int64(event.Score))
+	buf = appendAvroBool(buf, event.FavoriteColorBonus)
+	buf = binary.AppendVarint(buf, event.EventTS.UnixMilli())
+	buf = appendAvroString(buf, event.SessionID)
+	buf = appendAvroString(buf, event.RoomID)
+	return buf, nil
+}
+
+func (a *AvroSerializer) Deserialize(ctx context.Context, topic string, data []byte) (*models.GameEvent, error) {
+	id, payload, err := readWireHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := a.writerFields(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// the fields are read in the order of the writer schema, the ones AvroSchema
+	// does not know are skipped and the missing ones keep their default
+	r := &avroReader{buf: payload}
+	event := &models.GameEvent{}
+	for _, field := range fields {
+		switch field.typ {
+		case "string":
+			v := r.string()
+			switch field.name {
+			case "player":
+				event.Player = v
+			case "balloon_color":
+				event.BalloonColor = v
+			case "session_id":
+				event.SessionID = v
+			case "room_id":
+				event.RoomID = v
+			}
+		case "int", "long":
+			v := r.long()
+			switch field.name {
+			case "score":
+				event.Score = int(v)
+			case "event_ts":
+				event.EventTS = time.UnixMilli(v).UTC()
+			}
+		case "boolean":
+			v := r.bool()
+			if field.name == "favorite_color_bonus" {
+				event.FavoriteColorBonus = v
+			}
+		}
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to decode avro event: %w", r.err)
+	}
+	return event, nil
+}
+
+// writerFields returns the fields of the schema with id, checking they resolve to AvroSchema
+func (a *AvroSerializer) writerFields(ctx context.Context, id int) ([]avroField, error) {
+	a.mu.Lock()
+	fields, ok := a.writers[id]
+	a.mu.Unlock()
+	if ok {
+		return fields, nil
+	}
+	schema, err := a.schemas.writer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if fields, err = resolveAvroFields(schema); err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+	a.mu.Lock()
+	a.writers[id] = fields
+	a.mu.Unlock()
+	return fields, nil
+}
+
+// resolveAvroFields parses the GameEvent record schema the data was written with, following
+// the Avro schema resolution: the common fields have the same type and the fields of AvroSchema
+// without a default are written
+func resolveAvroFields(schema string) ([]avroField, error) {
+	var record struct {
+		Type   string `json:"type"`
+		Name   string `json:"name"`
+		Fields []struct {
+			Name string          `json:"name"`
+			Type json.RawMessage `json:"type"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(schema), &record); err != nil || record.Type != "record" {
+		return nil, fmt.Errorf("%w: not an avro record schema", ErrSchemaMismatch)
+	}
+	if name := record.Name[strings.LastIndex(record.Name, ".")+1:]; name != "GameEvent" {
+		return nil, fmt.Errorf("%w: record %s is not a GameEvent", ErrSchemaMismatch, record.Name)
+	}
+	fields := make([]avroField, 0, len(record.Fields))
+	written := make(map[string]bool, len(record.Fields))
+	for _, f := range record.Fields {
+		typ, err := avroPrimitive(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %s: %v", ErrSchemaMismatch, f.Name, err)
+		}
+		if want, ok := avroReaderFields[f.Name]; ok && want.typ != typ {
+			return nil, fmt.Errorf("%w: field %s is %s, expected %s", ErrSchemaMismatch, f.Name, typ, want.typ)
+		}
+		fields = append(fields, avroField{name: f.Name, typ: typ})
+		written[f.Name] = true
+	}
+	for name, field := range avroReaderFields {
+		if !field.optional && !written[name] {
+			return nil, fmt.Errorf("%w: field %s is missing", ErrSchemaMismatch, name)
+		}
+	}
+	return fields, nil
+}
+
+// avroPrimitive returns the primitive type of a field, e.g. long for a timestamp-millis
+func avroPrimitive(raw json.RawMessage) (string, error) {
+	var typ string
+	if err := json.Unmarshal(raw, &typ); err != nil {
+		var logical struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &logical); err != nil {
+			return "", fmt.Errorf("unsupported type %s", raw)
+		}
+		typ = logical.Type
+	}
+	switch typ {
+	case "string", "int", "long", "boolean":
+		return typ, nil
+	}
+	return "", fmt.Errorf("unsupported type %s", raw)
+}
+
+// Avro encodes int and long as zig-zag varints, the same as encoding/binary
+func appendAvroString(buf []byte, s string) []byte {
+	buf = binary.AppendVarint(buf, int64(len(s)))
+	return append(buf, s...)
+}
+
+func appendAvroBool(buf []byte, b bool) []byte {
+	if b {
+		return append(buf, 1)
+	}
+	return append(buf, 0)
+}
+
+// avroReader decodes the Avro primitives, keeping the first error
+type avroReader struct {
+	buf []byte
+	err error
+}
+
+func (r *avroReader) long() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.err = errAvroShortBuffer
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *avroReader) string() string {
+	n := r.long()
+	if r.err != nil {
+		return ""
+	}
+	if n < 0 || int64(len(r.buf)) < n {
+		r.err = errAvroShortBuffer
+		return ""
+	}
+	s := string(r.buf[:n])
+	r.buf = r.buf[n:]
+	return s
+}
+
+func (r *avroReader) bool() bool {
+	if r.err != nil {
+		return false
+	}
+	if len(r.buf) == 0 {
+		r.err = errAvroShortBuffer
+		return false
+	}
+	b := r.buf[0] == 1
+	r.buf = r.buf[1:]
+	return b
+}
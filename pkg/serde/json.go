@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package serde
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+)
+
+// JSONSerializer encodes the events as plain JSON, without the wire format header,
+// which is what the existing consumers of the score topic expect
+type JSONSerializer struct{}
+
+func (j *JSONSerializer) Format() string {
+	return FormatJSON
+}
+
+func (j *JSONSerializer) ContentType() string {
+	return "application/json"
+}
+
+func (j *JSONSerializer) Serialize(_ context.Context, _ string, event *models.GameEvent) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return data, nil
+}
+
+func (j *JSONSerializer) Deserialize(_ context.Context, _ string, data []byte) (*models.GameEvent, error) {
+	var event models.GameEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	return &event, nil
+}
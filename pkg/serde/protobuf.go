@@ -0,0 +1,248 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package serde
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"regexp"
+	"time"
+)
+
+// ProtobufSchema is the Protobuf schema of the GameEvent
+const ProtobufSchema = `syntax = "proto3";
+package com.github.kameshsampath.balloonpopper;
+
+message GameEvent {
+  string player = 1;
+  string balloon_color = 2;
+  int32 score = 3;
+  bool favorite_color_bonus = 4;
+  // milliseconds since the unix epoch
+  int64 event_ts = 5;
+  string session_id = 6;
+  string room_id = 7;
+}
+`
+
+// protobuf wire types
+const (
+	wireVarint     = 0
+	wireFixed64    = 1
+	wireBytes      = 2
+	wireStartGroup = 3
+	wireEndGroup   = 4
+	wireFixed32    = 5
+)
+
+var (
+	errProtobufShortBuffer = errors.New("protobuf: unexpected end of data")
+	// protobufMessage finds the GameEvent message in a Protobuf schema
+	protobufMessage = regexp.MustCompile(`(?m)^\s*message\s+GameEvent\s*\{`)
+)
+
+// ProtobufSerializer encodes the events as the GameEvent message of ProtobufSchema
+type ProtobufSerializer struct {
+	schemas *schemaIDs
+}
+
+// NewProtobufSerializer creates a Protobuf serializer registering ProtobufSchema with registry
+func NewProtobufSerializer(registry *RegistryClient) *ProtobufSerializer {
+	return &ProtobufSerializer{
+		schemas: newSchemaIDs(registry, SchemaTypeProtobuf, ProtobufSchema),
+	}
+}
+
+func (p *ProtobufSerializer) Format() string {
+	return FormatProtobuf
+}
+
+func (p *ProtobufSerializer) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (p *ProtobufSerializer) Serialize(ctx context.Context, topic string, event *models.GameEvent) ([]byte, error) {
+	id, err := p.schemas.id(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	buf := appendWireHeader(make([]byte, 0, 64), id)
+	// message indexes, a single 0 refers to the first message in the schema
+	buf = append(buf, 0)
+	if event.Player != "" {
+		buf = appendProtoBytes(buf, 1, event.Player)
+	}
+	if event.BalloonColor != "" {
+		buf = appendProtoBytes(buf, 2, event.BalloonColor)
+	}
+	if event.Score != 0 {
+		// int32 is sign extended to 64 bits
+		buf = appendProtoVarint(buf, 3, uint64(int64(event.Score))) //nolint:gosec
+	}
+	if event.FavoriteColorBonus {
+		buf = appendProtoVarint(buf, 4, 1)
+	}
+	if ts := event.EventTS.UnixMilli(); ts != 0 {
+		buf = appendProtoVarint(buf, 5, uint64(ts)) //nolint:gosec
+	}
+	if event.SessionID != "" {
+		buf = appendProtoBytes(buf, 6, event.SessionID)
+	}
+	if event.RoomID != "" {
+		buf = appendProtoBytes(buf, 7, event.RoomID)
+	}
+	return buf, nil
+}
+
+func (p *ProtobufSerializer) Deserialize(ctx context.Context, topic string, data []byte) (*models.GameEvent, error) {
+	id, payload, err := readWireHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	// the fields are matched by number, so any version of the GameEvent message can be read
+	schema, err := p.schemas.writer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !protobufMessage.MatchString(schema) {
+		return nil, fmt.Errorf("%w: schema %d has no GameEvent message", ErrSchemaMismatch, id)
+	}
+	// skip the message indexes
+	count, n := binary.Varint(payload)
+	if n <= 0 {
+		return nil, errProtobufShortBuffer
+	}
+	payload = payload[n:]
+	for i := int64(0); i < count; i++ {
+		if _, n = binary.Varint(payload); n <= 0 {
+			return nil, errProtobufShortBuffer
+		}
+		payload = payload[n:]
+	}
+
+	event := &models.GameEvent{EventTS: time.UnixMilli(0).UTC()}
+	for len(payload) > 0 {
+		key, n := binary.Uvarint(payload)
+		if n <= 0 {
+			return nil, errProtobufShortBuffer
+		}
+		payload = payload[n:]
+		field, wireType := key>>3, key&0x7
+		switch wireType {
+		case wireVarint:
+			v, n := binary.Uvarint(payload)
+			if n <= 0 {
+				return nil, errProtobufShortBuffer
+			}
+			payload = payload[n:]
+			switch field {
+			case 3:
+				event.Score = int(int32(v)) //nolint:gosec
+			case 4:
+				event.FavoriteColorBonus = v != 0
+			case 5:
+				event.EventTS = time.UnixMilli(int64(v)).UTC() //nolint:gosec
+			}
+		case wireBytes:
+			l, n := binary.Uvarint(payload)
+			if n <= 0 || uint64(len(payload)-n) < l {
+				return nil, errProtobufShortBuffer
+			}
+			value := string(payload[n : n+int(l)]) //nolint:gosec
+			payload = payload[n+int(l):]           //nolint:gosec
+			switch field {
+			case 1:
+				event.Player = value
+			case 2:
+				event.BalloonColor = value
+			case 6:
+				event.SessionID = value
+			case 7:
+				event.RoomID = value
+			}
+		default:
+			// the fields added by later schema versions are skipped
+			var err error
+			if payload, err = skipProtoField(payload, field, wireType); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return event, nil
+}
+
+// skipProtoField skips the value of a field, a group up to its end, and returns the rest of the payload
+func skipProtoField(payload []byte, field, wireType uint64) ([]byte, error) {
+	switch wireType {
+	case wireVarint:
+		_, n := binary.Uvarint(payload)
+		if n <= 0 {
+			return nil, errProtobufShortBuffer
+		}
+		return payload[n:], nil
+	case wireFixed64, wireFixed32:
+		size := 8
+		if wireType == wireFixed32 {
+			size = 4
+		}
+		if len(payload) < size {
+			return nil, errProtobufShortBuffer
+		}
+		return payload[size:], nil
+	case wireBytes:
+		l, n := binary.Uvarint(payload)
+		if n <= 0 || uint64(len(payload)-n) < l {
+			return nil, errProtobufShortBuffer
+		}
+		return payload[n+int(l):], nil //nolint:gosec
+	case wireStartGroup:
+		for {
+			key, n := binary.Uvarint(payload)
+			if n <= 0 {
+				return nil, errProtobufShortBuffer
+			}
+			payload = payload[n:]
+			if key&0x7 == wireEndGroup {
+				if key>>3 != field {
+					return nil, fmt.Errorf("protobuf: group of field %d ended by field %d", field, key>>3)
+				}
+				return payload, nil
+			}
+			var err error
+			if payload, err = skipProtoField(payload, key>>3, key&0x7); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("protobuf: unsupported wire type %d for field %d", wireType, field)
+	}
+}
+
+func appendProtoVarint(buf []byte, field int, v uint64) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field)<<3|wireVarint) //nolint:gosec
+	return binary.AppendUvarint(buf, v)
+}
+
+func appendProtoBytes(buf []byte, field int, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field)<<3|wireBytes) //nolint:gosec
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
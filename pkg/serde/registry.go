@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package serde
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SchemaTypeAvro is the schema registry type of Avro schemas
+	SchemaTypeAvro = "AVRO"
+	// SchemaTypeProtobuf is the schema registry type of Protobuf schemas
+	SchemaTypeProtobuf = "PROTOBUF"
+
+	registryContentType = "application/vnd.schemaregistry.v1+json"
+)
+
+// RegistryClient is a minimal client of the Confluent schema registry REST API
+type RegistryClient struct {
+	baseURL    string
+	httpClient *http.Client
+	username   string
+	password   string
+}
+
+// NewRegistryClient creates a client for the schema registry at baseURL
+func NewRegistryClient(baseURL string) (*RegistryClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid schema registry url %q", baseURL)
+	}
+	return &RegistryClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// SetBasicAuth sets the credentials sent with every request
+func (r *RegistryClient) SetBasicAuth(username, password string) {
+	r.username = username
+	r.password = password
+}
+
+// RegisterSchema registers the schema under subject and returns its ID, registering
+// an already registered schema returns the existing ID
+func (r *RegistryClient) RegisterSchema(ctx context.Context, subject, schemaType, schema string) (int, error) {
+	body := struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType,omitempty"`
+	}{
+		Schema: schema,
+	}
+	// AVRO is the registry default
+	if schemaType != SchemaTypeAvro {
+		body.SchemaType = schemaType
+	}
+	var resp struct {
+		ID int `json:"id"`
+	}
+	path := fmt.Sprintf("/subjects/%s/versions", url.PathEscape(subject))
+	if err := r.do(ctx, http.MethodPost, path, body, &resp); err != nil {
+		return 0, fmt.Errorf("failed to register schema for %s: %w", subject, err)
+	}
+	return resp.ID, nil
+}
+
+// SchemaByID returns the schema registered with id
+func (r *RegistryClient) SchemaByID(ctx context.Context, id int) (string, error) {
+	var resp struct {
+		Schema string `json:"schema"`
+	}
+	if err := r.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to get schema %d: %w", id, err)
+	}
+	return resp.Schema, nil
+}
+
+func (r *RegistryClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", registryContentType)
+	if in != nil {
+		req.Header.Set("Content-Type", registryContentType)
+	}
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var regErr struct {
+			ErrorCode int    `json:"error_code"`
+			Message   string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&regErr); err == nil && regErr.Message != "" {
+			return fmt.Errorf("schema registry error %d: %s", regErr.ErrorCode, regErr.Message)
+		}
+		return fmt.Errorf("schema registry returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// schemaIDs registers the schema once per topic and caches the IDs, together with
+// the schemas looked up to read the data
+type schemaIDs struct {
+	mu         sync.Mutex
+	registry   *RegistryClient
+	schemaType string
+	schema     string
+	ids        map[string]int
+	writers    map[int]string
+}
+
+func newSchemaIDs(registry *RegistryClient, schemaType, schema string) *schemaIDs {
+	return &schemaIDs{
+		registry:   registry,
+		schemaType: schemaType,
+		schema:     schema,
+		ids:        make(map[string]int),
+		writers:    make(map[int]string),
+	}
+}
+
+// id returns the schema ID for the topic, registering the schema on first use
+func (s *schemaIDs) id(ctx context.Context, topic string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id, ok := s.ids[topic]; ok {
+		return id, nil
+	}
+	id, err := s.registry.RegisterSchema(ctx, SubjectName(topic), s.schemaType, s.schema)
+	if err != nil {
+		return 0, err
+	}
+	s.ids[topic] = id
+	return id, nil
+}
+
+// writer looks up the schema the data was written with, the schemas never change once
+// registered so they are cached. Reading never registers a schema.
+func (s *schemaIDs) writer(ctx context.Context, id int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if schema, ok := s.writers[id]; ok {
+		return schema, nil
+	}
+	schema, err := s.registry.SchemaByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	s.writers[id] = schema
+	return schema, nil
+}
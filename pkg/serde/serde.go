@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package serde
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+)
+
+const (
+	// FormatJSON encodes the events as plain JSON
+	FormatJSON = "json"
+	// FormatAvro encodes the events as Avro binary in the Confluent wire format
+	FormatAvro = "avro"
+	// FormatProtobuf encodes the events as Protobuf in the Confluent wire format
+	FormatProtobuf = "protobuf"
+
+	// magicByte is the first byte of the Confluent wire format
+	magicByte byte = 0x0
+	// wireHeaderSize is the magic byte followed by the 4 byte schema ID
+	wireHeaderSize = 5
+)
+
+var (
+	// ErrNotWireFormat is returned when the data does not start with the Confluent magic byte
+	ErrNotWireFormat = errors.New("data is not in the confluent wire format")
+	// ErrSchemaMismatch is returned when the data was written with an unexpected schema
+	ErrSchemaMismatch = errors.New("data was written with a different schema")
+)
+
+// Serializer converts the GameEvent to and from the bytes sent to Kafka
+type Serializer interface {
+	// Format is the name of the encoding e.g. json, avro
+	Format() string
+	// ContentType is the media type of the serialized data
+	ContentType() string
+	// Serialize encodes the event for the given topic
+	Serialize(ctx context.Context, topic string, event *models.GameEvent) ([]byte, error)
+	// Deserialize decodes data produced by Serialize
+	Deserialize(ctx context.Context, topic string, data []byte) (*models.GameEvent, error)
+}
+
+// NewSerializer creates the Serializer for format, Avro and Protobuf require a schema registry
+func NewSerializer(format string, registry *RegistryClient) (Serializer, error) {
+	switch format {
+	case "", FormatJSON:
+		return &JSONSerializer{}, nil
+	case FormatAvro:
+		if registry == nil {
+			return nil, fmt.Errorf("%s format requires a schema registry", format)
+		}
+		return NewAvroSerializer(registry), nil
+	case FormatProtobuf:
+		if registry == nil {
+			return nil, fmt.Errorf("%s format requires a schema registry", format)
+		}
+		return NewProtobufSerializer(registry), nil
+	default:
+		return nil, fmt.Errorf("unknown event format %q, must be one of json, avro or protobuf", format)
+	}
+}
+
+// SubjectName returns the value subject of the topic, following the TopicNameStrategy
+func SubjectName(topic string) string {
+	return topic + "-value"
+}
+
+// appendWireHeader writes the magic byte and the schema ID
+func appendWireHeader(buf []byte, schemaID int) []byte {
+	buf = append(buf, magicByte)
+	return binary.BigEndian.AppendUint32(buf, uint32(schemaID)) //nolint:gosec
+}
+
+// readWireHeader returns the schema ID and the payload following the header
+func readWireHeader(data []byte) (int, []byte, error) {
+	if len(data) < wireHeaderSize || data[0] != magicByte {
+		return 0, nil, ErrNotWireFormat
+	}
+	return int(binary.BigEndian.Uint32(data[1:wireHeaderSize])), data[wireHeaderSize:], nil
+}
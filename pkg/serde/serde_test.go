@@ -0,0 +1,284 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+
+package serde
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRegistry is an in-process schema registry supporting registration and lookup by ID
+type fakeRegistry struct {
+	mu      sync.Mutex
+	schemas []string
+	types   []string
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", registryContentType)
+
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/subjects/") && strings.HasSuffix(r.URL.Path, "/versions"):
+		var req struct {
+			Schema     string `json:"schema"`
+			SchemaType string `json:"schemaType"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = fmt.Fprint(w, `{"error_code":42201,"message":"Invalid schema"}`)
+			return
+		}
+		if req.SchemaType == "" {
+			req.SchemaType = SchemaTypeAvro
+		}
+		for i, s := range f.schemas {
+			if s == req.Schema && f.types[i] == req.SchemaType {
+				_, _ = fmt.Fprintf(w, `{"id":%d}`, i+1)
+				return
+			}
+		}
+		f.schemas = append(f.schemas, req.Schema)
+		f.types = append(f.types, req.SchemaType)
+		_, _ = fmt.Fprintf(w, `{"id":%d}`, len(f.schemas))
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/schemas/ids/"):
+		var id int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/schemas/ids/"), "%d", &id); err != nil || id < 1 || id > len(f.schemas) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"error_code":40403,"message":"Schema not found"}`)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"schema": f.schemas[id-1]})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprint(w, `{"error_code":404,"message":"HTTP 404 Not Found"}`)
+	}
+}
+
+func newTestRegistry(t *testing.T) (*RegistryClient, *fakeRegistry) {
+	fake := &fakeRegistry{}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	client, err := NewRegistryClient(srv.URL)
+	assert.NoError(t, err)
+	return client, fake
+}
+
+func TestRoundTrip(t *testing.T) {
+	registry, _ := newTestRegistry(t)
+	event := &models.GameEvent{
+		Player:             "tester",
+		BalloonColor:       "gold",
+		Score:              -45,
+		FavoriteColorBonus: true,
+		EventTS:            time.Date(2025, 2, 25, 11, 7, 49, 401000000, time.UTC),
+		SessionID:          "9b2f6c1e-2f4b-4a57-a3f4-1f0e0a6c3d21",
+		RoomID:             "lobby",
+	}
+
+	for _, format := range []string{FormatJSON, FormatAvro, FormatProtobuf} {
+		t.Run(format, func(t *testing.T) {
+			s, err := NewSerializer(format, registry)
+			assert.NoError(t, err)
+			assert.Equal(t, format, s.Format())
+
+			data, err := s.Serialize(context.Background(), "balloon-game", event)
+			assert.NoError(t, err)
+			if format != FormatJSON {
+				id, _, err := readWireHeader(data)
+				assert.NoError(t, err)
+				assert.NotZero(t, id)
+			}
+
+			got, err := s.Deserialize(context.Background(), "balloon-game", data)
+			assert.NoError(t, err)
+			assert.Equal(t, event, got)
+		})
+	}
+}
+
+func TestWireFormat(t *testing.T) {
+	registry, fake := newTestRegistry(t)
+	avro := NewAvroSerializer(registry)
+	proto := NewProtobufSerializer(registry)
+	event := models.NewGameEvent("p", "red", 1, false)
+
+	avroData, err := avro.Serialize(context.Background(), "scores", event)
+	assert.NoError(t, err)
+	protoData, err := proto.Serialize(context.Background(), "scores", event)
+	assert.NoError(t, err)
+
+	assert.Equal(t, byte(0), avroData[0], "magic byte")
+	assert.Equal(t, uint32(1), binary.BigEndian.Uint32(avroData[1:5]))
+	assert.Equal(t, byte(0), protoData[0], "magic byte")
+	assert.Equal(t, uint32(2), binary.BigEndian.Uint32(protoData[1:5]))
+	assert.Equal(t, byte(0), protoData[5], "first message index")
+	assert.Equal(t, []string{SchemaTypeAvro, SchemaTypeProtobuf}, fake.types)
+
+	// the schema is registered once per topic
+	_, err = avro.Serialize(context.Background(), "scores", event)
+	assert.NoError(t, err)
+	assert.Len(t, fake.schemas, 2)
+
+	schema, err := registry.SchemaByID(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, AvroSchema, schema)
+
+	// data written with another schema is rejected
+	_, err = avro.Deserialize(context.Background(), "scores", protoData)
+	assert.ErrorIs(t, err, ErrSchemaMismatch)
+	_, err = avro.Deserialize(context.Background(), "scores", []byte(`{"player":"p"}`))
+	assert.ErrorIs(t, err, ErrNotWireFormat)
+}
+
+func TestNewSerializer(t *testing.T) {
+	_, err := NewSerializer(FormatAvro, nil)
+	assert.Error(t, err)
+	_, err = NewSerializer(FormatProtobuf, nil)
+	assert.Error(t, err)
+	_, err = NewSerializer("xml", nil)
+	assert.Error(t, err)
+	s, err := NewSerializer("", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, FormatJSON, s.Format())
+	_, err = NewRegistryClient("localhost:8081")
+	assert.Error(t, err)
+}
+
+func TestSchemaEvolution(t *testing.T) {
+	registry, fake := newTestRegistry(t)
+	ts := time.Date(2025, 2, 25, 11, 7, 49, 401000000, time.UTC)
+	register := func(schemaType, schema string) int {
+		id, err := registry.RegisterSchema(context.Background(), "scores-value", schemaType, schema)
+		assert.NoError(t, err)
+		return id
+	}
+
+	// the first version had no session and room, a later one an extra field
+	v1 := register(SchemaTypeAvro, `{"type": "record", "name": "GameEvent", "fields": [
+		{"name": "player", "type": "string"},
+		{"name": "balloon_color", "type": "string"},
+		{"name": "score", "type": "int"},
+		{"name": "favorite_color_bonus", "type": "boolean"},
+		{"name": "event_ts", "type": {"type": "long", "logicalType": "timestamp-millis"}}]}`)
+	v3 := register(SchemaTypeAvro, `{"type": "record", "name": "com.example.GameEvent", "fields": [
+		{"name": "player", "type": "string"},
+		{"name": "level", "type": "int"},
+		{"name": "balloon_color", "type": "string"},
+		{"name": "score", "type": "int"},
+		{"name": "favorite_color_bonus", "type": "boolean"},
+		{"name": "event_ts", "type": "long"},
+		{"name": "session_id", "type": "string"}]}`)
+	broken := register(SchemaTypeAvro, `{"type": "record", "name": "GameEvent", "fields": [
+		{"name": "player", "type": "string"},
+		{"name": "score", "type": "string"}]}`)
+	registered := len(fake.schemas)
+
+	avroV1 := appendWireHeader(nil, v1)
+	avroV1 = appendAvroString(avroV1, "tom")
+	avroV1 = appendAvroString(avroV1, "red")
+	avroV1 = binary.AppendVarint(avroV1, 25)
+	avroV1 = appendAvroBool(avroV1, true)
+	avroV1 = binary.AppendVarint(avroV1, ts.UnixMilli())
+
+	avroV3 := appendWireHeader(nil, v3)
+	avroV3 = appendAvroString(avroV3, "tom")
+	avroV3 = binary.AppendVarint(avroV3, 4)
+	avroV3 = appendAvroString(avroV3, "red")
+	avroV3 = binary.AppendVarint(avroV3, 25)
+	avroV3 = appendAvroBool(avroV3, true)
+	avroV3 = binary.AppendVarint(avroV3, ts.UnixMilli())
+	avroV3 = appendAvroString(avroV3, "s1")
+
+	avro := NewAvroSerializer(registry)
+	got, err := avro.Deserialize(context.Background(), "scores", avroV1)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.GameEvent{Player: "tom", BalloonColor: "red", Score: 25, FavoriteColorBonus: true, EventTS: ts}, got)
+	got, err = avro.Deserialize(context.Background(), "scores", avroV3)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.GameEvent{Player: "tom", BalloonColor: "red", Score: 25, FavoriteColorBonus: true, EventTS: ts, SessionID: "s1"}, got)
+	_, err = avro.Deserialize(context.Background(), "scores", appendWireHeader(nil, broken))
+	assert.ErrorIs(t, err, ErrSchemaMismatch)
+
+	// the first Protobuf version is read by field number
+	protoV1 := register(SchemaTypeProtobuf, "syntax = \"proto3\";\nmessage GameEvent {\n  string player = 1;\n  int32 score = 3;\n}\n")
+	registered++
+	data := appendWireHeader(nil, protoV1)
+	data = append(data, 0)
+	data = appendProtoBytes(data, 1, "tom")
+	data = appendProtoVarint(data, 3, 25)
+	got, err = NewProtobufSerializer(registry).Deserialize(context.Background(), "scores", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "tom", got.Player)
+	assert.Equal(t, 25, got.Score)
+
+	// the unknown fields of a later version are skipped, whatever their wire type
+	data = appendWireHeader(nil, protoV1)
+	data = append(data, 0)
+	data = appendProtoBytes(data, 1, "tom")
+	data = binary.AppendUvarint(data, 8<<3|wireFixed64)
+	data = binary.LittleEndian.AppendUint64(data, 1)
+	data = binary.AppendUvarint(data, 9<<3|wireFixed32)
+	data = binary.LittleEndian.AppendUint32(data, 2)
+	data = binary.AppendUvarint(data, 10<<3|wireStartGroup)
+	data = appendProtoVarint(data, 1, 3)
+	data = binary.AppendUvarint(data, 10<<3|wireEndGroup)
+	data = appendProtoVarint(data, 3, 25)
+	got, err = NewProtobufSerializer(registry).Deserialize(context.Background(), "scores", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "tom", got.Player)
+	assert.Equal(t, 25, got.Score)
+	_, err = NewProtobufSerializer(registry).Deserialize(context.Background(), "scores", data[:len(data)-5])
+	assert.ErrorIs(t, err, errProtobufShortBuffer)
+	_, err = NewProtobufSerializer(registry).Deserialize(context.Background(), "scores", avroV1)
+	assert.ErrorIs(t, err, ErrSchemaMismatch)
+
+	// reading looks the schemas up, it never registers one
+	assert.Len(t, fake.schemas, registered)
+}
+
+func TestRegistryBasicAuth(t *testing.T) {
+	fake := &fakeRegistry{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "balloon" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	registry, err := NewRegistryClient(srv.URL)
+	assert.NoError(t, err)
+
+	_, err = registry.RegisterSchema(context.Background(), "scores-value", SchemaTypeAvro, AvroSchema)
+	assert.Error(t, err)
+	registry.SetBasicAuth("balloon", "secret")
+	_, err = registry.RegisterSchema(context.Background(), "scores-value", SchemaTypeAvro, AvroSchema)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const recordSuffix = ".json"
+
+// Filter selects the session records of a room or played during a time range
+type Filter struct {
+	RoomID string
+	// From and To select the sessions that were running at some point of the range, zero leaves it open
+	From time.Time
+	To   time.Time
+}
+
+// match tells whether record passes the filter, the end of an active session is now
+func (f Filter) match(record *models.SessionRecord, now time.Time) bool {
+	if f.RoomID != "" && record.RoomID != f.RoomID {
+		return false
+	}
+	end := record.EndedAt
+	if record.Active || end.IsZero() {
+		end = now
+	}
+	if !f.From.IsZero() && end.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && record.StartedAt.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// RunningFunc tells whether the session is running in its room
+type RunningFunc func(roomID, sessionID string) bool
+
+// Store keeps the session records in memory and writes each of them to a JSON
+// file named after the session in a directory, an empty directory keeps them in memory only
+type Store struct {
+	mu      sync.RWMutex
+	dir     string
+	records map[string]*models.SessionRecord
+	// saved is when each record was last saved
+	saved map[string]time.Time
+}
+
+// Open opens or creates the store in dir, loading the records of the previous runs
+func Open(dir string) (*Store, error) {
+	s := &Store{
+		records: make(map[string]*models.SessionRecord),
+		saved:   make(map[string]time.Time),
+	}
+	if dir == "" {
+		return s, nil
+	}
+	s.dir = filepath.Clean(dir)
+	if err := os.MkdirAll(s.dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create sessions directory %s: %w", s.dir, err)
+	}
+	files, err := filepath.Glob(filepath.Join(s.dir, "*"+recordSuffix))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file) //nolint:gosec
+		if err != nil {
+			return nil, fmt.Errorf("failed to read session record %s: %w", file, err)
+		}
+		var record models.SessionRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("failed to parse session record %s: %w", file, err)
+		}
+		s.records[record.SessionID] = &record
+		if info, err := os.Stat(file); err == nil {
+			s.saved[record.SessionID] = info.ModTime().UTC()
+		}
+	}
+	return s, nil
+}
+
+// AbortStale ends the active sessions that are not running, e.g. left active by a crash,
+// they are marked aborted and end when their record was last saved. It returns the IDs of
+// the aborted sessions.
+func (s *Store) AbortStale(running RunningFunc) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	aborted := make([]string, 0)
+	for id, record := range s.records {
+		if !record.Active || running(record.RoomID, id) {
+			continue
+		}
+		stale := *record
+		stale.Active = false
+		stale.Aborted = true
+		stale.EndedAt = s.saved[id]
+		if stale.EndedAt.Before(stale.StartedAt) {
+			stale.EndedAt = stale.StartedAt
+		}
+		stale.DurationSeconds = stale.EndedAt.Sub(stale.StartedAt).Seconds()
+		if s.dir != "" {
+			if err := s.write(&stale); err != nil {
+				return aborted, err
+			}
+		}
+		s.records[id] = &stale
+		aborted = append(aborted, id)
+	}
+	sort.Strings(aborted)
+	return aborted, nil
+}
+
+// Dir returns the directory holding the session records
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save adds or replaces the record of its session
+func (s *Store) Save(record models.SessionRecord) error {
+	id := record.SessionID
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return fmt.Errorf("invalid session ID %q", id)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dir != "" {
+		if err := s.write(&record); err != nil {
+			return err
+		}
+	}
+	s.records[id] = &record
+	s.saved[id] = time.Now().UTC()
+	return nil
+}
+
+// write replaces the file of record by renaming a temporary file, so a crash never leaves half a record
+func (s *Store) write(record *models.SessionRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, record.SessionID+recordSuffix)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write session record %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write session record %s: %w", path, err)
+	}
+	return nil
+}
+
+// Get returns the record of the session id
+func (s *Store) Get(id string) (models.SessionRecord, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[id]
+	if !ok {
+		return models.SessionRecord{}, false
+	}
+	return *record, true
+}
+
+// List returns the records passing filter, the latest session first
+func (s *Store) List(filter Filter) []models.SessionRecord {
+	now := time.Now().UTC()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records := make([]models.SessionRecord, 0, len(s.records))
+	for _, record := range s.records {
+		if filter.match(record, now) {
+			records = append(records, *record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].StartedAt.After(records[j].StartedAt)
+	})
+	return records
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2025.  Kamesh Sampath <kamesh.sampath@hotmail.com>
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *   Unless required by applicable law or agreed to in writing, software
+ *   distributed under the License is distributed on an "AS IS" BASIS,
+ *   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *   See the License for the specific language governing permissions and
+ *   limitations under the License.
+ *
+ */
+package sessions
+
+import (
+	"github.com/kameshsampath/balloon-popper/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	twoPM := time.Date(2025, 3, 1, 14, 0, 0, 0, time.UTC)
+	morning := models.SessionRecord{
+		SessionID:    "s1",
+		RoomID:       "default",
+		StartedAt:    twoPM.Add(-4 * time.Hour),
+		EndedAt:      twoPM.Add(-3 * time.Hour),
+		Participants: []string{"tom"},
+	}
+	afternoon := models.SessionRecord{
+		SessionID:    "s2",
+		RoomID:       "lobby",
+		Active:       true,
+		StartedAt:    twoPM.Add(5 * time.Minute),
+		Participants: []string{"jerry"},
+	}
+	assert.NoError(t, store.Save(morning))
+	assert.NoError(t, store.Save(afternoon))
+	assert.Error(t, store.Save(models.SessionRecord{SessionID: "../s3"}))
+
+	afternoon.Active = false
+	afternoon.EndedAt = afternoon.StartedAt.Add(10 * time.Minute)
+	afternoon.DurationSeconds = 600
+	afternoon.Standings = []models.PlayerScore{{Rank: 1, Player: "jerry", TotalScore: 100}}
+	assert.NoError(t, store.Save(afternoon))
+
+	// the records survive a restart
+	store, err = Open(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	got, ok := store.Get("s2")
+	assert.True(t, ok)
+	assert.Equal(t, afternoon, got)
+	_, ok = store.Get("nope")
+	assert.False(t, ok)
+
+	ids := func(records []models.SessionRecord) []string {
+		var ids []string
+		for _, r := range records {
+			ids = append(ids, r.SessionID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"s2", "s1"}, ids(store.List(Filter{})))
+	assert.Equal(t, []string{"s2"}, ids(store.List(Filter{From: twoPM, To: twoPM.Add(time.Hour)})))
+	assert.Equal(t, []string{"s1"}, ids(store.List(Filter{RoomID: "default"})))
+	assert.Empty(t, store.List(Filter{To: twoPM.Add(-5 * time.Hour)}))
+}
+
+func TestMemoryStore(t *testing.T) {
+	store, err := Open("")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, store.Save(models.SessionRecord{SessionID: "s1", Active: true, StartedAt: time.Now().UTC()}))
+	assert.Len(t, store.List(Filter{From: time.Now().UTC()}), 1, "active sessions run until now")
+}
+
+func TestAbortStale(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	started := time.Now().UTC().Add(-time.Hour)
+	for _, id := range []string{"crashed", "running"} {
+		assert.NoError(t, store.Save(models.SessionRecord{SessionID: id, RoomID: "default", Active: true, StartedAt: started}))
+	}
+
+	// a restart finds both active, only one of them runs again
+	store, err = Open(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	aborted, err := store.AbortStale(func(_, sessionID string) bool { return sessionID == "running" })
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"crashed"}, aborted)
+
+	// the abort is saved
+	reopened, err := Open(dir)
+	assert.NoError(t, err)
+	for _, s := range []*Store{store, reopened} {
+		record, ok := s.Get("crashed")
+		assert.True(t, ok)
+		assert.False(t, record.Active)
+		assert.True(t, record.Aborted)
+		assert.False(t, record.EndedAt.Before(record.StartedAt))
+		record, _ = s.Get("running")
+		assert.True(t, record.Active)
+	}
+}
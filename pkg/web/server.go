@@ -88,17 +88,31 @@ func (s *Server) Start() error {
 	{
 		health.GET("/live", ec.Live)
 		health.GET("/ready", ec.Ready)
+		health.GET("/outbox", ec.OutboxStatus)
+		health.GET("/pipeline", ec.PipelineStatus)
 	}
 	//Game  endpoints /
 	router.GET("/", ec.Root)
 	router.GET("/index.html", ec.Root)
 	router.GET("/config", ec.GetConfig)
 	router.GET("/status", ec.GameStatus)
+	router.GET("/leaderboard", ec.GetLeaderboard)
+	router.GET("/leaderboard/:player", ec.GetPlayerScore)
+	//Session history endpoints /sessions
+	router.GET("/sessions", ec.ListSessions)
+	router.GET("/sessions/:id", ec.GetSession)
+	//Room endpoints /rooms, the room is its ID or join code
+	rooms := router.Group("/rooms")
+	{
+		rooms.GET("/:room/config", ec.RoomConfig)
+		rooms.GET("/:room/status", ec.RoomStatus)
+	}
 
 	//WebSockets
 	ws := router.Group("/ws")
 	{
 		ws.GET("/:player", ec.WebSocket)
+		ws.GET("/:room/:player", ec.RoomWebSocket)
 	}
 	//Protected Game Admin endpoints /admin
 	admin := router.Group("/admin")
@@ -112,6 +126,21 @@ func (s *Server) Start() error {
 		admin.Use(echojwt.WithConfig(config))
 		admin.POST("/start", ec.StartGame)
 		admin.POST("/stop", ec.StopGame)
+		admin.POST("/pause", ec.PauseGame)
+		admin.POST("/resume", ec.ResumeGame)
+		admin.GET("/schedules", ec.ListSchedules)
+		admin.POST("/schedules", ec.CreateSchedule)
+		admin.DELETE("/schedules/:id", ec.DeleteSchedule)
+		admin.GET("/flags", ec.ListFlags)
+		admin.GET("/config", ec.GetGameConfig)
+		admin.PUT("/config", ec.UpdateGameConfig)
+		admin.GET("/rooms", ec.ListRooms)
+		admin.POST("/rooms", ec.CreateRoom)
+		admin.GET("/rooms/:id", ec.GetRoom)
+		admin.POST("/rooms/:id/start", ec.StartRoom)
+		admin.POST("/rooms/:id/stop", ec.StopRoom)
+		admin.POST("/rooms/:id/pause", ec.PauseRoom)
+		admin.POST("/rooms/:id/resume", ec.ResumeRoom)
 	}
 	// Start server
 	port := strconv.Itoa(s.port)
@@ -122,8 +151,10 @@ func (s *Server) Start() error {
 }
 
 func (s *Server) Stop() error {
-	if err := s.endPointsConfig.KafkaProducer.Stop(); err != nil {
-		return fmt.Errorf("failed to stop Kafka producer: %v", err)
+	if sink := s.endPointsConfig.ScoreSink; sink != nil {
+		if err := sink.Stop(); err != nil {
+			return fmt.Errorf("failed to stop score sink: %v", err)
+		}
 	}
 	return s.echo.Close()
 }
@@ -15,6 +15,14 @@
  *
  */
 
+// The room to play in from ?room=<id or join code>, the default game when not set
+const gameRoom = new URLSearchParams(window.location.search).get("room");
+
+// roomURL maps an endpoint of the default game to the one of the room
+function roomURL(path) {
+    return gameRoom ? `/rooms/${encodeURIComponent(gameRoom)}${path}` : path;
+}
+
 class BalloonGame {
     constructor(character, playerName) {
         console.log("Initializing game for:", playerName, "as", character);
@@ -64,10 +72,12 @@ class BalloonGame {
         this.canvas.addEventListener("click", (e) => this.handleClick(e));
 
         // Game Config
-        fetch("/config")
+        fetch(roomURL("/config"))
             .then((response) => response.json())
             .then((config) => {
                 this.gameConfig = config;
+                // The server spawns the balloons and scores the pops by balloon ID
+                this.serverSpawning = config.balloon_spawning === "server";
                 console.log("Loaded game config:", config);
 
                 // Set negative color to one not in any favorite colors
@@ -84,19 +94,14 @@ class BalloonGame {
             );
     }
 
-    // Set negative colors to be EXACTLY the same as favorite colors
+    // Set negative colors to the look-alikes of the favorite colors the server treats as negative hits
     setNegativeColor() {
         if (!this.gameConfig || !this.gameConfig.colors) return;
 
-        // Get current character's favorite colors - use these EXACT colors for negative balloons
-        this.negativeColors = this.gameConfig.character_favorites[this.character] || [];
+        const negatives = this.gameConfig.character_negatives || {};
+        this.negativeColors = negatives[this.character] || [];
 
-        console.log("Set negative balloon colors to match favorite colors:", this.negativeColors);
-
-        // If we couldn't get favorite colors, fall back to black
-        if (this.negativeColors.length === 0) {
-            this.negativeColors = ["black"];
-        }
+        console.log("Set negative balloon colors:", this.negativeColors);
     }
 
     // Start level timer to increase difficulty over time
@@ -150,8 +155,11 @@ class BalloonGame {
 
     connectWebSocket() {
         console.log("Connecting WebSocket for player:", this.playerName);
+        const wsPath = gameRoom
+            ? `/ws/${encodeURIComponent(gameRoom)}/${this.playerName}`
+            : `/ws/${this.playerName}`;
         this.ws = new WebSocket(
-            `ws://${window.location.host}/ws/${this.playerName}`
+            `ws://${window.location.host}${wsPath}?character=${encodeURIComponent(this.character)}`
         );
 
         this.ws.onopen = () => {
@@ -162,7 +170,25 @@ class BalloonGame {
             const data = JSON.parse(event.data);
             console.log("Received WebSocket message:", data);
             if (data.type === "score_update") {
-                this.updateScore(data.event);
+                this.updateScore(data);
+            } else if (data.type === "balloon_spawned") {
+                this.addServerBalloon(data.balloon);
+            } else if (data.type === "error") {
+                console.warn("Pop rejected by the server:", data.code, data.message);
+            } else if (data.type === "game_paused") {
+                this.isActive = false;
+                document.getElementById("gameStatus").textContent = "Game is Paused";
+            } else if (data.type === "game_resumed") {
+                this.isActive = true;
+                document.getElementById("gameStatus").textContent = "Game is Active";
+            } else if (data.type === "config_changed") {
+                // Take the colors, scores and penalties the server uses from now on
+                this.gameConfig = data.config;
+                this.serverSpawning = data.config.balloon_spawning === "server";
+                this.setNegativeColor();
+            } else if (data.type === "time_remaining") {
+                document.getElementById("timeRemaining").textContent =
+                    data.remaining_seconds > 0 ? `Time left: ${data.remaining_seconds}s` : "Time's up!";
             }
         };
     }
@@ -184,7 +210,7 @@ class BalloonGame {
         let isBonus = false;
         let isNegative = false;
 
-        if (rand < this.negativeProbability) {
+        if (rand < this.negativeProbability && this.negativeColors.length > 0) {
             isNegative = true;
         } else if (rand < this.negativeProbability + bonusProbability) {
             isBonus = true;
@@ -243,6 +269,28 @@ class BalloonGame {
         this.balloons.push(balloon);
     }
 
+    // Adds a balloon spawned by the server, it can be popped until it expires
+    addServerBalloon(spawned) {
+        this.balloons.push({
+            id: spawned.id,
+            expiresAt: Date.parse(spawned.expires_at),
+            x: spawned.x * (this.canvas.width - 60) + 30,
+            y: this.canvas.height + 30,
+            radius: this.balloonRadius,
+            color: spawned.color,
+            speed: spawned.speed,
+            bobOffset: 0,
+            bobSpeed: Math.random() * 0.05 + 0.02,
+            bobTime: Math.random() * Math.PI * 2,
+            scale: 1,
+            isBonus: spawned.kind === "bonus",
+            isNegative: spawned.kind === "negative",
+            isFast: false,
+            sparkleAngle: 0,
+            spikes: spawned.kind === "negative" ? 5 : 0,
+        });
+    }
+
     createPopEffect(x, y, color) {
         const particles = [];
         const particleCount = 8;
@@ -276,7 +324,7 @@ class BalloonGame {
                 balloon.sparkleAngle += 0.05;
             }
 
-            if (balloon.y < -50) {
+            if (balloon.y < -50 || (balloon.expiresAt && Date.now() > balloon.expiresAt)) {
                 this.balloons.splice(i, 1);
             }
         }
@@ -319,28 +367,22 @@ class BalloonGame {
                     balloon.color
                 );
 
-                // Handle negative balloon locally with immediate feedback
-                if (balloon.isNegative) {
-                    this.handleNegativeBalloon();
-                    // Update score locally
-                    this.score = Math.max(0, this.score - 10);
-                    const scoreElement = document.getElementById("score");
-                    if (scoreElement) {
-                        scoreElement.textContent = `Score: ${this.score}`;
-                        scoreElement.classList.add("negative");
-                        setTimeout(() => scoreElement.classList.remove("negative"), 1000);
-                    }
-                }
-
                 // Send pop event to server
-                if (this.ws && this.ws.readyState === WebSocket.OPEN) {
+                if (this.ws && this.ws.readyState === WebSocket.OPEN && balloon.id) {
+                    this.ws.send(
+                        JSON.stringify({
+                            type: "pop",
+                            balloon_id: balloon.id,
+                            player: this.playerName,
+                        })
+                    );
+                } else if (this.ws && this.ws.readyState === WebSocket.OPEN) {
                     this.ws.send(
                         JSON.stringify({
                             balloon_color: balloon.color,
                             event_ts: new Date().toISOString(),
                             player: this.playerName,
                             character: this.character,
-                            negative_hit: balloon.isNegative
                         })
                     );
                 }
@@ -352,12 +394,9 @@ class BalloonGame {
     }
 
     handleNegativeBalloon() {
-        this.negativeHits++;
-
-        // Update UI
+        // Flash the counter, the server counts the negative hits
         const negativeElement = document.getElementById("negativeHits");
         if (negativeElement) {
-            negativeElement.textContent = `Negative Hits: ${this.negativeHits}`;
             negativeElement.classList.add("negative");
             setTimeout(() => negativeElement.classList.remove("negative"), 1000);
         }
@@ -571,35 +610,31 @@ class BalloonGame {
         this.drawGameInfo();
     }
 
-    updateScore(eventData) {
-        console.log("Updating score:", eventData);
+    // The server decides the kind of each hit and keeps the running total, the penalty of a
+    // negative hit included, and the hit counts of the player
+    updateScore(update) {
+        console.log("Updating score:", update);
+        const eventData = update.event;
+        this.score = update.total;
+        this.bonusHits = update.bonus_hits;
+        this.regularHits = update.regular_hits;
+        this.negativeHits = update.negative_hits;
 
-
-
-        if (eventData.negative_hit) {
-            this.negativeHits++;
-            // Handle scoring update
-            this.score += eventData.score;
-        } else {
-            if (eventData.favorite_color_bonus) {
-                this.bonusHits++;
-            } else {
-                // Handle scoring update
-                this.score += eventData.score;
-                this.regularHits++;
+        const scoreElement = document.getElementById("score");
+        if (update.penalty > 0 || eventData.score < 0) {
+            this.handleNegativeBalloon();
+            if (scoreElement) {
+                scoreElement.classList.add("negative");
+                setTimeout(() => scoreElement.classList.remove("negative"), 1000);
             }
-            // Add animations for positive scores
-            const scoreElement = document.getElementById("score");
+        } else if (eventData.favorite_color_bonus) {
             if (scoreElement) {
-                if (eventData.favorite_color_bonus) {
-                    scoreElement.classList.add("bonus");
-                    setTimeout(() => scoreElement.classList.remove("bonus"), 1000);
-                }
+                scoreElement.classList.add("bonus");
+                setTimeout(() => scoreElement.classList.remove("bonus"), 1000);
             }
         }
 
         // Update UI
-        const scoreElement = document.getElementById("score");
         const bonusElement = document.getElementById("bonusHits");
         const regularElement = document.getElementById("regularHits");
         const negativeElement = document.getElementById("negativeHits");
@@ -619,7 +654,7 @@ class BalloonGame {
     gameLoop() {
         if (this.isActive) {
             const currentTime = Date.now();
-            if (currentTime - this.lastSpawnTime > this.spawnInterval) {
+            if (!this.serverSpawning && currentTime - this.lastSpawnTime > this.spawnInterval) {
                 this.createBalloon();
                 this.lastSpawnTime = currentTime;
             }